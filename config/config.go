@@ -0,0 +1,479 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/pkg/features"
+)
+
+// Default values applied when an optional setting is not provided
+const (
+	DefaultDBConnectionString = "file:forum.db?_foreign_keys=on"
+	DefaultDBPath             = "forum.db"
+	DefaultDBMaxConnections   = 10
+	DefaultJWTExpirationHours = 24
+	DefaultServerHost         = "localhost"
+	DefaultServerPort         = "8080"
+	DefaultStaticDir          = "./frontend/static"
+	DefaultMigrationPath      = "./db/migrations/001_create_tables.sql"
+	DefaultLogLevel           = "info"
+	DefaultCORSExemptPaths    = "/healthz,/metrics"
+	DefaultCORSAllowedMethods = "GET,POST,PUT,DELETE,OPTIONS"
+	DefaultCORSAllowedHeaders = "Authorization,Content-Type"
+	DefaultFeedSort           = "newest"
+	DefaultStorageBackend     = "local"
+	DefaultUploadsDir         = "./data/uploads"
+	DefaultUploadsBaseURL     = "/uploads"
+	DefaultPostMinTitle       = 1
+	DefaultPostMinContent     = 1
+	DefaultLoginMaxFailures   = 5
+	DefaultLoginFailureWindow = 15 * time.Minute
+	DefaultPurgeInterval      = 24 * time.Hour
+	DefaultBatchMaxIDs        = 100
+
+	DefaultSessionCleanupInterval = time.Hour
+)
+
+// Default HTTP server timeouts; ReadHeaderTimeout in particular bounds how
+// long a slowloris client can hold a connection open
+const (
+	DefaultServerReadHeaderTimeout = 5 * time.Second
+	DefaultServerReadTimeout       = 15 * time.Second
+	DefaultServerWriteTimeout      = 30 * time.Second
+	DefaultServerIdleTimeout       = 60 * time.Second
+	// DefaultServerShutdownTimeout is how long in-flight requests get to
+	// finish once the server is asked to stop
+	DefaultServerShutdownTimeout = 15 * time.Second
+)
+
+// MinJWTSecretLength is the minimum number of bytes accepted for JWT_SECRET;
+// shorter HMAC keys make tokens practical to brute force
+const MinJWTSecretLength = 32
+
+// Config holds the typed, validated application settings
+type Config struct {
+	DBConnectionString string
+	DBReplicaString    string
+	DBMaxConnections   int
+	JWTSecret          string
+	JWTExpiration      time.Duration
+	ServerHost         string
+	ServerPort         string
+	ServerTimeouts     ServerTimeouts
+	StaticDir          string
+	MigrationPath      string
+	LogLevel           string
+	LogOutputPath      string
+	CORSAllowedOrigins []string
+	CORSExemptPaths    []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+	ModeratorUserIDs   []int
+	AdminUserIDs       []int
+	FeedDefaultSort    string
+	StorageBackend     string
+	UploadsDir         string
+	UploadsBaseURL     string
+	UploadsSigningKey  string
+	JSONStringIDs      bool
+	PostMinTitle       int
+	PostMinContent     int
+	DefaultCategoryID  int64
+	LoginMaxFailures   int
+	LoginFailureWindow time.Duration
+	PurgeDeletedAfter  time.Duration
+	PurgeInterval      time.Duration
+	// SessionCleanupInterval is how often expired sessions are deleted
+	SessionCleanupInterval time.Duration
+	// InteractionBatchMaxIDs caps the distinct IDs one batch interaction
+	// lookup may request
+	InteractionBatchMaxIDs int
+	DebugMode              bool
+	Features               *features.Flags
+}
+
+// ServerTimeouts bound how long the HTTP server waits on each phase of a
+// connection
+type ServerTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+	Shutdown   time.Duration
+}
+
+// Load reads the settings from the environment and validates them, returning
+// an error describing every missing or invalid value
+func Load() (*Config, error) {
+	cfg := &Config{
+		DBConnectionString: strings.TrimSpace(os.Getenv("DB_CONNECTION_STRING")),
+		DBReplicaString:    strings.TrimSpace(os.Getenv("DB_REPLICA_CONNECTION_STRING")),
+		JWTSecret:          os.Getenv("JWT_SECRET"),
+		ServerHost:         getEnv("SERVER_HOST", DefaultServerHost),
+		ServerPort:         getEnv("SERVER_PORT", DefaultServerPort),
+		StaticDir:          getEnv("STATIC_DIR", DefaultStaticDir),
+		MigrationPath:      getEnv("MIGRATION_PATH", DefaultMigrationPath),
+		LogLevel:           strings.ToLower(getEnv("LOG_LEVEL", DefaultLogLevel)),
+		LogOutputPath:      os.Getenv("LOG_OUTPUT_PATH"),
+		CORSAllowedOrigins: splitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		CORSExemptPaths:    splitList(getEnv("CORS_EXEMPT_PATHS", DefaultCORSExemptPaths)),
+		CORSAllowedMethods: splitList(strings.ToUpper(getEnv("CORS_ALLOWED_METHODS", DefaultCORSAllowedMethods))),
+		CORSAllowedHeaders: splitList(getEnv("CORS_ALLOWED_HEADERS", DefaultCORSAllowedHeaders)),
+		FeedDefaultSort:    strings.ToLower(getEnv("FEED_DEFAULT_SORT", DefaultFeedSort)),
+		StorageBackend:     strings.ToLower(getEnv("STORAGE_BACKEND", DefaultStorageBackend)),
+		UploadsDir:         getEnv("UPLOADS_DIR", DefaultUploadsDir),
+		UploadsBaseURL:     getEnv("UPLOADS_BASE_URL", DefaultUploadsBaseURL),
+		UploadsSigningKey:  os.Getenv("UPLOADS_SIGNING_KEY"),
+	}
+
+	var errs []error
+
+	if cfg.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	} else if len(cfg.JWTSecret) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d bytes, got %d", MinJWTSecretLength, len(cfg.JWTSecret)))
+	}
+
+	// Without a full connection string, compose one from the discrete settings
+	if cfg.DBConnectionString == "" {
+		foreignKeys, err := getEnvBool("DB_FOREIGN_KEYS", true)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		journalMode := strings.ToUpper(strings.TrimSpace(os.Getenv("DB_JOURNAL_MODE")))
+		switch journalMode {
+		case "", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+		default:
+			errs = append(errs, fmt.Errorf("DB_JOURNAL_MODE must be one of delete, truncate, persist, memory, wal, off, got %q", journalMode))
+		}
+
+		cfg.DBConnectionString = composeConnectionString(getEnv("DB_PATH", DefaultDBPath), foreignKeys, journalMode)
+	}
+
+	maxConnections, err := getEnvInt("DB_MAX_CONNECTIONS", DefaultDBMaxConnections)
+	if err != nil {
+		errs = append(errs, err)
+	} else if maxConnections <= 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_CONNECTIONS must be positive, got %d", maxConnections))
+	}
+	cfg.DBMaxConnections = maxConnections
+
+	expirationHours, err := getEnvInt("JWT_EXPIRATION_HOURS", DefaultJWTExpirationHours)
+	if err != nil {
+		errs = append(errs, err)
+	} else if expirationHours <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_EXPIRATION_HOURS must be positive, got %d", expirationHours))
+	}
+	cfg.JWTExpiration = time.Duration(expirationHours) * time.Hour
+
+	if port, err := strconv.Atoi(cfg.ServerPort); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("SERVER_PORT must be a number between 1 and 65535, got %q", cfg.ServerPort))
+	}
+
+	for _, timeout := range []struct {
+		key      string
+		fallback time.Duration
+		target   *time.Duration
+	}{
+		{"SERVER_READ_HEADER_TIMEOUT", DefaultServerReadHeaderTimeout, &cfg.ServerTimeouts.ReadHeader},
+		{"SERVER_READ_TIMEOUT", DefaultServerReadTimeout, &cfg.ServerTimeouts.Read},
+		{"SERVER_WRITE_TIMEOUT", DefaultServerWriteTimeout, &cfg.ServerTimeouts.Write},
+		{"SERVER_IDLE_TIMEOUT", DefaultServerIdleTimeout, &cfg.ServerTimeouts.Idle},
+		{"SERVER_SHUTDOWN_TIMEOUT", DefaultServerShutdownTimeout, &cfg.ServerTimeouts.Shutdown},
+	} {
+		value, err := getEnvDuration(timeout.key, timeout.fallback)
+		if err != nil {
+			errs = append(errs, err)
+		} else if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", timeout.key, value))
+		}
+		*timeout.target = value
+	}
+
+	for _, path := range cfg.CORSExemptPaths {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("CORS_EXEMPT_PATHS entries must start with /, got %q", path))
+		}
+	}
+
+	for _, value := range splitList(os.Getenv("MODERATOR_USER_IDS")) {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			errs = append(errs, fmt.Errorf("MODERATOR_USER_IDS entries must be positive integers, got %q", value))
+			continue
+		}
+		cfg.ModeratorUserIDs = append(cfg.ModeratorUserIDs, id)
+	}
+
+	for _, value := range splitList(os.Getenv("ADMIN_USER_IDS")) {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			errs = append(errs, fmt.Errorf("ADMIN_USER_IDS entries must be positive integers, got %q", value))
+			continue
+		}
+		cfg.AdminUserIDs = append(cfg.AdminUserIDs, id)
+	}
+
+	switch cfg.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error, got %q", cfg.LogLevel))
+	}
+
+	switch cfg.FeedDefaultSort {
+	case "newest", "oldest", "trending":
+	default:
+		errs = append(errs, fmt.Errorf("FEED_DEFAULT_SORT must be one of newest, oldest, trending, got %q", cfg.FeedDefaultSort))
+	}
+
+	// Attachment URLs fall back to the JWT secret when no dedicated key is set
+	if cfg.UploadsSigningKey == "" {
+		cfg.UploadsSigningKey = cfg.JWTSecret
+	} else if len(cfg.UploadsSigningKey) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("UPLOADS_SIGNING_KEY must be at least %d bytes, got %d", MinJWTSecretLength, len(cfg.UploadsSigningKey)))
+	}
+
+	if cfg.StorageBackend != "local" {
+		errs = append(errs, fmt.Errorf("STORAGE_BACKEND must be one of local, got %q", cfg.StorageBackend))
+	}
+
+	minTitle, err := getEnvInt("POST_MIN_TITLE_LENGTH", DefaultPostMinTitle)
+	if err != nil {
+		errs = append(errs, err)
+	} else if minTitle <= 0 {
+		errs = append(errs, fmt.Errorf("POST_MIN_TITLE_LENGTH must be positive, got %d", minTitle))
+	}
+	cfg.PostMinTitle = minTitle
+
+	minContent, err := getEnvInt("POST_MIN_CONTENT_LENGTH", DefaultPostMinContent)
+	if err != nil {
+		errs = append(errs, err)
+	} else if minContent <= 0 {
+		errs = append(errs, fmt.Errorf("POST_MIN_CONTENT_LENGTH must be positive, got %d", minContent))
+	}
+	cfg.PostMinContent = minContent
+
+	// Zero, the default, turns this off: posts created without a category are
+	// saved without any
+	defaultCategoryID, err := getEnvInt("DEFAULT_CATEGORY_ID", 0)
+	if err != nil {
+		errs = append(errs, err)
+	} else if defaultCategoryID < 0 {
+		errs = append(errs, fmt.Errorf("DEFAULT_CATEGORY_ID must not be negative, got %d", defaultCategoryID))
+	}
+	cfg.DefaultCategoryID = int64(defaultCategoryID)
+
+	maxFailures, err := getEnvInt("LOGIN_MAX_FAILURES", DefaultLoginMaxFailures)
+	if err != nil {
+		errs = append(errs, err)
+	} else if maxFailures <= 0 {
+		errs = append(errs, fmt.Errorf("LOGIN_MAX_FAILURES must be positive, got %d", maxFailures))
+	}
+	cfg.LoginMaxFailures = maxFailures
+
+	failureWindow, err := getEnvDuration("LOGIN_FAILURE_WINDOW", DefaultLoginFailureWindow)
+	if err != nil {
+		errs = append(errs, err)
+	} else if failureWindow <= 0 {
+		errs = append(errs, fmt.Errorf("LOGIN_FAILURE_WINDOW must be positive, got %s", failureWindow))
+	}
+	cfg.LoginFailureWindow = failureWindow
+
+	purgeAfter, err := getEnvDuration("PURGE_DELETED_AFTER", 0)
+	if err != nil {
+		errs = append(errs, err)
+	} else if purgeAfter < 0 {
+		errs = append(errs, fmt.Errorf("PURGE_DELETED_AFTER must not be negative, got %s", purgeAfter))
+	}
+	cfg.PurgeDeletedAfter = purgeAfter
+
+	purgeInterval, err := getEnvDuration("PURGE_INTERVAL", DefaultPurgeInterval)
+	if err != nil {
+		errs = append(errs, err)
+	} else if purgeInterval <= 0 {
+		errs = append(errs, fmt.Errorf("PURGE_INTERVAL must be positive, got %s", purgeInterval))
+	}
+	cfg.PurgeInterval = purgeInterval
+
+	sessionCleanupInterval, err := getEnvDuration("SESSION_CLEANUP_INTERVAL", DefaultSessionCleanupInterval)
+	if err != nil {
+		errs = append(errs, err)
+	} else if sessionCleanupInterval <= 0 {
+		errs = append(errs, fmt.Errorf("SESSION_CLEANUP_INTERVAL must be positive, got %s", sessionCleanupInterval))
+	}
+	cfg.SessionCleanupInterval = sessionCleanupInterval
+
+	batchMaxIDs, err := getEnvInt("INTERACTION_BATCH_MAX_IDS", DefaultBatchMaxIDs)
+	if err != nil {
+		errs = append(errs, err)
+	} else if batchMaxIDs <= 0 {
+		errs = append(errs, fmt.Errorf("INTERACTION_BATCH_MAX_IDS must be positive, got %d", batchMaxIDs))
+	}
+	cfg.InteractionBatchMaxIDs = batchMaxIDs
+
+	jsonStringIDs, err := getEnvBool("JSON_STRING_IDS", false)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.JSONStringIDs = jsonStringIDs
+
+	debugMode, err := getEnvBool("DEBUG_MODE", false)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.DebugMode = debugMode
+
+	flags, err := features.Parse(splitList(os.Getenv("FEATURE_FLAGS")))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("FEATURE_FLAGS: %w", err))
+	}
+	cfg.Features = flags
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+// composeConnectionString builds a SQLite DSN from its discrete parts; an
+// empty journal mode leaves the driver default in place
+func composeConnectionString(path string, foreignKeys bool, journalMode string) string {
+	foreignKeysValue := "off"
+	if foreignKeys {
+		foreignKeysValue = "on"
+	}
+
+	dsn := "file:" + path + "?_foreign_keys=" + foreignKeysValue
+	if journalMode != "" {
+		dsn += "&_journal_mode=" + journalMode
+	}
+	return dsn
+}
+
+// redactedValue replaces secrets in Redacted output
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the config that is safe to show to operators,
+// with signing keys and any password in the DB connection string masked
+func (c *Config) Redacted() Config {
+	redacted := *c
+	if redacted.JWTSecret != "" {
+		redacted.JWTSecret = redactedValue
+	}
+	if redacted.UploadsSigningKey != "" {
+		redacted.UploadsSigningKey = redactedValue
+	}
+	redacted.DBConnectionString = redactConnectionString(c.DBConnectionString)
+	redacted.DBReplicaString = redactConnectionString(c.DBReplicaString)
+	redacted.CORSAllowedOrigins = append([]string(nil), c.CORSAllowedOrigins...)
+	redacted.CORSExemptPaths = append([]string(nil), c.CORSExemptPaths...)
+	redacted.CORSAllowedMethods = append([]string(nil), c.CORSAllowedMethods...)
+	redacted.CORSAllowedHeaders = append([]string(nil), c.CORSAllowedHeaders...)
+	redacted.ModeratorUserIDs = append([]int(nil), c.ModeratorUserIDs...)
+	redacted.AdminUserIDs = append([]int(nil), c.AdminUserIDs...)
+	return redacted
+}
+
+// redactConnectionString masks the password in a DSN, whether given as URL
+// user info (user:pass@host) or as a query parameter such as _auth_pass
+func redactConnectionString(dsn string) string {
+	base, query, hasQuery := strings.Cut(dsn, "?")
+
+	if at := strings.LastIndex(base, "@"); at >= 0 {
+		userInfo := base[:at]
+		prefix := ""
+		if scheme := strings.Index(userInfo, "://"); scheme >= 0 {
+			prefix, userInfo = userInfo[:scheme+3], userInfo[scheme+3:]
+		}
+		if user, _, hasPassword := strings.Cut(userInfo, ":"); hasPassword {
+			base = prefix + user + ":" + redactedValue + base[at:]
+		}
+	}
+
+	if !hasQuery {
+		return base
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if strings.Contains(strings.ToLower(key), "pass") {
+			params[i] = key + "=" + redactedValue
+		}
+	}
+	return base + "?" + strings.Join(params, "&")
+}
+
+// Address returns the listen address for the configured port
+func (c *Config) Address() string {
+	return ":" + c.ServerPort
+}
+
+// getEnv returns the value of an environment variable or a fallback when unset
+func getEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt parses an integer environment variable, returning the fallback when unset
+func getEnvInt(key string, fallback int) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be an integer, got %q", key, value)
+	}
+	return parsed, nil
+}
+
+// getEnvDuration parses a duration environment variable such as "15s",
+// returning the fallback when unset
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be a duration such as 15s, got %q", key, value)
+	}
+	return parsed, nil
+}
+
+// getEnvBool parses a boolean environment variable, returning the fallback when unset
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be a boolean, got %q", key, value)
+	}
+	return parsed, nil
+}
+
+// splitList splits a comma separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,445 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"forum/pkg/features"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv sets every configuration variable for the duration of the test,
+// so values from the developer's environment do not leak into assertions
+func setEnv(t *testing.T, overrides map[string]string) {
+	env := map[string]string{
+		"DB_CONNECTION_STRING":         "file:test.db?_foreign_keys=on",
+		"DB_REPLICA_CONNECTION_STRING": "file:replica.db?mode=ro",
+		"DB_PATH":                      "",
+		"DB_FOREIGN_KEYS":              "",
+		"DB_JOURNAL_MODE":              "",
+		"DB_MAX_CONNECTIONS":           "5",
+		"JWT_SECRET":                   "a-sufficiently-long-test-secret-value",
+		"JWT_EXPIRATION_HOURS":         "12",
+		"SERVER_HOST":                  "localhost",
+		"SERVER_PORT":                  "9090",
+		"SERVER_READ_HEADER_TIMEOUT":   "2s",
+		"SERVER_READ_TIMEOUT":          "10s",
+		"SERVER_WRITE_TIMEOUT":         "20s",
+		"SERVER_IDLE_TIMEOUT":          "2m",
+		"SERVER_SHUTDOWN_TIMEOUT":      "5s",
+		"STATIC_DIR":                   "./frontend/static",
+		"MIGRATION_PATH":               "./db/migrations/001_create_tables.sql",
+		"LOG_LEVEL":                    "debug",
+		"LOG_OUTPUT_PATH":              "",
+		"CORS_ALLOWED_ORIGINS":         "http://localhost:8080, http://127.0.0.1:8080",
+		"CORS_EXEMPT_PATHS":            "/healthz",
+		"CORS_ALLOWED_METHODS":         "get, post",
+		"CORS_ALLOWED_HEADERS":         "Content-Type, X-Request-ID",
+		"MODERATOR_USER_IDS":           "1, 7",
+		"ADMIN_USER_IDS":               "1",
+		"FEED_DEFAULT_SORT":            "Trending",
+		"STORAGE_BACKEND":              "local",
+		"UPLOADS_DIR":                  "/tmp/forum-uploads",
+		"UPLOADS_BASE_URL":             "/files",
+		"UPLOADS_SIGNING_KEY":          "a-separate-attachment-signing-key",
+		"JSON_STRING_IDS":              "true",
+		"POST_MIN_TITLE_LENGTH":        "3",
+		"POST_MIN_CONTENT_LENGTH":      "20",
+		"DEFAULT_CATEGORY_ID":          "4",
+		"LOGIN_MAX_FAILURES":           "3",
+		"LOGIN_FAILURE_WINDOW":         "5m",
+		"PURGE_DELETED_AFTER":          "720h",
+		"PURGE_INTERVAL":               "6h",
+		"SESSION_CLEANUP_INTERVAL":     "30m",
+		"INTERACTION_BATCH_MAX_IDS":    "25",
+		"DEBUG_MODE":                   "true",
+		"FEATURE_FLAGS":                "moderation_queue",
+	}
+	for key, value := range overrides {
+		env[key] = value
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func TestLoadValidConfig(t *testing.T) {
+	setEnv(t, nil)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "file:test.db?_foreign_keys=on", cfg.DBConnectionString)
+	assert.Equal(t, "file:replica.db?mode=ro", cfg.DBReplicaString)
+	assert.Equal(t, 5, cfg.DBMaxConnections)
+	assert.Equal(t, 12*time.Hour, cfg.JWTExpiration)
+	assert.Equal(t, "9090", cfg.ServerPort)
+	assert.Equal(t, ":9090", cfg.Address())
+	assert.Equal(t, ServerTimeouts{ReadHeader: 2 * time.Second, Read: 10 * time.Second, Write: 20 * time.Second, Idle: 2 * time.Minute, Shutdown: 5 * time.Second}, cfg.ServerTimeouts)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, []string{"http://localhost:8080", "http://127.0.0.1:8080"}, cfg.CORSAllowedOrigins)
+	assert.Equal(t, []string{"/healthz"}, cfg.CORSExemptPaths)
+	assert.Equal(t, []string{"GET", "POST"}, cfg.CORSAllowedMethods)
+	assert.Equal(t, []string{"Content-Type", "X-Request-ID"}, cfg.CORSAllowedHeaders)
+	assert.Equal(t, []int{1, 7}, cfg.ModeratorUserIDs)
+	assert.Equal(t, []int{1}, cfg.AdminUserIDs)
+	assert.Equal(t, "trending", cfg.FeedDefaultSort)
+	assert.Equal(t, "local", cfg.StorageBackend)
+	assert.Equal(t, "/tmp/forum-uploads", cfg.UploadsDir)
+	assert.Equal(t, "/files", cfg.UploadsBaseURL)
+	assert.Equal(t, "a-separate-attachment-signing-key", cfg.UploadsSigningKey)
+	assert.True(t, cfg.JSONStringIDs)
+	assert.Equal(t, 3, cfg.PostMinTitle)
+	assert.Equal(t, 20, cfg.PostMinContent)
+	assert.Equal(t, int64(4), cfg.DefaultCategoryID)
+	assert.Equal(t, 3, cfg.LoginMaxFailures)
+	assert.Equal(t, 5*time.Minute, cfg.LoginFailureWindow)
+	assert.Equal(t, 720*time.Hour, cfg.PurgeDeletedAfter)
+	assert.Equal(t, 6*time.Hour, cfg.PurgeInterval)
+	assert.Equal(t, 30*time.Minute, cfg.SessionCleanupInterval)
+	assert.Equal(t, 25, cfg.InteractionBatchMaxIDs)
+	assert.True(t, cfg.DebugMode)
+	assert.True(t, cfg.Features.Enabled(features.ModerationQueue))
+}
+
+func TestLoadAppliesDefaults(t *testing.T) {
+	setEnv(t, map[string]string{
+		"DB_CONNECTION_STRING":         "",
+		"DB_REPLICA_CONNECTION_STRING": "",
+		"DB_MAX_CONNECTIONS":           "",
+		"JWT_EXPIRATION_HOURS":         "",
+		"SERVER_PORT":                  "",
+		"SERVER_READ_HEADER_TIMEOUT":   "",
+		"SERVER_READ_TIMEOUT":          "",
+		"SERVER_WRITE_TIMEOUT":         "",
+		"SERVER_IDLE_TIMEOUT":          "",
+		"SERVER_SHUTDOWN_TIMEOUT":      "",
+		"MIGRATION_PATH":               "",
+		"LOG_LEVEL":                    "",
+		"CORS_EXEMPT_PATHS":            "",
+		"CORS_ALLOWED_METHODS":         "",
+		"CORS_ALLOWED_HEADERS":         "",
+		"FEED_DEFAULT_SORT":            "",
+		"STORAGE_BACKEND":              "",
+		"UPLOADS_DIR":                  "",
+		"UPLOADS_SIGNING_KEY":          "",
+		"JSON_STRING_IDS":              "",
+		"POST_MIN_TITLE_LENGTH":        "",
+		"POST_MIN_CONTENT_LENGTH":      "",
+		"DEFAULT_CATEGORY_ID":          "",
+		"LOGIN_MAX_FAILURES":           "",
+		"LOGIN_FAILURE_WINDOW":         "",
+		"PURGE_DELETED_AFTER":          "",
+		"PURGE_INTERVAL":               "",
+		"SESSION_CLEANUP_INTERVAL":     "",
+		"INTERACTION_BATCH_MAX_IDS":    "",
+		"DEBUG_MODE":                   "",
+		"FEATURE_FLAGS":                "",
+	})
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultDBConnectionString, cfg.DBConnectionString)
+	assert.Empty(t, cfg.DBReplicaString)
+	assert.Equal(t, DefaultDBMaxConnections, cfg.DBMaxConnections)
+	assert.Equal(t, DefaultJWTExpirationHours*time.Hour, cfg.JWTExpiration)
+	assert.Equal(t, DefaultServerPort, cfg.ServerPort)
+	assert.Equal(t, ServerTimeouts{
+		ReadHeader: DefaultServerReadHeaderTimeout,
+		Read:       DefaultServerReadTimeout,
+		Write:      DefaultServerWriteTimeout,
+		Idle:       DefaultServerIdleTimeout,
+		Shutdown:   DefaultServerShutdownTimeout,
+	}, cfg.ServerTimeouts)
+	assert.Equal(t, DefaultMigrationPath, cfg.MigrationPath)
+	assert.Equal(t, DefaultLogLevel, cfg.LogLevel)
+	assert.Equal(t, []string{"/healthz", "/metrics"}, cfg.CORSExemptPaths)
+	assert.Equal(t, []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, cfg.CORSAllowedMethods)
+	assert.Equal(t, []string{"Authorization", "Content-Type"}, cfg.CORSAllowedHeaders)
+	assert.Equal(t, DefaultFeedSort, cfg.FeedDefaultSort)
+	assert.Equal(t, DefaultStorageBackend, cfg.StorageBackend)
+	assert.Equal(t, DefaultUploadsDir, cfg.UploadsDir)
+	assert.Equal(t, cfg.JWTSecret, cfg.UploadsSigningKey)
+	assert.False(t, cfg.JSONStringIDs)
+	assert.Equal(t, DefaultPostMinTitle, cfg.PostMinTitle)
+	assert.Equal(t, DefaultPostMinContent, cfg.PostMinContent)
+	assert.Zero(t, cfg.DefaultCategoryID)
+	assert.Equal(t, DefaultLoginMaxFailures, cfg.LoginMaxFailures)
+	assert.Equal(t, DefaultLoginFailureWindow, cfg.LoginFailureWindow)
+	assert.Zero(t, cfg.PurgeDeletedAfter)
+	assert.Equal(t, DefaultPurgeInterval, cfg.PurgeInterval)
+	assert.Equal(t, DefaultSessionCleanupInterval, cfg.SessionCleanupInterval)
+	assert.Equal(t, DefaultBatchMaxIDs, cfg.InteractionBatchMaxIDs)
+	assert.False(t, cfg.DebugMode)
+	assert.False(t, cfg.Features.Enabled(features.ModerationQueue))
+}
+
+func TestLoadInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		overrides     map[string]string
+		expectedError string
+	}{
+		{
+			name:          "Missing JWT Secret",
+			overrides:     map[string]string{"JWT_SECRET": ""},
+			expectedError: "JWT_SECRET is required",
+		},
+		{
+			name:          "Non-numeric Port",
+			overrides:     map[string]string{"SERVER_PORT": "http"},
+			expectedError: "SERVER_PORT must be a number between 1 and 65535",
+		},
+		{
+			name:          "Port Out Of Range",
+			overrides:     map[string]string{"SERVER_PORT": "70000"},
+			expectedError: "SERVER_PORT must be a number between 1 and 65535",
+		},
+		{
+			name:          "Unparseable Server Timeout",
+			overrides:     map[string]string{"SERVER_READ_TIMEOUT": "15"},
+			expectedError: "SERVER_READ_TIMEOUT must be a duration",
+		},
+		{
+			name:          "Non-positive Server Timeout",
+			overrides:     map[string]string{"SERVER_READ_HEADER_TIMEOUT": "0s"},
+			expectedError: "SERVER_READ_HEADER_TIMEOUT must be positive",
+		},
+		{
+			name:          "Negative Default Category",
+			overrides:     map[string]string{"DEFAULT_CATEGORY_ID": "-1"},
+			expectedError: "DEFAULT_CATEGORY_ID must not be negative",
+		},
+		{
+			name:          "Non-positive Login Max Failures",
+			overrides:     map[string]string{"LOGIN_MAX_FAILURES": "0"},
+			expectedError: "LOGIN_MAX_FAILURES must be positive",
+		},
+		{
+			name:          "Unparseable Login Failure Window",
+			overrides:     map[string]string{"LOGIN_FAILURE_WINDOW": "15"},
+			expectedError: "LOGIN_FAILURE_WINDOW must be a duration",
+		},
+		{
+			name:          "Negative Purge Retention",
+			overrides:     map[string]string{"PURGE_DELETED_AFTER": "-1h"},
+			expectedError: "PURGE_DELETED_AFTER must not be negative",
+		},
+		{
+			name:          "Non-positive Purge Interval",
+			overrides:     map[string]string{"PURGE_INTERVAL": "0s"},
+			expectedError: "PURGE_INTERVAL must be positive",
+		},
+		{
+			name:          "Unparseable Session Cleanup Interval",
+			overrides:     map[string]string{"SESSION_CLEANUP_INTERVAL": "60"},
+			expectedError: "SESSION_CLEANUP_INTERVAL must be a duration",
+		},
+		{
+			name:          "Non-positive Interaction Batch Max IDs",
+			overrides:     map[string]string{"INTERACTION_BATCH_MAX_IDS": "0"},
+			expectedError: "INTERACTION_BATCH_MAX_IDS must be positive",
+		},
+		{
+			name:          "Invalid Max Connections",
+			overrides:     map[string]string{"DB_MAX_CONNECTIONS": "ten"},
+			expectedError: "DB_MAX_CONNECTIONS must be an integer",
+		},
+		{
+			name:          "Non-positive Expiration",
+			overrides:     map[string]string{"JWT_EXPIRATION_HOURS": "0"},
+			expectedError: "JWT_EXPIRATION_HOURS must be positive",
+		},
+		{
+			name:          "Unknown Log Level",
+			overrides:     map[string]string{"LOG_LEVEL": "verbose"},
+			expectedError: "LOG_LEVEL must be one of",
+		},
+		{
+			name:          "Relative CORS Exempt Path",
+			overrides:     map[string]string{"CORS_EXEMPT_PATHS": "healthz"},
+			expectedError: "CORS_EXEMPT_PATHS entries must start with /",
+		},
+		{
+			name:          "Invalid Moderator ID",
+			overrides:     map[string]string{"MODERATOR_USER_IDS": "1,admin"},
+			expectedError: "MODERATOR_USER_IDS entries must be positive integers",
+		},
+		{
+			name:          "Invalid Admin ID",
+			overrides:     map[string]string{"ADMIN_USER_IDS": "root"},
+			expectedError: "ADMIN_USER_IDS entries must be positive integers",
+		},
+		{
+			name:          "Unknown Feed Sort",
+			overrides:     map[string]string{"FEED_DEFAULT_SORT": "random"},
+			expectedError: "FEED_DEFAULT_SORT must be one of",
+		},
+		{
+			name:          "Unknown Storage Backend",
+			overrides:     map[string]string{"STORAGE_BACKEND": "ftp"},
+			expectedError: "STORAGE_BACKEND must be one of local",
+		},
+		{
+			name:          "Short Uploads Signing Key",
+			overrides:     map[string]string{"UPLOADS_SIGNING_KEY": "short"},
+			expectedError: "UPLOADS_SIGNING_KEY must be at least 32 bytes",
+		},
+		{
+			name:          "Unknown Journal Mode",
+			overrides:     map[string]string{"DB_CONNECTION_STRING": "", "DB_JOURNAL_MODE": "fast"},
+			expectedError: "DB_JOURNAL_MODE must be one of",
+		},
+		{
+			name:          "Non-positive Minimum Content Length",
+			overrides:     map[string]string{"POST_MIN_CONTENT_LENGTH": "0"},
+			expectedError: "POST_MIN_CONTENT_LENGTH must be positive",
+		},
+		{
+			name:          "Invalid Debug Flag",
+			overrides:     map[string]string{"DEBUG_MODE": "maybe"},
+			expectedError: "DEBUG_MODE must be a boolean",
+		},
+		{
+			name:          "Unknown Feature Flag",
+			overrides:     map[string]string{"FEATURE_FLAGS": "moderation_queue,time_travel"},
+			expectedError: `FEATURE_FLAGS: unknown feature flag "time_travel"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setEnv(t, tc.overrides)
+
+			cfg, err := Load()
+			assert.Nil(t, cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestLoadJWTSecretLength(t *testing.T) {
+	t.Run("Short Secret Rejected", func(t *testing.T) {
+		setEnv(t, map[string]string{"JWT_SECRET": "x"})
+
+		cfg, err := Load()
+		assert.Nil(t, cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "JWT_SECRET must be at least 32 bytes, got 1")
+	})
+
+	t.Run("One Byte Below Minimum Rejected", func(t *testing.T) {
+		setEnv(t, map[string]string{"JWT_SECRET": strings.Repeat("s", MinJWTSecretLength-1)})
+
+		_, err := Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("Minimum Length Accepted", func(t *testing.T) {
+		secret := strings.Repeat("s", MinJWTSecretLength)
+		setEnv(t, map[string]string{"JWT_SECRET": secret})
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		assert.Equal(t, secret, cfg.JWTSecret)
+	})
+}
+
+func TestLoadReportsEveryInvalidValue(t *testing.T) {
+	setEnv(t, map[string]string{
+		"JWT_SECRET":  "",
+		"SERVER_PORT": "0",
+	})
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET is required")
+	assert.Contains(t, err.Error(), "SERVER_PORT must be a number between 1 and 65535")
+}
+
+func TestLoadComposesConnectionString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "Defaults",
+			env:      map[string]string{},
+			expected: DefaultDBConnectionString,
+		},
+		{
+			name:     "Custom Path",
+			env:      map[string]string{"DB_PATH": "/var/lib/forum/forum.db"},
+			expected: "file:/var/lib/forum/forum.db?_foreign_keys=on",
+		},
+		{
+			name:     "Foreign Keys Off",
+			env:      map[string]string{"DB_FOREIGN_KEYS": "false"},
+			expected: "file:forum.db?_foreign_keys=off",
+		},
+		{
+			name:     "Journal Mode",
+			env:      map[string]string{"DB_PATH": "data.db", "DB_JOURNAL_MODE": "wal"},
+			expected: "file:data.db?_foreign_keys=on&_journal_mode=WAL",
+		},
+		{
+			name:     "Full String Wins",
+			env:      map[string]string{"DB_CONNECTION_STRING": "file:explicit.db", "DB_PATH": "ignored.db", "DB_JOURNAL_MODE": "wal"},
+			expected: "file:explicit.db",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			overrides := map[string]string{"DB_CONNECTION_STRING": ""}
+			for key, value := range tc.env {
+				overrides[key] = value
+			}
+			setEnv(t, overrides)
+
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.DBConnectionString)
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	setEnv(t, map[string]string{
+		"DB_CONNECTION_STRING":         "file:forum.db?_auth&_auth_user=admin&_auth_pass=hunter2&_foreign_keys=on",
+		"DB_REPLICA_CONNECTION_STRING": "file:replica.db?_auth&_auth_user=reader&_auth_pass=hunter3",
+	})
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "[REDACTED]", redacted.JWTSecret)
+	assert.Equal(t, "[REDACTED]", redacted.UploadsSigningKey)
+	assert.Equal(t, "file:forum.db?_auth&_auth_user=admin&_auth_pass=[REDACTED]&_foreign_keys=on", redacted.DBConnectionString)
+	assert.Equal(t, "file:replica.db?_auth&_auth_user=reader&_auth_pass=[REDACTED]", redacted.DBReplicaString)
+	assert.Equal(t, cfg.ServerPort, redacted.ServerPort)
+
+	// The loaded config itself is left untouched
+	assert.Equal(t, "a-sufficiently-long-test-secret-value", cfg.JWTSecret)
+	assert.Contains(t, cfg.DBConnectionString, "hunter2")
+}
+
+func TestRedactConnectionString(t *testing.T) {
+	testCases := map[string]string{
+		"file:forum.db?_foreign_keys=on":             "file:forum.db?_foreign_keys=on",
+		"postgres://forum:s3cret@db:5432/forum":      "postgres://forum:[REDACTED]@db:5432/forum",
+		"postgres://forum@db:5432/forum?sslmode=off": "postgres://forum@db:5432/forum?sslmode=off",
+		"user:pw@tcp(db)/forum?password=again":       "user:[REDACTED]@tcp(db)/forum?password=[REDACTED]",
+	}
+
+	for dsn, expected := range testCases {
+		assert.Equal(t, expected, redactConnectionString(dsn), dsn)
+	}
+}
@@ -2,19 +2,56 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrMigrationNotFound is returned when the migration script does not exist
+var ErrMigrationNotFound = errors.New("migration script not found")
+
 type Database struct {
 	Conn *sql.DB
+	// Replica is an optional read-only connection for queries; nil when
+	// reads go to Conn
+	Replica *sql.DB
 }
 
 func NewDatabase(connString string) (*Database, error) {
+	conn, err := open(connString)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{Conn: conn}, nil
+}
+
+// OpenReplica connects the read-only replica that Reader hands out for queries
+func (db *Database) OpenReplica(connString string) error {
+	conn, err := open(connString)
+	if err != nil {
+		return fmt.Errorf("replica: %w", err)
+	}
+	db.Replica = conn
+	return nil
+}
+
+// Reader returns the connection queries should use: the replica when one is
+// open, otherwise the primary
+func (db *Database) Reader() *sql.DB {
+	if db.Replica != nil {
+		return db.Replica
+	}
+	return db.Conn
+}
+
+// open connects to a database and checks that it is reachable
+func open(connString string) (*sql.DB, error) {
 	// Validate connection string
 	if connString == "" {
 		return nil, fmt.Errorf("database connection string cannot be empty")
@@ -42,7 +79,7 @@ func NewDatabase(connString string) (*Database, error) {
 	}
 
 	log.Printf("✅ Database connection established successfully: %s", connString)
-	return &Database{Conn: conn}, nil
+	return conn, nil
 }
 
 // Migrate runs SQL migration scripts from a specified path
@@ -54,6 +91,20 @@ func (db *Database) Migrate(migrationPath string) error {
 
 	log.Printf("Attempting to run migrations from: %s", migrationPath)
 
+	// Report a missing script with the resolved path, since a relative
+	// path depends on the directory the server was started from
+	info, err := os.Stat(migrationPath)
+	if errors.Is(err, os.ErrNotExist) {
+		absPath, absErr := filepath.Abs(migrationPath)
+		if absErr != nil {
+			absPath = migrationPath
+		}
+		return fmt.Errorf("%w: looked for %s; set MIGRATION_PATH or start the server from the repository root", ErrMigrationNotFound, absPath)
+	}
+	if err == nil && info.IsDir() {
+		return fmt.Errorf("migration path %s is a directory; MIGRATION_PATH must name the SQL file", migrationPath)
+	}
+
 	// Read migration script
 	migrationScript, err := ioutil.ReadFile(migrationPath)
 	if err != nil {
@@ -84,6 +135,11 @@ func (db *Database) Migrate(migrationPath string) error {
 		}
 	}
 
+	// Bring tables created by earlier schemas up to date
+	if err := applyUpgrades(tx); err != nil {
+		return err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit migration: %w", err)
@@ -93,8 +149,14 @@ func (db *Database) Migrate(migrationPath string) error {
 	return nil
 }
 
-// Close safely closes the database connection
+// Close safely closes the database connection and the replica, if any
 func (db *Database) Close() error {
+	if db.Replica != nil {
+		log.Println("Closing replica database connection")
+		if err := db.Replica.Close(); err != nil {
+			log.Printf("Failed to close replica: %v", err)
+		}
+	}
 	if db.Conn != nil {
 		log.Println("Closing database connection")
 		return db.Conn.Close()
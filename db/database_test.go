@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateMissingScript(t *testing.T) {
+	database, err := NewDatabase(":memory:")
+	require.NoError(t, err)
+	defer database.Close()
+
+	missing := filepath.Join(t.TempDir(), "migrations", "001_create_tables.sql")
+
+	err = database.Migrate(missing)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrMigrationNotFound)
+	assert.Contains(t, err.Error(), missing)
+	assert.Contains(t, err.Error(), "MIGRATION_PATH")
+}
+
+func TestMigrateDirectory(t *testing.T) {
+	database, err := NewDatabase(":memory:")
+	require.NoError(t, err)
+	defer database.Close()
+
+	dir := t.TempDir()
+
+	err = database.Migrate(dir)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrMigrationNotFound)
+	assert.Contains(t, err.Error(), "is a directory")
+}
+
+func TestMigrateInteractionsTable(t *testing.T) {
+	database, err := NewDatabase(":memory:")
+	require.NoError(t, err)
+	defer database.Close()
+
+	// The pool must reuse the single in-memory database
+	database.Conn.SetMaxOpenConns(1)
+
+	migration := filepath.Join("migrations", "001_create_tables.sql")
+	require.NoError(t, database.Migrate(migration))
+	// Running it again must be a no-op
+	require.NoError(t, database.Migrate(migration))
+
+	result, err := database.Conn.Exec(`INSERT INTO users (username, email, password) VALUES ('voter', 'voter@example.com', 'hash')`)
+	require.NoError(t, err)
+	userID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	// Columns as written by InteractionRepository
+	insert := `INSERT INTO interactions (user_id, entity_id, entity_type, type, created_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	_, err = database.Conn.Exec(insert, userID, 1, "post", 1)
+	require.NoError(t, err)
+
+	// A user reacts to an entity at most once
+	_, err = database.Conn.Exec(insert, userID, 1, "post", 2)
+	assert.ErrorContains(t, err, "UNIQUE constraint failed")
+
+	// Only posts and comments can be reacted to
+	_, err = database.Conn.Exec(insert, userID, 2, "banana", 1)
+	assert.ErrorContains(t, err, "CHECK constraint failed")
+
+	var count int
+	require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM interactions WHERE entity_type = 'post' AND entity_id = 1`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+// openBaselineDatabase returns an in-memory database holding the original
+// schema and some content written by that version of the forum
+func openBaselineDatabase(t *testing.T) *Database {
+	t.Helper()
+
+	database, err := NewDatabase(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	database.Conn.SetMaxOpenConns(1)
+
+	baseline, err := os.ReadFile(filepath.Join("testdata", "baseline_schema.sql"))
+	require.NoError(t, err)
+	_, err = database.Conn.Exec(string(baseline))
+	require.NoError(t, err)
+
+	_, err = database.Conn.Exec(`
+		INSERT INTO users (id, username, email, password) VALUES
+			(1, 'alice', 'alice@example.com', 'hash'),
+			(2, 'bob', 'bob@example.com', 'hash');
+		INSERT INTO posts (id, user_id, title, content, created_at) VALUES
+			(1, 1, 'Hello World', 'First post', '2024-01-02 10:00:00'),
+			(2, 2, 'Hello World', 'Same title', '2024-01-03 11:30:00');
+		INSERT INTO comments (id, post_id, user_id, content, created_at) VALUES
+			(1, 1, 2, 'Nice', '2024-01-02 12:00:00');
+		INSERT INTO likes (user_id, content_type, content_id, is_like) VALUES
+			(1, 'post', 2, 1),
+			(2, 'post', 1, 1),
+			(1, 'comment', 1, 0);
+	`)
+	require.NoError(t, err)
+	return database
+}
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	database := openBaselineDatabase(t)
+	migration := filepath.Join("migrations", "001_create_tables.sql")
+
+	require.NoError(t, database.Migrate(migration))
+	// Upgraded databases must migrate cleanly on every later start too
+	require.NoError(t, database.Migrate(migration))
+
+	var version int
+	require.NoError(t, database.Conn.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, upgrades[len(upgrades)-1].version, version)
+
+	t.Run("Post Updated At", func(t *testing.T) {
+		var createdAt, updatedAt string
+		require.NoError(t, database.Conn.QueryRow(`SELECT created_at, updated_at FROM posts WHERE id = 2`).Scan(&createdAt, &updatedAt))
+		assert.Equal(t, createdAt, updatedAt)
+
+		var index int
+		require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_posts_updated_at'`).Scan(&index))
+		assert.Equal(t, 1, index)
+	})
+	t.Run("Post Excerpt", func(t *testing.T) {
+		var excerpt sql.NullString
+		require.NoError(t, database.Conn.QueryRow(`SELECT excerpt FROM posts WHERE id = 1`).Scan(&excerpt))
+		assert.False(t, excerpt.Valid)
+	})
+
+	t.Run("Post Status", func(t *testing.T) {
+		var pending int
+		require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM posts WHERE status != 'approved'`).Scan(&pending))
+		assert.Zero(t, pending)
+	})
+	t.Run("Likes Moved To Interactions", func(t *testing.T) {
+		var likesTable int
+		require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'likes'`).Scan(&likesTable))
+		assert.Zero(t, likesTable)
+
+		var commentVote int
+		require.NoError(t, database.Conn.QueryRow(`SELECT type FROM interactions WHERE user_id = 1 AND entity_type = 'comment' AND entity_id = 1`).Scan(&commentVote))
+		assert.Equal(t, 2, commentVote, "is_like false becomes a dislike")
+
+		var likes, dislikes int
+		require.NoError(t, database.Conn.QueryRow(`SELECT like_count, dislike_count FROM posts WHERE id = 2`).Scan(&likes, &dislikes))
+		assert.Equal(t, 1, likes)
+		assert.Zero(t, dislikes)
+	})
+	t.Run("Post Slugs", func(t *testing.T) {
+		slugs := make(map[int64]string)
+		rows, err := database.Conn.Query(`SELECT id, slug FROM posts`)
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var id int64
+			var slug string
+			require.NoError(t, rows.Scan(&id, &slug))
+			slugs[id] = slug
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, map[int64]string{1: "hello-world", 2: "hello-world-2"}, slugs)
+
+		_, err = database.Conn.Exec(`UPDATE posts SET slug = 'hello-world' WHERE id = 2`)
+		assert.ErrorContains(t, err, "UNIQUE constraint failed")
+	})
+	t.Run("Comment Threading", func(t *testing.T) {
+		var parentID, deletedAt sql.NullString
+		require.NoError(t, database.Conn.QueryRow(`SELECT parent_id, deleted_at FROM comments WHERE id = 1`).Scan(&parentID, &deletedAt))
+		assert.False(t, parentID.Valid)
+		assert.False(t, deletedAt.Valid)
+
+		_, err := database.Conn.Exec(`INSERT INTO comments (post_id, parent_id, user_id, content) VALUES (1, 1, 1, 'Reply')`)
+		assert.NoError(t, err)
+	})
+}
+
+func TestMigrateBackfillsPostCounters(t *testing.T) {
+	database := openBaselineDatabase(t)
+
+	// Interactions recorded before posts carried their own counters
+	_, err := database.Conn.Exec(`
+		CREATE TABLE interactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			entity_id INTEGER NOT NULL,
+			entity_type TEXT NOT NULL,
+			type INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, entity_id, entity_type)
+		);
+		INSERT INTO interactions (user_id, entity_id, entity_type, type) VALUES
+			(1, 1, 'post', 1),
+			(2, 1, 'post', 2);
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, database.Migrate(filepath.Join("migrations", "001_create_tables.sql")))
+
+	var likes, dislikes int
+	require.NoError(t, database.Conn.QueryRow(`SELECT like_count, dislike_count FROM posts WHERE id = 1`).Scan(&likes, &dislikes))
+	assert.Equal(t, 1, likes)
+	assert.Equal(t, 1, dislikes)
+}
+
+func TestMigrateAddsInteractionsCheck(t *testing.T) {
+	database := openBaselineDatabase(t)
+
+	// The interactions table as first created, without the CHECK constraint
+	_, err := database.Conn.Exec(`
+		CREATE TABLE interactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			entity_id INTEGER NOT NULL,
+			entity_type TEXT NOT NULL,
+			type INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, entity_id, entity_type)
+		);
+		CREATE INDEX idx_interactions_entity ON interactions(entity_type, entity_id);
+		INSERT INTO interactions (user_id, entity_id, entity_type, type) VALUES
+			(2, 2, 'post', 2),
+			(2, 9, 'banana', 1);
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, database.Migrate(filepath.Join("migrations", "001_create_tables.sql")))
+
+	var kept int
+	require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM interactions WHERE user_id = 2 AND entity_type = 'post' AND entity_id = 2`).Scan(&kept))
+	assert.Equal(t, 1, kept)
+
+	var invalid int
+	require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM interactions WHERE entity_type NOT IN ('post', 'comment')`).Scan(&invalid))
+	assert.Zero(t, invalid)
+
+	_, err = database.Conn.Exec(`INSERT INTO interactions (user_id, entity_id, entity_type, type) VALUES (1, 3, 'banana', 1)`)
+	assert.ErrorContains(t, err, "CHECK constraint failed")
+
+	var index int
+	require.NoError(t, database.Conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_interactions_entity'`).Scan(&index))
+	assert.Equal(t, 1, index)
+}
+
+func TestMigrateAddsNotificationRequestID(t *testing.T) {
+	database := openBaselineDatabase(t)
+
+	// The notifications table as first created, before request tracing
+	_, err := database.Conn.Exec(`
+		CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			actor_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			is_read BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO notifications (user_id, actor_id, type, entity_type, entity_id) VALUES (1, 2, 'comment', 'post', 1);
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, database.Migrate(filepath.Join("migrations", "001_create_tables.sql")))
+
+	var requestID string
+	require.NoError(t, database.Conn.QueryRow(`SELECT request_id FROM notifications WHERE user_id = 1`).Scan(&requestID))
+	assert.Empty(t, requestID)
+}
@@ -36,10 +36,27 @@ CREATE TABLE IF NOT EXISTS posts (
     user_id INTEGER NOT NULL,
     title TEXT NOT NULL,
     content TEXT NOT NULL,
+    excerpt TEXT, -- optional author-written preview for listings
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    like_count INTEGER NOT NULL DEFAULT 0, -- denormalized from interactions
+    dislike_count INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'approved', -- 'pending' while awaiting moderation
+    slug TEXT, -- URL name derived from the title at creation, unique by idx_posts_slug
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- Columns added to existing tables, and their indexes, are applied by the
+-- upgrade steps in db/upgrades.go once this script has run
+
+-- Post Tombstones Table (records deleted posts for sync clients)
+CREATE TABLE IF NOT EXISTS post_tombstones (
+    post_id INTEGER PRIMARY KEY,
+    deleted_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tombstones_deleted_at ON post_tombstones(deleted_at);
+
 -- Post Categories Junction Table
 CREATE TABLE IF NOT EXISTS post_categories (
     post_id INTEGER NOT NULL,
@@ -53,22 +70,73 @@ CREATE TABLE IF NOT EXISTS post_categories (
 CREATE TABLE IF NOT EXISTS comments (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     post_id INTEGER NOT NULL,
+    parent_id INTEGER,
     user_id INTEGER NOT NULL,
     content TEXT NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
     FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES comments(id),
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
--- Likes Table
-CREATE TABLE IF NOT EXISTS likes (
+-- Interactions Table (likes and dislikes on posts and comments)
+CREATE TABLE IF NOT EXISTS interactions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id INTEGER NOT NULL,
-    content_type TEXT NOT NULL, -- 'post' or 'comment'
-    content_id INTEGER NOT NULL,
-    is_like BOOLEAN NOT NULL,
+    entity_id INTEGER NOT NULL,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('post', 'comment')),
+    type INTEGER NOT NULL, -- 1 = like, 2 = dislike
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, entity_id, entity_type),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_interactions_entity ON interactions(entity_type, entity_id);
+
+-- Notifications Table
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL, -- recipient
+    actor_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    entity_type TEXT NOT NULL, -- 'post' or 'comment'
+    entity_id INTEGER NOT NULL,
+    is_read BOOLEAN NOT NULL DEFAULT 0,
+    request_id TEXT NOT NULL DEFAULT '', -- originating request, for tracing
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at);
+
+-- Notification Preferences Table (a missing row means the type is enabled)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    PRIMARY KEY (user_id, type),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Category Subscriptions Table (users following categories for new post notifications)
+CREATE TABLE IF NOT EXISTS category_subscriptions (
+    user_id INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(user_id, content_type, content_id),
+    PRIMARY KEY (user_id, category_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_subscriptions_category ON category_subscriptions(category_id);
+
+-- Password Reset Tokens Table (single-use tokens for self-service resets)
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
@@ -0,0 +1,279 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"forum/models"
+	"forum/pkg/slug"
+)
+
+// upgrade brings a database created by an earlier schema up to date. The
+// migration script only creates what is missing, so columns and data changes
+// for tables that already exist are applied here instead
+type upgrade struct {
+	version int
+	name    string
+	apply   func(tx *sql.Tx) error
+}
+
+// upgrades run in order after the migration script, each at most once per
+// database; PRAGMA user_version records the last one applied. Append new
+// steps with the next version and never reorder existing ones. Steps still
+// check the schema before changing it, since a database created from the
+// current script already has everything they add
+var upgrades = []upgrade{
+	{version: 1, name: "posts.updated_at", apply: addPostUpdatedAt},
+	{version: 2, name: "posts.status", apply: addPostStatus},
+	{version: 3, name: "posts.like_count and posts.dislike_count", apply: addPostInteractionCounts},
+	{version: 4, name: "likes into interactions", apply: migrateLikes},
+	{version: 5, name: "interactions entity_type check", apply: rebuildInteractionsWithCheck},
+	{version: 6, name: "posts.slug", apply: addPostSlug},
+	{version: 7, name: "comments.parent_id and comments.deleted_at", apply: addCommentThreading},
+	{version: 8, name: "notifications.request_id", apply: addNotificationRequestID},
+	{version: 9, name: "posts.excerpt", apply: addPostExcerpt},
+}
+
+// applyUpgrades runs the upgrades the database has not received yet
+func applyUpgrades(tx *sql.Tx) error {
+	var current int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, step := range upgrades {
+		if step.version <= current {
+			continue
+		}
+
+		log.Printf("Applying schema upgrade %d: %s", step.version, step.name)
+		if err := step.apply(tx); err != nil {
+			return fmt.Errorf("schema upgrade %d (%s) failed: %w", step.version, step.name, err)
+		}
+		// PRAGMA does not take bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", step.version)); err != nil {
+			return fmt.Errorf("failed to record schema version %d: %w", step.version, err)
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether table has the named column
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// hasTable reports whether the database has the named table
+func hasTable(tx *sql.Tx, table string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)`, table).Scan(&exists)
+	return exists, err
+}
+
+// addColumn adds a column to table unless it is already there. SQLite only
+// accepts constant defaults here, so columns defaulting to CURRENT_TIMESTAMP
+// are added without one and backfilled by the caller
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := hasColumn(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// addPostUpdatedAt adds posts.updated_at, starting existing posts at their
+// creation time, and indexes it for the sync endpoint
+func addPostUpdatedAt(tx *sql.Tx) error {
+	if err := addColumn(tx, "posts", "updated_at", "DATETIME"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE posts SET updated_at = created_at WHERE updated_at IS NULL`); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_updated_at ON posts(updated_at)`)
+	return err
+}
+
+// addPostStatus adds posts.status; posts written before moderation existed
+// were all public, so they start out approved
+func addPostStatus(tx *sql.Tx) error {
+	return addColumn(tx, "posts", "status", "TEXT NOT NULL DEFAULT 'approved'")
+}
+
+// addPostInteractionCounts adds the denormalized like and dislike counters to
+// posts and fills them in from the interactions recorded so far
+func addPostInteractionCounts(tx *sql.Tx) error {
+	for _, column := range []string{"like_count", "dislike_count"} {
+		if err := addColumn(tx, "posts", column, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+	return recountPostInteractions(tx)
+}
+
+// recountPostInteractions recomputes every post's like and dislike counters
+// from the interactions table
+func recountPostInteractions(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		UPDATE posts SET
+			like_count = (SELECT COUNT(*) FROM interactions
+				WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?),
+			dislike_count = (SELECT COUNT(*) FROM interactions
+				WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?)
+	`, models.Like, models.Dislike)
+	return err
+}
+
+// migrateLikes moves the votes of the original likes table into interactions,
+// recounts the post counters and drops likes. A vote the user has since cast
+// again through interactions is kept as it is
+func migrateLikes(tx *sql.Tx) error {
+	exists, err := hasTable(tx, "likes")
+	if err != nil || !exists {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO interactions (user_id, entity_id, entity_type, type, created_at)
+		SELECT user_id, content_id, content_type,
+			CASE WHEN is_like THEN ? ELSE ? END,
+			created_at
+		FROM likes
+		WHERE content_type IN ('post', 'comment')
+	`, models.Like, models.Dislike)
+	if err != nil {
+		return err
+	}
+	if err := recountPostInteractions(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DROP TABLE likes`)
+	return err
+}
+
+// rebuildInteractionsWithCheck restricts interactions.entity_type to posts and
+// comments. SQLite cannot add a CHECK constraint to an existing table, so the
+// table is rebuilt; rows naming any other entity type are dropped on the way
+func rebuildInteractionsWithCheck(tx *sql.Tx) error {
+	var definition string
+	err := tx.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'interactions'`).Scan(&definition)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(definition, "CHECK") {
+		return nil
+	}
+
+	statements := []string{
+		`CREATE TABLE interactions_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			entity_id INTEGER NOT NULL,
+			entity_type TEXT NOT NULL CHECK (entity_type IN ('post', 'comment')),
+			type INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, entity_id, entity_type),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO interactions_new (id, user_id, entity_id, entity_type, type, created_at)
+		SELECT id, user_id, entity_id, entity_type, type, created_at
+		FROM interactions
+		WHERE entity_type IN ('post', 'comment')`,
+		`DROP TABLE interactions`,
+		`ALTER TABLE interactions_new RENAME TO interactions`,
+		`CREATE INDEX IF NOT EXISTS idx_interactions_entity ON interactions(entity_type, entity_id)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPostSlug adds posts.slug and gives every post without one a slug made
+// from its title, oldest first so the earliest post keeps the plain slug.
+// SQLite cannot add a UNIQUE column, so uniqueness comes from an index
+func addPostSlug(tx *sql.Tx) error {
+	if err := addColumn(tx, "posts", "slug", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug)`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, title FROM posts WHERE slug IS NULL ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	titles := make(map[int64]string)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var title string
+		if err := rows.Scan(&id, &title); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		titles[id] = title
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		postSlug, err := slug.Unique(tx, slug.Make(titles[id]))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE posts SET slug = ? WHERE id = ?`, postSlug, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCommentThreading adds the reply parent and soft delete time to comments;
+// existing comments stay top-level and visible
+func addCommentThreading(tx *sql.Tx) error {
+	if err := addColumn(tx, "comments", "parent_id", "INTEGER REFERENCES comments(id)"); err != nil {
+		return err
+	}
+	return addColumn(tx, "comments", "deleted_at", "DATETIME")
+}
+
+// addNotificationRequestID adds the originating request to notifications;
+// those created earlier are left without one
+func addNotificationRequestID(tx *sql.Tx) error {
+	return addColumn(tx, "notifications", "request_id", "TEXT NOT NULL DEFAULT ''")
+}
+
+// addPostExcerpt adds the optional author-written excerpt to posts; listings
+// keep previewing the content of posts without one
+func addPostExcerpt(tx *sql.Tx) error {
+	return addColumn(tx, "posts", "excerpt", "TEXT")
+}
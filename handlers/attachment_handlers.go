@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+
+	"forum/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AttachmentHandler struct {
+	storage storage.Storage
+	signer  *storage.URLSigner
+}
+
+func NewAttachmentHandler(store storage.Storage, signer *storage.URLSigner) *AttachmentHandler {
+	return &AttachmentHandler{
+		storage: store,
+		signer:  signer,
+	}
+}
+
+// Download serves an attachment only when the request carries a valid,
+// unexpired signature for the attachment's URL
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	// Check the signature against the canonical URL for the key
+	query := r.URL.Query()
+	err := h.signer.Verify(h.storage.URL(key), query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		http.Error(w, "Forbidden: invalid or expired link", http.StatusForbidden)
+		return
+	}
+
+	reader, err := h.storage.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error reading attachment: %v", err)
+		http.Error(w, "Failed to read attachment", http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, reader); err != nil {
+		log.Printf("Error streaming attachment: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"forum/pkg/storage"
+)
+
+func TestDownloadAttachment(t *testing.T) {
+	signer := storage.NewURLSigner("test-signing-key-for-attachments")
+	store, err := storage.NewLocalStorage(t.TempDir(), "/uploads", signer)
+	require.NoError(t, err)
+	handler := NewAttachmentHandler(store, signer)
+
+	key := "posts/7/secret.txt"
+	require.NoError(t, store.Put(key, strings.NewReader("private contents")))
+
+	tamper := func(signedURL string) string {
+		u, _ := url.Parse(signedURL)
+		q := u.Query()
+		q.Set("expires", "99999999999")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{
+			name:           "Valid Signature",
+			url:            store.SignedURL(key, time.Minute),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Expired Signature",
+			url:            store.SignedURL(key, -time.Minute),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Tampered Expiry",
+			url:            tamper(store.SignedURL(key, time.Minute)),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Signature For Another Key",
+			url:            strings.Replace(store.SignedURL("posts/7/other.txt", time.Minute), "other.txt", "secret.txt", 1),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Unsigned",
+			url:            store.URL(key),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("*", strings.TrimPrefix(req.URL.Path, "/uploads/"))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+
+			handler.Download(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, "private contents", w.Body.String())
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"forum/middleware"
 	"forum/models"
@@ -18,11 +20,15 @@ type AuthHandler struct {
 	userRepo       repository.UserRepository
 	authMiddleware middleware.AuthMiddlewareInterface
 	renderer       *TemplateRenderer
+	resetRepo      repository.PasswordResetRepositoryInterface
+	sessionRepo    repository.SessionRepositoryInterface
+	resetMailer    PasswordResetMailer
 }
 
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Remember bool   `json:"remember"`
 }
 
 type AuthResponse struct {
@@ -31,6 +37,23 @@ type AuthResponse struct {
 	Message string `json:"message"`
 }
 
+// defaultLoginRedirect is where browsers land after login without a next page
+const defaultLoginRedirect = "/dashboard"
+
+// localRedirectTarget returns next when it is a path on this site, so the
+// login flow cannot be used as an open redirect to another host
+func localRedirectTarget(next string) (string, bool) {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "", false
+	}
+
+	parsed, err := url.Parse(next)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" {
+		return "", false
+	}
+	return next, true
+}
+
 func NewAuthHandler(userRepo repository.UserRepository, authMiddleware middleware.AuthMiddlewareInterface, renderer *TemplateRenderer) *AuthHandler {
 	return &AuthHandler{
 		userRepo:       userRepo,
@@ -39,6 +62,16 @@ func NewAuthHandler(userRepo repository.UserRepository, authMiddleware middlewar
 	}
 }
 
+// WithPasswordResets enables the forgot/reset password endpoints, delivering
+// reset tokens through mailer; with a nil mailer no tokens are issued. A reset
+// ends the user's session in sessionRepo, signing out whoever held it
+func (h *AuthHandler) WithPasswordResets(resetRepo repository.PasswordResetRepositoryInterface, sessionRepo repository.SessionRepositoryInterface, mailer PasswordResetMailer) *AuthHandler {
+	h.resetRepo = resetRepo
+	h.sessionRepo = sessionRepo
+	h.resetMailer = mailer
+	return h
+}
+
 // Input Validation
 func validateRegisterRequest(req map[string]string) error {
 	// Trim whitespace
@@ -66,7 +99,11 @@ func validateRegisterRequest(req map[string]string) error {
 		return errors.New("invalid email format")
 	}
 
-	// Password validation
+	return validatePassword(password)
+}
+
+// validatePassword enforces the password complexity rules for new passwords
+func validatePassword(password string) error {
 	if password == "" {
 		return errors.New("password is required")
 	}
@@ -90,6 +127,16 @@ func validateRegisterRequest(req map[string]string) error {
 }
 
 // Input Validation for Login Request
+// rememberRequested reads the login form's remember checkbox, which browsers
+// submit as "on" when it has no value of its own
+func rememberRequested(value string) bool {
+	if strings.EqualFold(value, "on") {
+		return true
+	}
+	remember, _ := strconv.ParseBool(value)
+	return remember
+}
+
 func validateLoginRequest(req *LoginRequest) error {
 	// Trim whitespace
 	req.Email = strings.TrimSpace(req.Email)
@@ -126,6 +173,11 @@ func (h *AuthHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
 		data.Error = "Invalid login credentials"
 	}
 
+	// Carry the page the user was heading to through the login form
+	if next, ok := localRedirectTarget(r.URL.Query().Get("next")); ok {
+		data.Data = map[string]string{"Next": next}
+	}
+
 	// Render the login page
 	err := h.renderer.Render(w, r, "login.html", data)
 	if err != nil {
@@ -145,11 +197,19 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	loginRequest := &LoginRequest{
 		Email:    r.Form.Get("email"),
 		Password: r.Form.Get("password"),
+		Remember: rememberRequested(r.Form.Get("remember")),
+	}
+
+	// Only local paths are honored as the post-login destination
+	next, hasNext := localRedirectTarget(r.Form.Get("next"))
+	nextParam := ""
+	if hasNext {
+		nextParam = "&next=" + url.QueryEscape(next)
 	}
 
 	// Validate login request
 	if err := validateLoginRequest(loginRequest); err != nil {
-		http.Redirect(w, r, "/login?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(err.Error())+nextParam, http.StatusSeeOther)
 		return
 	}
 
@@ -157,18 +217,43 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userRepo.Authenticate(loginRequest.Email, loginRequest.Password)
 	if err != nil {
 		// Redirect back to login with error
-		http.Redirect(w, r, "/login?error=invalid_credentials", http.StatusSeeOther)
+		http.Redirect(w, r, "/login?error=invalid_credentials"+nextParam, http.StatusSeeOther)
 		return
 	}
 
+	// Remembered logins get a long-lived session and a cookie that outlives
+	// the browser session; zero keeps the default lifetime
+	var lifetime time.Duration
+	if loginRequest.Remember {
+		lifetime = models.RememberSessionDuration
+	}
+
 	// Generate JWT token
-	token, err := h.authMiddleware.GenerateToken(user.ID, user.Username)
+	token, err := h.authMiddleware.GenerateToken(user.ID, user.Username, lifetime)
 	if err != nil {
 		log.Printf("Token generation error: %v", err)
 		h.renderer.RenderError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
+	// Browsers authenticate with the token cookie and continue where they
+	// were heading; API clients asking for JSON still receive the token
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(lifetime.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		if !hasNext {
+			next = defaultLoginRedirect
+		}
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	}
+
 	// Prepare response
 	response := AuthResponse{
 		Token:   token,
@@ -242,7 +327,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate JWT token
-	token, err := h.authMiddleware.GenerateToken(user.ID, user.Username)
+	token, err := h.authMiddleware.GenerateToken(user.ID, user.Username, 0)
 	if err != nil {
 		log.Printf("Token generation error: %v", err)
 		h.renderer.RenderError(w, r, http.StatusInternalServerError, err)
@@ -264,9 +349,22 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Logout revokes the session behind the caller's token and clears the token
+// cookie. Requests without a valid token are simply sent to the login page
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// Implement logout logic
-	// Clear session, remove tokens, etc.
+	if err := h.authMiddleware.RevokeSession(r); err != nil {
+		log.Printf("Logout without an active session: %v", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
 
 	// Redirect to login page
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"forum/middleware"
+	"forum/mocks"
+	"forum/models"
+	"forum/repository"
+)
+
+// MockUserRepository simulates user repository for testing
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(user *models.User) (*models.User, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Authenticate(email, password string) (*models.User, error) {
+	args := m.Called(email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(userID int, newPassword string) error {
+	args := m.Called(userID, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindByID(id int) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByIDs(ids []int) (map[int]*models.User, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Ensure MockUserRepository implements the interface
+var _ repository.UserRepository = (*MockUserRepository)(nil)
+
+func TestLoginRedirectsToNext(t *testing.T) {
+	testCases := []struct {
+		name             string
+		next             string
+		expectedLocation string
+	}{
+		{name: "Local Next", next: "/posts/create?draft=1", expectedLocation: "/posts/create?draft=1"},
+		{name: "Absolute External URL", next: "https://evil.example.com/phish", expectedLocation: defaultLoginRedirect},
+		{name: "Protocol Relative URL", next: "//evil.example.com", expectedLocation: defaultLoginRedirect},
+		{name: "No Next", expectedLocation: defaultLoginRedirect},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			mockUserRepo.On("Authenticate", "user@example.com", "Password123!").
+				Return(&models.User{ID: 7, Username: "user"}, nil)
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GenerateToken", 7, "user", time.Duration(0)).Return("signed-token", nil)
+
+			handler := NewAuthHandler(mockUserRepo, mockAuthMiddleware, nil)
+
+			form := url.Values{"email": {"user@example.com"}, "password": {"Password123!"}}
+			if tc.next != "" {
+				form.Set("next", tc.next)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			handler.Login(w, req)
+
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+
+			cookies := w.Result().Cookies()
+			if assert.Len(t, cookies, 1) {
+				assert.Equal(t, "token", cookies[0].Name)
+				assert.Equal(t, "signed-token", cookies[0].Value)
+			}
+		})
+	}
+}
+
+func TestLoginFailureKeepsNext(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserRepo.On("Authenticate", "user@example.com", "WrongPass1!").Return(nil, assert.AnError)
+
+	handler := NewAuthHandler(mockUserRepo, mocks.NewMockAuthMiddleware(t), nil)
+
+	form := url.Values{"email": {"user@example.com"}, "password": {"WrongPass1!"}, "next": {"/dashboard"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.Login(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login?error=invalid_credentials&next=%2Fdashboard", w.Header().Get("Location"))
+}
+
+func TestLoginRemember(t *testing.T) {
+	testCases := []struct {
+		name             string
+		remember         string
+		expectedLifetime time.Duration
+		expectedMaxAge   int
+	}{
+		{name: "Default Lifetime", expectedLifetime: models.DefaultSessionDuration},
+		{name: "Remember Checkbox", remember: "on", expectedLifetime: models.RememberSessionDuration, expectedMaxAge: int(models.RememberSessionDuration.Seconds())},
+		{name: "Remember False", remember: "false", expectedLifetime: models.DefaultSessionDuration},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, cleanup := repository.SetupTestDB(t)
+			defer cleanup()
+
+			userRepo := repository.NewUserRepository(conn)
+			user, err := userRepo.Create(&models.User{Username: "member", Email: "member@example.com", Password: "Password123!"})
+			require.NoError(t, err)
+
+			authMiddleware := middleware.NewAuthMiddleware(repository.NewSessionRepository(conn), "test-secret", 24)
+			handler := NewAuthHandler(userRepo, authMiddleware, nil)
+
+			form := url.Values{"email": {"member@example.com"}, "password": {"Password123!"}}
+			if tc.remember != "" {
+				form.Set("remember", tc.remember)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			before := time.Now()
+
+			handler.Login(w, req)
+
+			require.Equal(t, http.StatusSeeOther, w.Code)
+			cookies := w.Result().Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, tc.expectedMaxAge, cookies[0].MaxAge)
+
+			claims, err := authMiddleware.ValidateToken(cookies[0].Value)
+			require.NoError(t, err)
+			assert.WithinDuration(t, before.Add(tc.expectedLifetime), claims.ExpiresAt.Time, time.Minute)
+
+			var expiresAt time.Time
+			require.NoError(t, conn.QueryRow(`SELECT expires_at FROM sessions WHERE user_id = ?`, user.ID).Scan(&expiresAt))
+			assert.WithinDuration(t, before.Add(tc.expectedLifetime), expiresAt, time.Minute)
+		})
+	}
+}
+
+func TestLogoutInvalidatesSession(t *testing.T) {
+	conn, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	result, err := conn.Exec(`INSERT INTO users (username, email, password) VALUES ('member', 'member@example.com', 'hash')`)
+	require.NoError(t, err)
+	userID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	sessionRepo := repository.NewSessionRepository(conn)
+	authMiddleware := middleware.NewAuthMiddleware(sessionRepo, "test-secret", 1)
+	handler := NewAuthHandler(new(MockUserRepository), authMiddleware, nil)
+
+	token, err := authMiddleware.GenerateToken(int(userID), "member", 0)
+	require.NoError(t, err)
+	claims, err := authMiddleware.ValidateToken(token)
+	require.NoError(t, err)
+	_, err = sessionRepo.ValidateSession(claims.SessionToken)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+
+	handler.Logout(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("Location"))
+
+	_, err = sessionRepo.ValidateSession(claims.SessionToken)
+	assert.Error(t, err, "session must be gone after logout")
+	_, err = authMiddleware.ValidateToken(token)
+	assert.Error(t, err, "token must stop validating after logout")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "token", cookies[0].Name)
+	assert.Empty(t, cookies[0].Value)
+	assert.Negative(t, cookies[0].MaxAge)
+
+	t.Run("Without Token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Logout(w, httptest.NewRequest(http.MethodGet, "/logout", nil))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+}
@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"forum/models"
+	"forum/repository"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -15,11 +20,20 @@ import (
 type CategoryRepositoryInterface interface {
 	Create(category *models.Category) error
 	GetByID(categoryID int64) (*models.Category, error)
+	CategoriesExist(ids []int64) (missing []int64, err error)
 	ListCategories() ([]models.Category, error)
+	ListCategoriesWithCounts() ([]repository.CategoryWithCount, error)
 	Update(category *models.Category) error
 	Delete(categoryID int64) error
+	MergeCategories(sourceID, targetID int64, dryRun bool) (*repository.MergeResult, error)
+	GetCategoryUsageFrequency() ([]repository.CategoryCount, error)
+	GetTrendingCategories(window time.Duration, limit int) ([]repository.TrendingCategory, error)
+	ResolveCategoryNames(names []string, create bool) ([]int64, error)
 }
 
+// maxCategoryNameLength caps category names, in characters
+const maxCategoryNameLength = 50
+
 type CategoryHandler struct {
 	categoryRepo CategoryRepositoryInterface
 }
@@ -31,28 +45,23 @@ func NewCategoryHandler(categoryRepo CategoryRepositoryInterface) *CategoryHandl
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate category name
-	category.Name = strings.TrimSpace(category.Name)
-	if category.Name == "" {
-		http.Error(w, "Category name is required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate category name length
-	if len(category.Name) > 50 {
-		http.Error(w, "Category name must be 50 characters or less", http.StatusBadRequest)
+	if !validateCategory(w, &category) {
 		return
 	}
 
 	err := h.categoryRepo.Create(&category)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCategory) {
+			writeError(w, http.StatusConflict, "name", "A category with this name already exists")
+			return
+		}
 		log.Printf("Failed to create category: %v", err)
-		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "", "Failed to create category")
 		return
 	}
 
@@ -63,6 +72,21 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// validateCategory trims the category name and checks it, writing a JSON
+// validation error and returning false when it is unusable
+func validateCategory(w http.ResponseWriter, category *models.Category) bool {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		writeError(w, http.StatusBadRequest, "name", "Category name is required")
+		return false
+	}
+	if utf8.RuneCountInString(category.Name) > maxCategoryNameLength {
+		writeError(w, http.StatusBadRequest, "name", fmt.Sprintf("Category name must be %d characters or less", maxCategoryNameLength))
+		return false
+	}
+	return true
+}
+
 func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
@@ -85,7 +109,21 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListCategories lists every category; with with_counts=true each one also
+// carries the number of posts tagged with it
 func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	if raw := r.URL.Query().Get("with_counts"); raw != "" {
+		withCounts, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "with_counts", "Invalid with_counts value")
+			return
+		}
+		if withCounts {
+			h.listCategoriesWithCounts(w)
+			return
+		}
+	}
+
 	categories, err := h.categoryRepo.ListCategories()
 	if err != nil {
 		log.Printf("Failed to retrieve categories: %v", err)
@@ -93,11 +131,68 @@ func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(categories); err != nil {
-		log.Printf("Failed to encode categories response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	writeList(w, categories, nil)
+}
+
+func (h *CategoryHandler) listCategoriesWithCounts(w http.ResponseWriter) {
+	categories, err := h.categoryRepo.ListCategoriesWithCounts()
+	if err != nil {
+		log.Printf("Failed to retrieve categories with counts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve categories")
+		return
+	}
+
+	writeList(w, categories, nil)
+}
+
+// GetCategoryFrequency reports how many posts use each category, for tag clouds
+func (h *CategoryHandler) GetCategoryFrequency(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.categoryRepo.GetCategoryUsageFrequency()
+	if err != nil {
+		log.Printf("Failed to retrieve category frequency: %v", err)
+		http.Error(w, "Failed to retrieve category frequency", http.StatusInternalServerError)
+		return
 	}
+	writeList(w, counts, nil)
+}
+
+const (
+	// defaultTrendingWindow is how far back activity counts when no window is given
+	defaultTrendingWindow = 7 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+	maxTrendingLimit      = 100
+)
+
+// GetTrendingCategories lists the categories with the most recent posts and
+// reactions within the window query parameter (a Go duration such as 24h)
+func (h *CategoryHandler) GetTrendingCategories(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "window", "Invalid window")
+			return
+		}
+		window = parsed
+	}
+
+	limit := defaultTrendingLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxTrendingLimit {
+			writeError(w, http.StatusBadRequest, "limit", "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	categories, err := h.categoryRepo.GetTrendingCategories(window, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve trending categories: %v", err)
+		writeError(w, http.StatusInternalServerError, "", "Failed to retrieve trending categories")
+		return
+	}
+	writeList(w, categories, nil)
 }
 
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
@@ -105,28 +200,19 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "id", "Invalid category ID")
 		return
 	}
 
 	// Decode request body
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate category name
-	category.Name = strings.TrimSpace(category.Name)
-	if category.Name == "" {
-		http.Error(w, "Category name is required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate category name length
-	if len(category.Name) > 50 {
-		http.Error(w, "Category name must be 50 characters or less", http.StatusBadRequest)
+	if !validateCategory(w, &category) {
 		return
 	}
 
@@ -136,8 +222,12 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	// Update category
 	err = h.categoryRepo.Update(&category)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCategory) {
+			writeError(w, http.StatusConflict, "name", "A category with this name already exists")
+			return
+		}
 		log.Printf("Failed to update category: %v", err)
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "", "Failed to update category")
 		return
 	}
 
@@ -184,35 +274,29 @@ func (h *CategoryHandler) GetCategoriesHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	writeList(w, categories, nil)
 }
 
 func (h *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate category name
-	category.Name = strings.TrimSpace(category.Name)
-	if category.Name == "" {
-		http.Error(w, "Category name is required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate category name length
-	if len(category.Name) > 50 {
-		http.Error(w, "Category name must be 50 characters or less", http.StatusBadRequest)
+	if !validateCategory(w, &category) {
 		return
 	}
 
 	err := h.categoryRepo.Create(&category)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCategory) {
+			writeError(w, http.StatusConflict, "name", "A category with this name already exists")
+			return
+		}
 		log.Printf("Failed to create category: %v", err)
-		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "", "Failed to create category")
 		return
 	}
 
@@ -228,28 +312,19 @@ func (h *CategoryHandler) UpdateCategoryHandler(w http.ResponseWriter, r *http.R
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "id", "Invalid category ID")
 		return
 	}
 
 	// Decode request body
 	var category models.Category
 	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate category name
-	category.Name = strings.TrimSpace(category.Name)
-	if category.Name == "" {
-		http.Error(w, "Category name is required", http.StatusBadRequest)
-		return
-	}
-
-	// Validate category name length
-	if len(category.Name) > 50 {
-		http.Error(w, "Category name must be 50 characters or less", http.StatusBadRequest)
+	if !validateCategory(w, &category) {
 		return
 	}
 
@@ -259,8 +334,12 @@ func (h *CategoryHandler) UpdateCategoryHandler(w http.ResponseWriter, r *http.R
 	// Update category
 	err = h.categoryRepo.Update(&category)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCategory) {
+			writeError(w, http.StatusConflict, "name", "A category with this name already exists")
+			return
+		}
 		log.Printf("Failed to update category: %v", err)
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "", "Failed to update category")
 		return
 	}
 
@@ -298,3 +377,42 @@ func (h *CategoryHandler) DeleteCategoryHandler(w http.ResponseWriter, r *http.R
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+type MergeCategoriesRequest struct {
+	SourceID int64 `json:"source_id"`
+	TargetID int64 `json:"target_id"`
+	DryRun   bool  `json:"dry_run"`
+}
+
+// MergeCategories folds a duplicate category into another one; with dry_run
+// set it only reports the affected counts
+func (h *CategoryHandler) MergeCategories(w http.ResponseWriter, r *http.Request) {
+	var req MergeCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.SourceID <= 0 || req.TargetID <= 0 {
+		http.Error(w, "Source and target category IDs are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.categoryRepo.MergeCategories(req.SourceID, req.TargetID, req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMergeIntoSelf):
+			http.Error(w, "Cannot merge a category into itself", http.StatusBadRequest)
+		case errors.Is(err, repository.ErrCategoryNotFound):
+			http.Error(w, "Category not found", http.StatusNotFound)
+		default:
+			log.Printf("Failed to merge categories: %v", err)
+			http.Error(w, "Failed to merge categories", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"forum/models"
+	"forum/repository"
 )
 
 // MockCategoryRepository simulates category repository for testing
@@ -35,11 +42,35 @@ func (m *MockCategoryRepository) GetByID(categoryID int64) (*models.Category, er
 	return args.Get(0).(*models.Category), args.Error(1)
 }
 
+func (m *MockCategoryRepository) CategoriesExist(ids []int64) ([]int64, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetCategoriesByIDs(ids []int64) ([]models.Category, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Category), args.Error(1)
+}
+
 func (m *MockCategoryRepository) ListCategories() ([]models.Category, error) {
 	args := m.Called()
 	return args.Get(0).([]models.Category), args.Error(1)
 }
 
+func (m *MockCategoryRepository) ListCategoriesWithCounts() ([]repository.CategoryWithCount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.CategoryWithCount), args.Error(1)
+}
+
 func (m *MockCategoryRepository) Update(category *models.Category) error {
 	args := m.Called(category)
 	return args.Error(0)
@@ -50,6 +81,38 @@ func (m *MockCategoryRepository) Delete(categoryID int64) error {
 	return args.Error(0)
 }
 
+func (m *MockCategoryRepository) MergeCategories(sourceID, targetID int64, dryRun bool) (*repository.MergeResult, error) {
+	args := m.Called(sourceID, targetID, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.MergeResult), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetCategoryUsageFrequency() ([]repository.CategoryCount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.CategoryCount), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetTrendingCategories(window time.Duration, limit int) ([]repository.TrendingCategory, error) {
+	args := m.Called(window, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.TrendingCategory), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ResolveCategoryNames(names []string, create bool) ([]int64, error) {
+	args := m.Called(names, create)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
 func TestListCategories(t *testing.T) {
 	mockRepo := new(MockCategoryRepository)
 	handler := NewCategoryHandler(mockRepo)
@@ -94,8 +157,7 @@ func TestListCategories(t *testing.T) {
 
 			if tc.expectedStatus == http.StatusOK {
 				var categories []models.Category
-				err := json.NewDecoder(w.Body).Decode(&categories)
-				assert.NoError(t, err)
+				decodeList(t, w.Body, &categories)
 				assert.Len(t, categories, tc.expectedCount)
 			}
 
@@ -103,3 +165,214 @@ func TestListCategories(t *testing.T) {
 		})
 	}
 }
+
+// categoryValidationCases are shared by the create and update handler tests
+var categoryValidationCases = []struct {
+	name           string
+	body           string
+	repoErr        error
+	expectedStatus int
+	expectedError  ErrorResponse
+}{
+	{
+		name:           "Empty Name",
+		body:           `{"name": "   "}`,
+		expectedStatus: http.StatusBadRequest,
+		expectedError:  ErrorResponse{Error: "Category name is required", Field: "name"},
+	},
+	{
+		name:           "Name Too Long",
+		body:           `{"name": "` + strings.Repeat("x", maxCategoryNameLength+1) + `"}`,
+		expectedStatus: http.StatusBadRequest,
+		expectedError:  ErrorResponse{Error: "Category name must be 50 characters or less", Field: "name"},
+	},
+	{
+		name:           "Duplicate Name",
+		body:           `{"name": "Technology"}`,
+		repoErr:        repository.ErrDuplicateCategory,
+		expectedStatus: http.StatusConflict,
+		expectedError:  ErrorResponse{Error: "A category with this name already exists", Field: "name"},
+	},
+	{
+		name:           "Malformed Body",
+		body:           `{"name":`,
+		expectedStatus: http.StatusBadRequest,
+		expectedError:  ErrorResponse{Error: "Invalid request body"},
+	},
+}
+
+// assertErrorBody checks a response carries the JSON error shape
+func assertErrorBody(t *testing.T, w *httptest.ResponseRecorder, expected ErrorResponse) {
+	t.Helper()
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, expected, body)
+}
+
+func TestCreateCategoryValidationErrors(t *testing.T) {
+	for _, tc := range categoryValidationCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			if tc.repoErr != nil {
+				mockRepo.On("Create", mock.AnythingOfType("*models.Category")).Return(tc.repoErr)
+			}
+			handler := NewCategoryHandler(mockRepo)
+
+			req := httptest.NewRequest(http.MethodPost, "/categories", bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+
+			handler.CreateCategory(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assertErrorBody(t, w, tc.expectedError)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateCategoryValidationErrors(t *testing.T) {
+	for _, tc := range categoryValidationCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			if tc.repoErr != nil {
+				mockRepo.On("Update", mock.AnythingOfType("*models.Category")).Return(tc.repoErr)
+			}
+			handler := NewCategoryHandler(mockRepo)
+
+			req := httptest.NewRequest(http.MethodPut, "/categories/3", bytes.NewBufferString(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "3")
+			w := httptest.NewRecorder()
+
+			handler.UpdateCategory(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assertErrorBody(t, w, tc.expectedError)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTrendingCategories(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockBehavior   func(m *MockCategoryRepository)
+		expectedStatus int
+	}{
+		{
+			name: "Default Window",
+			mockBehavior: func(m *MockCategoryRepository) {
+				m.On("GetTrendingCategories", 7*24*time.Hour, 10).Return([]repository.TrendingCategory{
+					{CategoryID: 2, Name: "Technology", Posts: 1, Interactions: 3, Activity: 4},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Custom Window And Limit",
+			query: "?window=24h&limit=3",
+			mockBehavior: func(m *MockCategoryRepository) {
+				m.On("GetTrendingCategories", 24*time.Hour, 3).Return([]repository.TrendingCategory(nil), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Window",
+			query:          "?window=yesterday",
+			mockBehavior:   func(m *MockCategoryRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			tc.mockBehavior(mockRepo)
+
+			w := httptest.NewRecorder()
+			NewCategoryHandler(mockRepo).GetTrendingCategories(w, httptest.NewRequest(http.MethodGet, "/categories/trending"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var categories []repository.TrendingCategory
+				decodeList(t, w.Body, &categories)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListCategoriesWithCounts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockBehavior   func(m *MockCategoryRepository)
+		expectedStatus int
+		expectedCounts map[string]int
+	}{
+		{
+			name:  "With Counts",
+			query: "?with_counts=true",
+			mockBehavior: func(m *MockCategoryRepository) {
+				m.On("ListCategoriesWithCounts").Return([]repository.CategoryWithCount{
+					{Category: models.Category{ID: 1, Name: "Music"}, PostCount: 0},
+					{Category: models.Category{ID: 2, Name: "Technology"}, PostCount: 3},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCounts: map[string]int{"Music": 0, "Technology": 3},
+		},
+		{
+			name:  "Counts Turned Off",
+			query: "?with_counts=false",
+			mockBehavior: func(m *MockCategoryRepository) {
+				m.On("ListCategories").Return([]models.Category{{ID: 1, Name: "Music"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Flag",
+			query:          "?with_counts=maybe",
+			mockBehavior:   func(m *MockCategoryRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Database Error",
+			query: "?with_counts=true",
+			mockBehavior: func(m *MockCategoryRepository) {
+				m.On("ListCategoriesWithCounts").Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			tc.mockBehavior(mockRepo)
+
+			w := httptest.NewRecorder()
+			NewCategoryHandler(mockRepo).ListCategories(w, httptest.NewRequest(http.MethodGet, "/categories"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCounts != nil {
+				var categories []map[string]interface{}
+				decodeList(t, w.Body, &categories)
+				counts := make(map[string]int)
+				for _, category := range categories {
+					counts[category["name"].(string)] = int(category["post_count"].(float64))
+				}
+				assert.Equal(t, tc.expectedCounts, counts)
+			}
+			if tc.expectedStatus == http.StatusInternalServerError {
+				var body ErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, "Failed to retrieve categories", body.Error)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"forum/middleware"
+	"forum/models"
 	"forum/repository"
 
 	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 type CommentHandler struct {
-	commentRepo    repository.CommentRepositoryInterface
-	authMiddleware *middleware.AuthMiddleware
+	commentRepo      repository.CommentRepositoryInterface
+	notificationRepo repository.NotificationRepositoryInterface
+	userRepo         repository.UserRepository
+	authMiddleware   *middleware.AuthMiddleware
 }
 
 func NewCommentHandler(commentRepo repository.CommentRepositoryInterface, authMiddleware *middleware.AuthMiddleware) *CommentHandler {
@@ -24,11 +30,43 @@ func NewCommentHandler(commentRepo repository.CommentRepositoryInterface, authMi
 	}
 }
 
+// WithNotifications enables notifying thread participants of new comments
+func (h *CommentHandler) WithNotifications(notificationRepo repository.NotificationRepositoryInterface) *CommentHandler {
+	h.notificationRepo = notificationRepo
+	return h
+}
+
+// WithUsers lets responses embed the full public profile of comment authors
+func (h *CommentHandler) WithUsers(userRepo repository.UserRepository) *CommentHandler {
+	h.userRepo = userRepo
+	return h
+}
+
+// includeDeleted reads the include_deleted query flag, which only moderators
+// may set; it writes an error response and returns false otherwise
+func (h *CommentHandler) includeDeleted(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := r.URL.Query().Get("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid include_deleted value")
+		return false, false
+	}
+	if include && !h.authMiddleware.IsModerator(r.Context()) {
+		writeJSONError(w, http.StatusForbidden, "Forbidden: moderator access required")
+		return false, false
+	}
+	return include, true
+}
+
 func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	// Check if user is authenticated
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -36,67 +74,307 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	postIDStr := chi.URLParam(r, "postId")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	// Parse request body
 	var req struct {
-		Content string `json:"content"`
+		Content  string `json:"content"`
+		ParentID *int64 `json:"parent_id"`
 	}
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate input
 	if req.Content == "" {
-		http.Error(w, "Comment content is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Comment content is required")
 		return
 	}
 
 	// Create comment
 	newComment := &repository.Comment{
-		PostID:  postID,
-		UserID:  int64(userID),
-		Content: req.Content,
+		PostID:   postID,
+		ParentID: req.ParentID,
+		UserID:   int64(userID),
+		Content:  req.Content,
 	}
 
 	// Save comment to database
 	err = h.commentRepo.Create(newComment)
 	if err != nil {
+		if errors.Is(err, repository.ErrParentCommentNotFound) {
+			writeJSONError(w, http.StatusBadRequest, "Parent comment not found")
+			return
+		}
 		log.Printf("Error creating comment: %v", err)
-		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create comment")
 		return
 	}
 
+	h.notifyParticipants(r.Context(), newComment)
+
 	// Prepare response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(newComment)
 }
 
+// notifyParticipants tells the post author and everyone else in the thread
+// about a new comment using a single batched insert. Each notification records
+// the originating request ID so it can be traced back to the comment. Failures
+// are logged rather than returned since the comment itself was saved
+func (h *CommentHandler) notifyParticipants(ctx context.Context, comment *repository.Comment) {
+	if h.notificationRepo == nil {
+		return
+	}
+
+	requestID := chimiddleware.GetReqID(ctx)
+
+	participantIDs, err := h.commentRepo.GetParticipantIDs(comment.PostID)
+	if err != nil {
+		log.Printf("[%s] Error loading thread participants for comment %d: %v", requestID, comment.ID, err)
+		return
+	}
+
+	var notifications []repository.Notification
+	for _, userID := range participantIDs {
+		if userID == comment.UserID {
+			continue
+		}
+		notifications = append(notifications, repository.Notification{
+			UserID:     userID,
+			ActorID:    comment.UserID,
+			Type:       repository.NotificationTypeComment,
+			EntityType: models.EntityTypeComment,
+			EntityID:   comment.ID,
+			RequestID:  requestID,
+		})
+	}
+
+	if err := h.notificationRepo.CreateBatch(notifications); err != nil {
+		log.Printf("[%s] Error creating notifications for comment %d: %v", requestID, comment.ID, err)
+		return
+	}
+	log.Printf("[%s] Created %d notifications for comment %d", requestID, len(notifications), comment.ID)
+}
+
+// GetComments lists a post's comments a page at a time, newest first unless
+// sort asks for old or top (most liked) first
 func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 	// Get post ID from URL
 	postIDStr := chi.URLParam(r, "postId")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	includeDeleted, ok := h.includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	view, ok := parseUserViewParam(w, r)
+	if !ok {
 		return
 	}
 
-	// Retrieve comments for the post
-	comments, err := h.commentRepo.GetByPostID(postID)
+	page, limit := parsePagination(r)
+
+	sort := repository.CommentSortNew
+	if sortParam := r.URL.Query().Get("sort"); sortParam != "" {
+		parsed, err := repository.ParseCommentSort(sortParam)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid sort")
+			return
+		}
+		sort = parsed
+	}
+
+	// Retrieve one page of comments for the post
+	comments, totalCount, err := h.commentRepo.GetByPostIDPaginated(postID, page, limit, sort, includeDeleted)
 	if err != nil {
 		log.Printf("Error retrieving comments: %v", err)
-		http.Error(w, "Failed to retrieve comments", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comments")
+		return
+	}
+
+	if view != UserViewNone {
+		embedded, err := newAuthorEmbedder(view, h.userRepo).comments(comments)
+		if err != nil {
+			log.Printf("Error embedding comment authors: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comments")
+			return
+		}
+		writePage(w, r, embedded, newPagination(page, limit, totalCount))
+		return
+	}
+
+	writePage(w, r, comments, newPagination(page, limit, totalCount))
+}
+
+func (h *CommentHandler) GetComment(w http.ResponseWriter, r *http.Request) {
+	// Get comment ID from URL
+	commentIDStr := chi.URLParam(r, "id")
+	commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	includeDeleted, ok := h.includeDeleted(w, r)
+	if !ok {
 		return
 	}
 
+	view, ok := parseUserViewParam(w, r)
+	if !ok {
+		return
+	}
+
+	// Retrieve the comment
+	comment, err := h.commentRepo.GetByID(commentID, includeDeleted)
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Comment not found")
+			return
+		}
+		log.Printf("Error retrieving comment: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+		return
+	}
+
+	var response interface{} = comment
+	if view != UserViewNone {
+		embedded, err := newAuthorEmbedder(view, h.userRepo).comment(*comment)
+		if err != nil {
+			log.Printf("Error embedding comment author: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+			return
+		}
+		response = embedded
+	}
+
 	// Prepare response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(comments)
+	json.NewEncoder(w).Encode(response)
+}
+
+// maxRecentComments caps the size of the moderator comment feed
+const maxRecentComments = 200
+
+// GetRecentComments returns the newest comments site-wide for moderators
+func (h *CommentHandler) GetRecentComments(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 || l > maxRecentComments {
+			writeJSONError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = l
+	}
+
+	comments, err := h.commentRepo.GetRecentComments(limit)
+	if err != nil {
+		log.Printf("Error retrieving recent comments: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comments")
+		return
+	}
+
+	writeList(w, comments, nil)
+}
+
+// UpdateComment edits the content of the current user's comment and responds
+// with the updated comment
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	// Check if user is authenticated
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get comment ID from URL
+	commentIDStr := chi.URLParam(r, "id")
+	commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate input
+	if req.Content == "" {
+		writeJSONError(w, http.StatusBadRequest, "Comment content is required")
+		return
+	}
+
+	// Only the author may edit; other users' comments look missing
+	err = h.commentRepo.UpdateComment(commentID, int64(userID), req.Content)
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Comment not found")
+			return
+		}
+		log.Printf("Error updating comment: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update comment")
+		return
+	}
+
+	comment, err := h.commentRepo.GetByID(commentID, false)
+	if err != nil {
+		log.Printf("Error retrieving updated comment: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	// Check if user is authenticated
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Get comment ID from URL
+	commentIDStr := chi.URLParam(r, "id")
+	commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	// Soft-delete the comment so its replies stay in the thread
+	err = h.commentRepo.DeleteComment(commentID, int64(userID))
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Comment not found")
+			return
+		}
+		log.Printf("Error deleting comment: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to delete comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
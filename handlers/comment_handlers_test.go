@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -24,16 +27,103 @@ func (m *MockCommentRepository) Create(comment *repository.Comment) error {
 	return args.Error(0)
 }
 
-func (m *MockCommentRepository) GetByPostID(postID int64) ([]repository.Comment, error) {
-	args := m.Called(postID)
+func (m *MockCommentRepository) GetByPostID(postID int64, includeDeleted bool) ([]repository.Comment, error) {
+	args := m.Called(postID, includeDeleted)
 	repoComments := args.Get(0).([]repository.Comment)
 
 	return repoComments, args.Error(1)
 }
 
+func (m *MockCommentRepository) GetByPostIDPaginated(postID int64, page, limit int, sort repository.CommentSort, includeDeleted bool) ([]repository.Comment, int, error) {
+	args := m.Called(postID, page, limit, sort, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]repository.Comment), args.Int(1), args.Error(2)
+}
+
+func (m *MockCommentRepository) GetByID(commentID int64, includeDeleted bool) (*repository.Comment, error) {
+	args := m.Called(commentID, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateComment(commentID, userID int64, content string) error {
+	args := m.Called(commentID, userID, content)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteComment(commentID, userID int64) error {
+	args := m.Called(commentID, userID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetParticipantIDs(postID int64) ([]int64, error) {
+	args := m.Called(postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetRecentComments(limit int) ([]repository.Comment, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]repository.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) CountUserComments(userID int64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetUserComments(userID int64, page, limit int) ([]repository.Comment, int, error) {
+	args := m.Called(userID, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]repository.Comment), args.Int(1), args.Error(2)
+}
+
 // Ensure MockCommentRepository implements the interface
 var _ repository.CommentRepositoryInterface = &MockCommentRepository{}
 
+// MockNotificationRepository simulates notification repository for testing
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(notification *repository.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) CreateBatch(notifications []repository.Notification) error {
+	args := m.Called(notifications)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUserID(userID int64, limit int) ([]repository.Notification, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]repository.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetPreferences(userID int64) (map[string]bool, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func (m *MockNotificationRepository) UpdatePreferences(userID int64, preferences map[string]bool) error {
+	args := m.Called(userID, preferences)
+	return args.Error(0)
+}
+
+var _ repository.NotificationRepositoryInterface = &MockNotificationRepository{}
+
 func TestCreateComment(t *testing.T) {
 	mockRepo := new(MockCommentRepository)
 	mockAuthMiddleware := &middleware.AuthMiddleware{}
@@ -84,29 +174,119 @@ func TestCreateComment(t *testing.T) {
 	}
 }
 
+func TestUpdateComment(t *testing.T) {
+	testCases := []struct {
+		name           string
+		payload        map[string]interface{}
+		mockBehavior   func(m *MockCommentRepository)
+		expectedStatus int
+	}{
+		{
+			name:    "Author Edits",
+			payload: map[string]interface{}{"content": "Edited"},
+			mockBehavior: func(m *MockCommentRepository) {
+				m.On("UpdateComment", int64(5), int64(2), "Edited").Return(nil)
+				m.On("GetByID", int64(5), false).Return(&repository.Comment{ID: 5, UserID: 2, Content: "Edited"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "Not The Author",
+			payload: map[string]interface{}{"content": "Hijacked"},
+			mockBehavior: func(m *MockCommentRepository) {
+				m.On("UpdateComment", int64(5), int64(2), "Hijacked").Return(repository.ErrCommentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Empty Content",
+			payload:        map[string]interface{}{"content": ""},
+			mockBehavior:   func(m *MockCommentRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewCommentHandler(mockRepo, &middleware.AuthMiddleware{})
+
+			payload, _ := json.Marshal(tc.payload)
+			req := httptest.NewRequest(http.MethodPut, "/comments/5", bytes.NewBuffer(payload))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "5")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+			w := httptest.NewRecorder()
+
+			handler.UpdateComment(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetComments(t *testing.T) {
 	mockRepo := new(MockCommentRepository)
 	mockAuthMiddleware := &middleware.AuthMiddleware{}
 	handler := NewCommentHandler(mockRepo, mockAuthMiddleware)
 
 	testCases := []struct {
-		name           string
-		postID         string
-		mockBehavior   func()
-		expectedStatus int
-		expectedCount  int
+		name               string
+		postID             string
+		query              string
+		mockBehavior       func()
+		expectedStatus     int
+		expectedCount      int
+		expectedPagination *Pagination
 	}{
 		{
 			name:   "Successful Comments Retrieval",
 			postID: "1",
 			mockBehavior: func() {
-				mockRepo.On("GetByPostID", int64(1)).Return([]repository.Comment{
+				mockRepo.On("GetByPostIDPaginated", int64(1), 1, 10, repository.CommentSortNew, false).Return([]repository.Comment{
 					{ID: 1, PostID: 1, Content: "Comment 1"},
 					{ID: 2, PostID: 1, Content: "Comment 2"},
-				}, nil)
+				}, 2, nil)
 			},
-			expectedStatus: http.StatusOK,
-			expectedCount:  2,
+			expectedStatus:     http.StatusOK,
+			expectedCount:      2,
+			expectedPagination: &Pagination{Page: 1, Limit: 10, TotalCount: 2, TotalPages: 1},
+		},
+		{
+			name:   "Second Page",
+			postID: "2",
+			query:  "?page=2&limit=25",
+			mockBehavior: func() {
+				mockRepo.On("GetByPostIDPaginated", int64(2), 2, 25, repository.CommentSortNew, false).Return([]repository.Comment{
+					{ID: 26, PostID: 2, Content: "Comment 26"},
+				}, 26, nil)
+			},
+			expectedStatus:     http.StatusOK,
+			expectedCount:      1,
+			expectedPagination: &Pagination{Page: 2, Limit: 25, TotalCount: 26, TotalPages: 2},
+		},
+		{
+			name:   "Top Sort",
+			postID: "3",
+			query:  "?sort=top",
+			mockBehavior: func() {
+				mockRepo.On("GetByPostIDPaginated", int64(3), 1, 10, repository.CommentSortTop, false).Return([]repository.Comment{
+					{ID: 31, PostID: 3, Content: "Popular"},
+				}, 1, nil)
+			},
+			expectedStatus:     http.StatusOK,
+			expectedCount:      1,
+			expectedPagination: &Pagination{Page: 1, Limit: 10, TotalCount: 1, TotalPages: 1},
+		},
+		{
+			name:           "Invalid Sort",
+			postID:         "3",
+			query:          "?sort=loudest",
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:   "Invalid Post ID",
@@ -123,10 +303,10 @@ func TestGetComments(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockBehavior()
 
-			req := httptest.NewRequest(http.MethodGet, "/comments", nil)
-			q := req.URL.Query()
-			q.Add("post_id", tc.postID)
-			req.URL.RawQuery = q.Encode()
+			req := httptest.NewRequest(http.MethodGet, "/posts/"+tc.postID+"/comments"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("postId", tc.postID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
 			w := httptest.NewRecorder()
 
@@ -136,12 +316,192 @@ func TestGetComments(t *testing.T) {
 
 			if tc.expectedStatus == http.StatusOK {
 				var comments []repository.Comment
-				err := json.NewDecoder(w.Body).Decode(&comments)
-				assert.NoError(t, err)
+				pagination := decodeList(t, w.Body, &comments)
 				assert.Len(t, comments, tc.expectedCount)
+				assert.Equal(t, tc.expectedPagination, pagination)
 			}
 
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestGetComment(t *testing.T) {
+	mockRepo := new(MockCommentRepository)
+	mockAuthMiddleware := &middleware.AuthMiddleware{}
+	handler := NewCommentHandler(mockRepo, mockAuthMiddleware)
+
+	testCases := []struct {
+		name           string
+		commentID      string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:      "Comment Found",
+			commentID: "1",
+			mockBehavior: func() {
+				mockRepo.On("GetByID", int64(1), false).Return(&repository.Comment{
+					ID: 1, PostID: 1, UserID: 2, Username: "alice", Content: "Comment 1",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "Comment Not Found",
+			commentID: "2",
+			mockBehavior: func() {
+				mockRepo.On("GetByID", int64(2), false).Return(nil, repository.ErrCommentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Comment ID",
+			commentID:      "invalid",
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			req := httptest.NewRequest(http.MethodGet, "/comments/"+tc.commentID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.commentID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+
+			handler.GetComment(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedStatus == http.StatusOK {
+				var comment repository.Comment
+				err := json.NewDecoder(w.Body).Decode(&comment)
+				assert.NoError(t, err)
+				assert.Equal(t, "alice", comment.Username)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetCommentsIncludeDeleted(t *testing.T) {
+	const moderatorID, regularID = 1, 2
+
+	testCases := []struct {
+		name           string
+		userID         int
+		query          string
+		mockBehavior   func(m *MockCommentRepository)
+		expectedStatus int
+		expectedText   string
+	}{
+		{
+			name:   "Moderator Sees Deleted Content",
+			userID: moderatorID,
+			query:  "?include_deleted=true",
+			mockBehavior: func(m *MockCommentRepository) {
+				m.On("GetByPostIDPaginated", int64(1), 1, 10, repository.CommentSortNew, true).Return([]repository.Comment{
+					{ID: 1, PostID: 1, Content: "Original thought", Deleted: true},
+				}, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedText:   "Original thought",
+		},
+		{
+			name:           "Regular User Forbidden",
+			userID:         regularID,
+			query:          "?include_deleted=true",
+			mockBehavior:   func(m *MockCommentRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "Regular User Sees Tombstone",
+			userID: regularID,
+			mockBehavior: func(m *MockCommentRepository) {
+				m.On("GetByPostIDPaginated", int64(1), 1, 10, repository.CommentSortNew, false).Return([]repository.Comment{
+					{ID: 1, PostID: 1, Content: repository.DeletedCommentContent, Deleted: true},
+				}, 1, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedText:   repository.DeletedCommentContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			authMiddleware := (&middleware.AuthMiddleware{}).WithModerators(moderatorID)
+			handler := NewCommentHandler(mockRepo, authMiddleware)
+			tc.mockBehavior(mockRepo)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/1/comments"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("postId", "1")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, middleware.UserClaimsKey, &middleware.Claims{UserID: tc.userID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+
+			handler.GetComments(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedStatus == http.StatusOK {
+				var comments []repository.Comment
+				decodeList(t, w.Body, &comments)
+				if assert.Len(t, comments, 1) {
+					assert.Equal(t, tc.expectedText, comments[0].Content)
+				}
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateCommentNotificationCarriesRequestID(t *testing.T) {
+	mockRepo := new(MockCommentRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+	handler := NewCommentHandler(mockRepo, &middleware.AuthMiddleware{}).WithNotifications(mockNotificationRepo)
+
+	const commenterID, authorID = 3, 1
+
+	mockRepo.On("Create", mock.AnythingOfType("*repository.Comment")).Run(func(args mock.Arguments) {
+		args.Get(0).(*repository.Comment).ID = 42
+	}).Return(nil)
+	mockRepo.On("GetParticipantIDs", int64(5)).Return([]int64{authorID, commenterID}, nil)
+
+	var notifications []repository.Notification
+	mockNotificationRepo.On("CreateBatch", mock.Anything).Run(func(args mock.Arguments) {
+		notifications = args.Get(0).([]repository.Notification)
+	}).Return(nil)
+
+	payload, _ := json.Marshal(map[string]interface{}{"content": "Nice post"})
+	req := httptest.NewRequest(http.MethodPost, "/posts/5/comments", bytes.NewBuffer(payload))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("postId", "5")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, middleware.UserClaimsKey, &middleware.Claims{UserID: commenterID})
+	ctx = context.WithValue(ctx, chimiddleware.RequestIDKey, "forum/req-000042")
+	req = req.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+
+	handler.CreateComment(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, int64(authorID), notifications[0].UserID)
+		assert.Equal(t, int64(42), notifications[0].EntityID)
+		assert.Equal(t, "forum/req-000042", notifications[0].RequestID)
+	}
+	mockRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"forum/config"
+)
+
+type ConfigHandler struct {
+	cfg *config.Config
+}
+
+func NewConfigHandler(cfg *config.Config) *ConfigHandler {
+	return &ConfigHandler{cfg: cfg}
+}
+
+// GetConfig reports the effective configuration with secrets redacted
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.cfg.Redacted()); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
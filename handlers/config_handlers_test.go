@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"forum/config"
+)
+
+func TestGetConfigRedactsSecrets(t *testing.T) {
+	cfg := &config.Config{
+		DBConnectionString: "file:forum.db?_auth_user=admin&_auth_pass=hunter2",
+		JWTSecret:          "a-sufficiently-long-test-secret-value",
+		UploadsSigningKey:  "a-separate-attachment-signing-key",
+		ServerPort:         "8080",
+	}
+	handler := NewConfigHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetConfig(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.NotContains(t, body, "a-sufficiently-long-test-secret-value")
+	assert.NotContains(t, body, "a-separate-attachment-signing-key")
+	assert.NotContains(t, body, "hunter2")
+	assert.Contains(t, body, `"JWTSecret":"[REDACTED]"`)
+	assert.Contains(t, body, `"ServerPort":"8080"`)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Pagination describes the page of a collection returned in a ListResponse
+type Pagination struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+// ListResponse is the envelope every collection endpoint responds with;
+// Pagination is omitted for collections that are returned whole
+type ListResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// newPagination computes the page metadata for a collection of totalCount items
+func newPagination(page, limit, totalCount int) *Pagination {
+	totalPages := 0
+	if limit > 0 {
+		// Ceiling division of total count by limit
+		totalPages = (totalCount + limit - 1) / limit
+	}
+	return &Pagination{
+		Page:       page,
+		Limit:      limit,
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+	}
+}
+
+// writeList encodes items in the standard list envelope, rendering a nil
+// slice as an empty array so clients never see "data": null
+func writeList(w http.ResponseWriter, items interface{}, pagination *Pagination) {
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ListResponse{Data: items, Pagination: pagination}); err != nil {
+		log.Printf("Failed to encode list response: %v", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writePage encodes one page of a collection like writeList and links the
+// neighbouring pages in an RFC 5988 Link header
+func writePage(w http.ResponseWriter, r *http.Request, items interface{}, pagination *Pagination) {
+	setPageLinks(w, r, pagination)
+	writeList(w, items, pagination)
+}
+
+// setPageLinks sets the Link header to the next, previous, first and last
+// pages of the request's URL, keeping its other query parameters. An empty
+// collection still has a first and last page
+func setPageLinks(w http.ResponseWriter, r *http.Request, pagination *Pagination) {
+	lastPage := max(pagination.TotalPages, 1)
+	link := func(page int, rel string) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(pagination.Limit))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+	}
+
+	var links []string
+	if pagination.Page < lastPage {
+		links = append(links, link(pagination.Page+1, "next"))
+	}
+	if pagination.Page > 1 {
+		links = append(links, link(min(pagination.Page-1, lastPage), "prev"))
+	}
+	links = append(links, link(1, "first"), link(lastPage, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// ErrorResponse is the JSON body of a failed request; Field names the request
+// field that failed validation, when there is one
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"`
+}
+
+// writeError responds with status and a JSON ErrorResponse
+func writeError(w http.ResponseWriter, status int, field, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: message, Field: field}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
+
+// writeJSONError responds with status and a JSON ErrorResponse that is not
+// tied to a request field
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeError(w, status, "", message)
+}
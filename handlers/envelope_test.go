@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"forum/models"
+	"forum/repository"
+)
+
+// decodeList unpacks a list envelope into data and returns its pagination
+func decodeList(t *testing.T, body io.Reader, data interface{}) *Pagination {
+	t.Helper()
+
+	var envelope struct {
+		Data       json.RawMessage `json:"data"`
+		Pagination *Pagination     `json:"pagination"`
+	}
+	require.NoError(t, json.NewDecoder(body).Decode(&envelope))
+	require.NoError(t, json.Unmarshal(envelope.Data, data))
+	return envelope.Pagination
+}
+
+// envelopeKeys returns the top-level keys of a JSON object response
+func envelopeKeys(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var object map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &object))
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestListEnvelopeShape(t *testing.T) {
+	t.Run("Posts", func(t *testing.T) {
+		mockPostRepo := new(MockPostRepository)
+		mockPostRepo.On("ListPosts", 2, 5, repository.PostSortNewest, (*int64)(nil)).
+			Return([]models.Post{{ID: 6}, {ID: 7}}, 12, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/posts?page=2&limit=5", nil)
+		w := httptest.NewRecorder()
+		NewPostHandler(mockPostRepo, nil, nil).ListPosts(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []string{"data", "pagination"}, envelopeKeys(t, w.Body.Bytes()))
+
+		var posts []models.Post
+		pagination := decodeList(t, w.Body, &posts)
+		assert.Len(t, posts, 2)
+		assert.Equal(t, &Pagination{Page: 2, Limit: 5, TotalCount: 12, TotalPages: 3}, pagination)
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		mockRepo := new(MockCategoryRepository)
+		mockRepo.On("ListCategories").Return([]models.Category{{ID: 1, Name: "Go"}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+		w := httptest.NewRecorder()
+		NewCategoryHandler(mockRepo).ListCategories(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"data"}, envelopeKeys(t, w.Body.Bytes()))
+
+		var categories []models.Category
+		assert.Nil(t, decodeList(t, w.Body, &categories))
+		assert.Equal(t, []models.Category{{ID: 1, Name: "Go"}}, categories)
+	})
+
+	t.Run("Filtered Posts", func(t *testing.T) {
+		mockPostRepo := new(MockPostRepository)
+		mockPostRepo.On("FilterPosts", mock.Anything, repository.AnonymousViewer).Return([]models.Post(nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/posts/filter?category_id=3", nil)
+		w := httptest.NewRecorder()
+		NewPostHandler(mockPostRepo, nil, nil).FilterPosts(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+	})
+}
+
+func TestWriteJSONError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"Unauthorized"}`, w.Body.String())
+}
+
+func TestHandlerErrorsAreJSON(t *testing.T) {
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		request        *http.Request
+		expectedStatus int
+	}{
+		{
+			name:           "Post Invalid Sort",
+			handler:        NewPostHandler(new(MockPostRepository), nil, nil).ListPosts,
+			request:        httptest.NewRequest(http.MethodGet, "/posts?sort=random", nil),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Interaction Missing Entity",
+			handler:        NewInteractionHandler(new(MockInteractionRepository), nil).GetInteractionCounts,
+			request:        httptest.NewRequest(http.MethodGet, "/interactions/counts", nil),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Comment Invalid Post ID",
+			handler:        NewCommentHandler(new(MockCommentRepository), nil).GetComments,
+			request:        httptest.NewRequest(http.MethodGet, "/posts/abc/comments", nil),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, tc.request)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	links := func(target string, totalCount int) map[string]string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		page, limit := parsePagination(req)
+		w := httptest.NewRecorder()
+		writePage(w, req, []models.Post{}, newPagination(page, limit, totalCount))
+
+		rels := make(map[string]string)
+		for _, link := range strings.Split(w.Header().Get("Link"), ", ") {
+			url, rel, found := strings.Cut(link, "; ")
+			require.True(t, found, link)
+			rels[strings.TrimSuffix(strings.TrimPrefix(rel, `rel="`), `"`)] = strings.Trim(url, "<>")
+		}
+		return rels
+	}
+
+	t.Run("Middle Page", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"next":  "/posts?limit=5&page=3&sort=top",
+			"prev":  "/posts?limit=5&page=1&sort=top",
+			"first": "/posts?limit=5&page=1&sort=top",
+			"last":  "/posts?limit=5&page=4&sort=top",
+		}, links("/posts?page=2&limit=5&sort=top", 17))
+	})
+
+	t.Run("First Page", func(t *testing.T) {
+		rels := links("/posts", 25)
+		assert.NotContains(t, rels, "prev")
+		assert.Equal(t, "/posts?limit=10&page=2", rels["next"])
+		assert.Equal(t, "/posts?limit=10&page=3", rels["last"])
+	})
+
+	t.Run("Last Page", func(t *testing.T) {
+		rels := links("/posts?page=3", 25)
+		assert.NotContains(t, rels, "next")
+		assert.Equal(t, "/posts?limit=10&page=2", rels["prev"])
+	})
+
+	t.Run("Empty Collection", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"first": "/posts?limit=10&page=1",
+			"last":  "/posts?limit=10&page=1",
+		}, links("/posts", 0))
+	})
+}
@@ -2,18 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"forum/middleware"
 	"forum/models"
 	"forum/repository"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type InteractionHandler struct {
 	interactionRepo repository.InteractionRepositoryInterface
 	authMiddleware  *middleware.AuthMiddleware
+	maxBatchIDs     int
 }
 
 func NewInteractionHandler(
@@ -23,12 +30,26 @@ func NewInteractionHandler(
 	return &InteractionHandler{
 		interactionRepo: interactionRepo,
 		authMiddleware:  authMiddleware,
+		maxBatchIDs:     DefaultMaxBatchIDs,
+	}
+}
+
+// DefaultMaxBatchIDs caps how many entities a single batch lookup may request
+// unless WithMaxBatchIDs says otherwise
+const DefaultMaxBatchIDs = 100
+
+// WithMaxBatchIDs sets how many distinct entity IDs a batch lookup may
+// request; a non-positive max keeps DefaultMaxBatchIDs
+func (h *InteractionHandler) WithMaxBatchIDs(max int) *InteractionHandler {
+	if max > 0 {
+		h.maxBatchIDs = max
 	}
+	return h
 }
 
 type InteractionRequest struct {
-	EntityID   int64  `json:"entity_id"`
-	EntityType string `json:"entity_type"`
+	EntityID   int64             `json:"entity_id"`
+	EntityType models.EntityType `json:"entity_type"`
 }
 
 type InteractionResponse struct {
@@ -40,26 +61,26 @@ func (h *InteractionHandler) LikeEntity(w http.ResponseWriter, r *http.Request)
 	// Check if user is logged in
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized: Login required", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
 		return
 	}
 
 	var request InteractionRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate request
 	if request.EntityID <= 0 || request.EntityType == "" {
-		http.Error(w, "Invalid entity details", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity details")
 		return
 	}
 
 	// Validate entity type
-	if request.EntityType != "post" && request.EntityType != "comment" {
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
+	if !request.EntityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
 		return
 	}
 
@@ -71,8 +92,12 @@ func (h *InteractionHandler) LikeEntity(w http.ResponseWriter, r *http.Request)
 		models.Like,
 	)
 	if err != nil {
+		if errors.Is(err, repository.ErrEntityNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Entity not found")
+			return
+		}
 		log.Printf("Failed to add like: %v", err)
-		http.Error(w, "Failed to add like", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to add like")
 		return
 	}
 
@@ -83,26 +108,26 @@ func (h *InteractionHandler) DislikeEntity(w http.ResponseWriter, r *http.Reques
 	// Check if user is logged in
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized: Login required", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
 		return
 	}
 
 	var request InteractionRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate request
 	if request.EntityID <= 0 || request.EntityType == "" {
-		http.Error(w, "Invalid entity details", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity details")
 		return
 	}
 
 	// Validate entity type
-	if request.EntityType != "post" && request.EntityType != "comment" {
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
+	if !request.EntityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
 		return
 	}
 
@@ -114,35 +139,152 @@ func (h *InteractionHandler) DislikeEntity(w http.ResponseWriter, r *http.Reques
 		models.Dislike,
 	)
 	if err != nil {
+		if errors.Is(err, repository.ErrEntityNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Entity not found")
+			return
+		}
 		log.Printf("Failed to add dislike: %v", err)
-		http.Error(w, "Failed to add dislike", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to add dislike")
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+// RemoveInteraction undoes the current user's like or dislike on an entity
+func (h *InteractionHandler) RemoveInteraction(w http.ResponseWriter, r *http.Request) {
+	// Check if user is logged in
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
+		return
+	}
+
+	var request InteractionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate request
+	if request.EntityID <= 0 || request.EntityType == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity details")
+		return
+	}
+
+	// Validate entity type
+	if !request.EntityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
+		return
+	}
+
+	err := h.interactionRepo.RemoveInteraction(int64(userID), request.EntityID, request.EntityType)
+	if err != nil {
+		if errors.Is(err, repository.ErrInteractionNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Interaction not found")
+			return
+		}
+		log.Printf("Failed to remove interaction: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to remove interaction")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ToggleInteractionRequest struct {
+	EntityID   int64             `json:"entity_id"`
+	EntityType models.EntityType `json:"entity_type"`
+	Type       string            `json:"type"` // "like" or "dislike"
+}
+
+type ToggleInteractionResponse struct {
+	Type string `json:"type"` // "like", "dislike" or "none"
+}
+
+// ToggleInteraction applies a like or dislike with toggle semantics so that
+// repeating a reaction clears it, and reports the user's resulting reaction
+func (h *InteractionHandler) ToggleInteraction(w http.ResponseWriter, r *http.Request) {
+	// Check if user is logged in
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
+		return
+	}
+
+	var request ToggleInteractionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate request
+	if request.EntityID <= 0 || request.EntityType == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity details")
+		return
+	}
+
+	// Validate entity type
+	if !request.EntityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
+		return
+	}
+
+	var interactionType models.InteractionType
+	switch request.Type {
+	case models.Like.String():
+		interactionType = models.Like
+	case models.Dislike.String():
+		interactionType = models.Dislike
+	default:
+		writeJSONError(w, http.StatusBadRequest, "Invalid interaction type")
+		return
+	}
+
+	result, err := h.interactionRepo.ToggleInteraction(int64(userID), request.EntityID, request.EntityType, interactionType)
+	if err != nil {
+		if errors.Is(err, repository.ErrEntityNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Entity not found")
+			return
+		}
+		log.Printf("Failed to toggle interaction: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to toggle interaction")
+		return
+	}
+
+	response := ToggleInteractionResponse{Type: "none"}
+	if result != 0 {
+		response.Type = result.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
 func (h *InteractionHandler) GetInteractionCounts(w http.ResponseWriter, r *http.Request) {
 	// Parse entity details from query parameters
 	entityID := r.URL.Query().Get("entity_id")
-	entityType := r.URL.Query().Get("entity_type")
+	entityType := models.EntityType(r.URL.Query().Get("entity_type"))
 
 	// Validate input
 	if entityID == "" || entityType == "" {
-		http.Error(w, "Missing entity details", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Missing entity details")
 		return
 	}
 
 	// Validate entity type
-	if entityType != "post" && entityType != "comment" {
-		http.Error(w, "Invalid entity type", http.StatusBadRequest)
+	if !entityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
 		return
 	}
 
 	// Convert entityID to int64
 	parsedEntityID, err := strconv.ParseInt(entityID, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid entity ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity ID")
 		return
 	}
 
@@ -150,7 +292,7 @@ func (h *InteractionHandler) GetInteractionCounts(w http.ResponseWriter, r *http
 	likes, dislikes, err := h.interactionRepo.GetInteractionCounts(parsedEntityID, entityType)
 	if err != nil {
 		log.Printf("Failed to retrieve interaction counts: %v", err)
-		http.Error(w, "Failed to retrieve interaction counts", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve interaction counts")
 		return
 	}
 
@@ -162,6 +304,223 @@ func (h *InteractionHandler) GetInteractionCounts(w http.ResponseWriter, r *http
 	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Failed to encode response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to encode response")
+	}
+}
+
+// GetUserInteractions reports the logged-in user's reaction to each entity in
+// the comma separated entity_ids list, keyed by entity ID
+func (h *InteractionHandler) GetUserInteractions(w http.ResponseWriter, r *http.Request) {
+	// Check if user is logged in
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
+		return
 	}
+
+	entityType := models.EntityType(r.URL.Query().Get("entity_type"))
+	if !entityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
+		return
+	}
+
+	entityIDs, ok := h.parseEntityIDs(w, r.URL.Query().Get("entity_ids"))
+	if !ok {
+		return
+	}
+
+	interactions, err := h.interactionRepo.GetUserInteractions(int64(userID), entityIDs, entityType)
+	if err != nil {
+		log.Printf("Failed to retrieve user interactions: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user interactions")
+		return
+	}
+
+	// Respond with "like" or "dislike" per reacted entity
+	response := make(map[int64]string, len(interactions))
+	for entityID, interactionType := range interactions {
+		response[entityID] = interactionType.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to encode response")
+	}
+}
+
+// parseEntityIDs reads a comma separated list of entity IDs, dropping
+// repeats, and answers 400 for malformed IDs or more distinct IDs than the
+// batch limit
+func (h *InteractionHandler) parseEntityIDs(w http.ResponseWriter, value string) ([]int64, bool) {
+	var entityIDs []int64
+	seen := make(map[int64]bool)
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		entityID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || entityID <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid entity ID")
+			return nil, false
+		}
+		if seen[entityID] {
+			continue
+		}
+		seen[entityID] = true
+		entityIDs = append(entityIDs, entityID)
+		if len(entityIDs) > h.maxBatchIDs {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Too many entity IDs, at most %d allowed", h.maxBatchIDs))
+			return nil, false
+		}
+	}
+	return entityIDs, true
+}
+
+// EntityInteractionSummary is the reaction counts on one entity and the
+// viewer's own reaction, null when they have not reacted
+type EntityInteractionSummary struct {
+	Likes          int     `json:"likes"`
+	Dislikes       int     `json:"dislikes"`
+	ViewerReaction *string `json:"viewer_reaction"`
+}
+
+// GetInteractionSummaries reports the like and dislike counts and the
+// logged-in user's reaction for each entity in the comma separated ids list,
+// keyed by entity ID
+func (h *InteractionHandler) GetInteractionSummaries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
+		return
+	}
+
+	entityType := models.EntityType(r.URL.Query().Get("entity_type"))
+	if !entityType.Valid() {
+		writeJSONError(w, http.StatusBadRequest, "Invalid entity type")
+		return
+	}
+
+	entityIDs, ok := h.parseEntityIDs(w, r.URL.Query().Get("ids"))
+	if !ok {
+		return
+	}
+
+	summaries, err := h.interactionRepo.GetInteractionSummaries(int64(userID), entityIDs, entityType)
+	if err != nil {
+		log.Printf("Failed to retrieve interaction summaries: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve interaction summaries")
+		return
+	}
+
+	// Every requested entity is present, with zero counts if nobody reacted
+	response := make(map[int64]EntityInteractionSummary, len(entityIDs))
+	for _, entityID := range entityIDs {
+		summary := summaries[entityID]
+		entry := EntityInteractionSummary{Likes: summary.Likes, Dislikes: summary.Dislikes}
+		if summary.ViewerReaction != 0 {
+			reaction := summary.ViewerReaction.String()
+			entry.ViewerReaction = &reaction
+		}
+		response[entityID] = entry
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+const (
+	// defaultLeaderboardWindow is the ranking window used when none is given
+	defaultLeaderboardWindow = 7 * 24 * time.Hour
+	defaultLeaderboardLimit  = 10
+	maxLeaderboardLimit      = 100
+)
+
+// GetLeaderboard ranks users by likes received on their posts within the
+// window query parameter (a Go duration such as 168h)
+func (h *InteractionHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	window := defaultLeaderboardWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid window")
+			return
+		}
+		window = parsed
+	}
+
+	limit := defaultLeaderboardLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxLeaderboardLimit {
+			writeJSONError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.interactionRepo.GetTopUsersByLikes(window, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve leaderboard: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve leaderboard")
+		return
+	}
+	writeList(w, entries, nil)
+}
+
+type InteractionSummaryResponse struct {
+	UserID        int64 `json:"user_id"`
+	LikesGiven    int   `json:"likes_given"`
+	DislikesGiven int   `json:"dislikes_given"`
+	LikesReceived int   `json:"likes_received"`
+}
+
+// GetUserInteractionSummary reports a user's reaction totals for their profile
+func (h *InteractionHandler) GetUserInteractionSummary(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	likesGiven, dislikesGiven, likesReceived, err := h.interactionRepo.GetUserInteractionSummary(userID)
+	if err != nil {
+		log.Printf("Failed to retrieve interaction summary: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve interaction summary")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := InteractionSummaryResponse{
+		UserID:        userID,
+		LikesGiven:    likesGiven,
+		DislikesGiven: dislikesGiven,
+		LikesReceived: likesReceived,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to encode response")
+	}
+}
+
+// GetReceivedInteractions lists the reactions other users left on the current
+// user's posts and comments, newest first
+func (h *InteractionHandler) GetReceivedInteractions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized: Login required")
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	details, totalCount, err := h.interactionRepo.GetReceivedInteractions(int64(userID), page, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve received interactions: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve received interactions")
+		return
+	}
+
+	writePage(w, r, details, newPagination(page, limit, totalCount))
 }
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -20,21 +25,63 @@ type MockInteractionRepository struct {
 	mock.Mock
 }
 
-func (m *MockInteractionRepository) AddInteraction(userID, entityID int64, entityType string, interactionType models.InteractionType) error {
+func (m *MockInteractionRepository) AddInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) error {
 	args := m.Called(userID, entityID, entityType, interactionType)
 	return args.Error(0)
 }
 
-func (m *MockInteractionRepository) GetInteractionCounts(entityID int64, entityType string) (likes, dislikes int, err error) {
+func (m *MockInteractionRepository) ToggleInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) (models.InteractionType, error) {
+	args := m.Called(userID, entityID, entityType, interactionType)
+	return args.Get(0).(models.InteractionType), args.Error(1)
+}
+
+func (m *MockInteractionRepository) GetInteractionCounts(entityID int64, entityType models.EntityType) (likes, dislikes int, err error) {
 	args := m.Called(entityID, entityType)
 	return args.Int(0), args.Int(1), args.Error(2)
 }
 
-func (m *MockInteractionRepository) RemoveInteraction(userID, entityID int64, entityType string) error {
+func (m *MockInteractionRepository) RemoveInteraction(userID, entityID int64, entityType models.EntityType) error {
 	args := m.Called(userID, entityID, entityType)
 	return args.Error(0)
 }
 
+func (m *MockInteractionRepository) GetUserInteractions(userID int64, entityIDs []int64, entityType models.EntityType) (map[int64]models.InteractionType, error) {
+	args := m.Called(userID, entityIDs, entityType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]models.InteractionType), args.Error(1)
+}
+
+func (m *MockInteractionRepository) GetTopUsersByLikes(window time.Duration, limit int) ([]repository.LeaderboardEntry, error) {
+	args := m.Called(window, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.LeaderboardEntry), args.Error(1)
+}
+
+func (m *MockInteractionRepository) GetUserInteractionSummary(userID int64) (likesGiven, dislikesGiven, likesReceived int, err error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Int(1), args.Int(2), args.Error(3)
+}
+
+func (m *MockInteractionRepository) GetReceivedInteractions(userID int64, page, limit int) ([]repository.InteractionDetail, int, error) {
+	args := m.Called(userID, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]repository.InteractionDetail), args.Int(1), args.Error(2)
+}
+
+func (m *MockInteractionRepository) GetInteractionSummaries(viewerID int64, entityIDs []int64, entityType models.EntityType) (map[int64]repository.InteractionSummary, error) {
+	args := m.Called(viewerID, entityIDs, entityType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]repository.InteractionSummary), args.Error(1)
+}
+
 // Ensure MockInteractionRepository implements the interface
 var _ repository.InteractionRepositoryInterface = (*MockInteractionRepository)(nil)
 
@@ -56,7 +103,7 @@ func TestLikeEntity(t *testing.T) {
 				"entity_type": "post",
 			},
 			mockBehavior: func() {
-				mockRepo.On("AddInteraction", int64(1), int64(1), "post", models.InteractionType(models.Like)).Return(nil)
+				mockRepo.On("AddInteraction", int64(1), int64(1), models.EntityTypePost, models.InteractionType(models.Like)).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -88,6 +135,239 @@ func TestLikeEntity(t *testing.T) {
 	}
 }
 
+func TestRemoveInteraction(t *testing.T) {
+	testCases := []struct {
+		name           string
+		payload        map[string]interface{}
+		mockBehavior   func(m *MockInteractionRepository)
+		expectedStatus int
+	}{
+		{
+			name:    "Removed",
+			payload: map[string]interface{}{"entity_id": 4, "entity_type": "post"},
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("RemoveInteraction", int64(2), int64(4), models.EntityTypePost).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:    "Nothing To Remove",
+			payload: map[string]interface{}{"entity_id": 4, "entity_type": "comment"},
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("RemoveInteraction", int64(2), int64(4), models.EntityTypeComment).Return(repository.ErrInteractionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Entity Type",
+			payload:        map[string]interface{}{"entity_id": 4, "entity_type": "user"},
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockInteractionRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewInteractionHandler(mockRepo, &middleware.AuthMiddleware{})
+
+			payload, _ := json.Marshal(tc.payload)
+			req := httptest.NewRequest(http.MethodDelete, "/interactions", bytes.NewBuffer(payload))
+			ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+			w := httptest.NewRecorder()
+
+			handler.RemoveInteraction(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetInteractionSummaries(t *testing.T) {
+	testCases := []struct {
+		name             string
+		query            string
+		mockBehavior     func(m *MockInteractionRepository)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:  "Mixed Reactions",
+			query: "?entity_type=post&ids=4,5,6",
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("GetInteractionSummaries", int64(2), []int64{4, 5, 6}, models.EntityTypePost).Return(map[int64]repository.InteractionSummary{
+					4: {Likes: 3, Dislikes: 1, ViewerReaction: models.Like},
+					5: {Likes: 1, Dislikes: 2, ViewerReaction: models.Dislike},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{
+				"4": {"likes": 3, "dislikes": 1, "viewer_reaction": "like"},
+				"5": {"likes": 1, "dislikes": 2, "viewer_reaction": "dislike"},
+				"6": {"likes": 0, "dislikes": 0, "viewer_reaction": null}
+			}`,
+		},
+		{
+			name:           "Invalid Entity Type",
+			query:          "?entity_type=user&ids=4",
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			query:          "?entity_type=post&ids=4,x",
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Duplicate IDs",
+			query: "?entity_type=post&ids=4,5,4,5,4",
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("GetInteractionSummaries", int64(2), []int64{4, 5}, models.EntityTypePost).Return(map[int64]repository.InteractionSummary{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResponse: `{
+				"4": {"likes": 0, "dislikes": 0, "viewer_reaction": null},
+				"5": {"likes": 0, "dislikes": 0, "viewer_reaction": null}
+			}`,
+		},
+		{
+			name:           "Over The Cap",
+			query:          "?entity_type=post&ids=" + idList(DefaultMaxBatchIDs+1),
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Duplicates Do Not Count Toward The Cap",
+			query: "?entity_type=post&ids=" + idList(DefaultMaxBatchIDs) + ",1,2,3",
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("GetInteractionSummaries", int64(2), mock.Anything, models.EntityTypePost).Return(map[int64]repository.InteractionSummary{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockInteractionRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewInteractionHandler(mockRepo, &middleware.AuthMiddleware{})
+
+			req := httptest.NewRequest(http.MethodGet, "/interactions/summary"+tc.query, nil)
+			ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+			w := httptest.NewRecorder()
+
+			handler.GetInteractionSummaries(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedResponse != "" {
+				assert.JSONEq(t, tc.expectedResponse, w.Body.String())
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Requires Login", func(t *testing.T) {
+		handler := NewInteractionHandler(new(MockInteractionRepository), &middleware.AuthMiddleware{})
+		w := httptest.NewRecorder()
+		handler.GetInteractionSummaries(w, httptest.NewRequest(http.MethodGet, "/interactions/summary?entity_type=post&ids=4", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Configured Cap", func(t *testing.T) {
+		handler := NewInteractionHandler(new(MockInteractionRepository), &middleware.AuthMiddleware{}).WithMaxBatchIDs(2)
+		req := httptest.NewRequest(http.MethodGet, "/interactions/summary?entity_type=post&ids=4,5,6", nil)
+		ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+		w := httptest.NewRecorder()
+		handler.GetInteractionSummaries(w, req.WithContext(ctx))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 2")
+	})
+}
+
+// idList returns the comma separated IDs 1 through n
+func idList(n int) string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestToggleInteraction(t *testing.T) {
+	testCases := []struct {
+		name           string
+		payload        map[string]interface{}
+		mockBehavior   func(m *MockInteractionRepository)
+		expectedStatus int
+		expectedType   string
+	}{
+		{
+			name:    "Like Added",
+			payload: map[string]interface{}{"entity_id": 4, "entity_type": "post", "type": "like"},
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("ToggleInteraction", int64(2), int64(4), models.EntityTypePost, models.Like).Return(models.Like, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "like",
+		},
+		{
+			name:    "Like Cleared",
+			payload: map[string]interface{}{"entity_id": 4, "entity_type": "post", "type": "like"},
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("ToggleInteraction", int64(2), int64(4), models.EntityTypePost, models.Like).Return(models.InteractionType(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "none",
+		},
+		{
+			name:    "Deleted Entity",
+			payload: map[string]interface{}{"entity_id": 4, "entity_type": "comment", "type": "dislike"},
+			mockBehavior: func(m *MockInteractionRepository) {
+				m.On("ToggleInteraction", int64(2), int64(4), models.EntityTypeComment, models.Dislike).Return(models.InteractionType(0), repository.ErrEntityNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Interaction Type",
+			payload:        map[string]interface{}{"entity_id": 4, "entity_type": "post", "type": "love"},
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Entity Type",
+			payload:        map[string]interface{}{"entity_id": 4, "entity_type": "user", "type": "like"},
+			mockBehavior:   func(m *MockInteractionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockInteractionRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewInteractionHandler(mockRepo, &middleware.AuthMiddleware{})
+
+			payload, _ := json.Marshal(tc.payload)
+			req := httptest.NewRequest(http.MethodPost, "/interactions/toggle", bytes.NewBuffer(payload))
+			ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+			w := httptest.NewRecorder()
+
+			handler.ToggleInteraction(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedType != "" {
+				var response ToggleInteractionResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tc.expectedType, response.Type)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetInteractionCounts(t *testing.T) {
 	mockRepo := new(MockInteractionRepository)
 	mockAuthMiddleware := &middleware.AuthMiddleware{}
@@ -96,16 +376,16 @@ func TestGetInteractionCounts(t *testing.T) {
 	testCases := []struct {
 		name           string
 		entityID       int64
-		entityType     string
+		entityType     models.EntityType
 		mockBehavior   func()
 		expectedStatus int
 	}{
 		{
 			name:       "Successful Interaction Counts",
 			entityID:   1,
-			entityType: "post",
+			entityType: models.EntityTypePost,
 			mockBehavior: func() {
-				mockRepo.On("GetInteractionCounts", int64(1), "post").Return(5, 2, nil)
+				mockRepo.On("GetInteractionCounts", int64(1), models.EntityTypePost).Return(5, 2, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -125,7 +405,7 @@ func TestGetInteractionCounts(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/interactions", nil)
 			q := req.URL.Query()
 			q.Add("entity_id", "1")
-			q.Add("entity_type", tc.entityType)
+			q.Add("entity_type", string(tc.entityType))
 			req.URL.RawQuery = q.Encode()
 
 			w := httptest.NewRecorder()
@@ -137,3 +417,23 @@ func TestGetInteractionCounts(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserInteractionSummary(t *testing.T) {
+	mockRepo := new(MockInteractionRepository)
+	mockRepo.On("GetUserInteractionSummary", int64(9)).Return(5, 3, 12, nil)
+	handler := NewInteractionHandler(mockRepo, &middleware.AuthMiddleware{})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/9/interactions", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "9")
+	w := httptest.NewRecorder()
+
+	handler.GetUserInteractionSummary(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"dislikes_given":3`)
+	var response InteractionSummaryResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, InteractionSummaryResponse{UserID: 9, LikesGiven: 5, DislikesGiven: 3, LikesReceived: 12}, response)
+	mockRepo.AssertExpectations(t)
+}
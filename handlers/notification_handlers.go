@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"forum/middleware"
+	"forum/repository"
+)
+
+type NotificationHandler struct {
+	notificationRepo repository.NotificationRepositoryInterface
+}
+
+func NewNotificationHandler(notificationRepo repository.NotificationRepositoryInterface) *NotificationHandler {
+	return &NotificationHandler{notificationRepo: notificationRepo}
+}
+
+// GetPreferences reports which notification types the current user receives
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.writePreferences(w, int64(userID))
+}
+
+// UpdatePreferences enables or mutes notification types for the current user,
+// e.g. {"comment": false}; types left out keep their current setting
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var preferences map[string]bool
+	if err := json.NewDecoder(r.Body).Decode(&preferences); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.notificationRepo.UpdatePreferences(int64(userID), preferences); err != nil {
+		if errors.Is(err, repository.ErrUnknownNotificationType) {
+			http.Error(w, "Unknown notification type", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to update notification preferences: %v", err)
+		http.Error(w, "Failed to update notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	h.writePreferences(w, int64(userID))
+}
+
+func (h *NotificationHandler) writePreferences(w http.ResponseWriter, userID int64) {
+	preferences, err := h.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		log.Printf("Failed to retrieve notification preferences: %v", err)
+		http.Error(w, "Failed to retrieve notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preferences); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"forum/middleware"
+	"forum/repository"
+)
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		mockBehavior   func(m *MockNotificationRepository)
+		expectedStatus int
+	}{
+		{
+			name: "Mute Comments",
+			body: `{"comment": false}`,
+			mockBehavior: func(m *MockNotificationRepository) {
+				m.On("UpdatePreferences", int64(5), map[string]bool{"comment": false}).Return(nil)
+				m.On("GetPreferences", int64(5)).Return(map[string]bool{"comment": false, "new_post": true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Unknown Type",
+			body: `{"follow": false}`,
+			mockBehavior: func(m *MockNotificationRepository) {
+				m.On("UpdatePreferences", int64(5), map[string]bool{"follow": false}).Return(repository.ErrUnknownNotificationType)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Body",
+			body:           `{"comment": "off"}`,
+			mockBehavior:   func(m *MockNotificationRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockNotificationRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewNotificationHandler(mockRepo)
+
+			req := httptest.NewRequest(http.MethodPut, "/me/notification-preferences", bytes.NewBufferString(tc.body))
+			ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 5})
+			w := httptest.NewRecorder()
+
+			handler.UpdatePreferences(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var preferences map[string]bool
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&preferences))
+				assert.False(t, preferences["comment"])
+				assert.True(t, preferences["new_post"])
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
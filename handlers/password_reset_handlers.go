@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"forum/repository"
+)
+
+// PasswordResetMailer delivers a reset token to the address a user registered with
+type PasswordResetMailer interface {
+	SendPasswordReset(email, token string) error
+}
+
+// LogPasswordResetMailer writes reset tokens to the server log. It stands in
+// for a real mailer until one is configured, so only use it in development
+type LogPasswordResetMailer struct{}
+
+func (LogPasswordResetMailer) SendPasswordReset(email, token string) error {
+	log.Printf("Password reset requested for %s: token %s", email, token)
+	return nil
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// forgotPasswordMessage is sent whether or not the email is registered, so
+// the endpoint cannot be used to discover accounts
+const forgotPasswordMessage = "If that email is registered, a password reset link has been sent"
+
+// ForgotPassword issues a reset token for the account with the given email
+// and mails it to the owner. It always answers 200 for a well-formed request
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var request ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	email := strings.TrimSpace(request.Email)
+	if email == "" {
+		writeError(w, http.StatusBadRequest, "email", "Email is required")
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(email)
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		// Respond exactly as for a known address
+	case err != nil:
+		log.Printf("Failed to look up user for password reset: %v", err)
+		writeError(w, http.StatusInternalServerError, "", "Failed to request password reset")
+		return
+	case h.resetMailer == nil:
+		log.Printf("Password reset requested for user %d but no mailer is configured", user.ID)
+	default:
+		token, err := h.resetRepo.CreateResetToken(user.ID)
+		if err != nil {
+			log.Printf("Failed to create password reset token: %v", err)
+			writeError(w, http.StatusInternalServerError, "", "Failed to request password reset")
+			return
+		}
+		if err := h.resetMailer.SendPasswordReset(user.Email, token); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": forgotPasswordMessage})
+}
+
+// ResetPassword sets a new password for the user a reset token was issued to
+// and ends their session, so tokens issued before the reset stop working. The
+// password is checked before the token is spent, so a rejected password can
+// be retried with the same token
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if request.Token == "" {
+		writeError(w, http.StatusBadRequest, "token", "Reset token is required")
+		return
+	}
+	if err := validatePassword(request.Password); err != nil {
+		writeError(w, http.StatusBadRequest, "password", err.Error())
+		return
+	}
+
+	userID, err := h.resetRepo.ConsumeResetToken(request.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidResetToken) {
+			writeError(w, http.StatusBadRequest, "token", "Invalid or expired reset token")
+			return
+		}
+		log.Printf("Failed to consume password reset token: %v", err)
+		writeError(w, http.StatusInternalServerError, "", "Failed to reset password")
+		return
+	}
+
+	if err := h.userRepo.UpdatePassword(userID, request.Password); err != nil {
+		log.Printf("Failed to update password: %v", err)
+		writeError(w, http.StatusInternalServerError, "", "Failed to reset password")
+		return
+	}
+
+	if err := h.sessionRepo.InvalidateSession(userID); err != nil {
+		log.Printf("Failed to end sessions after password reset for user %d: %v", userID, err)
+		writeError(w, http.StatusInternalServerError, "", "Password was reset but existing sessions could not be signed out")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password has been reset"})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"forum/middleware"
+	"forum/mocks"
+	"forum/models"
+	"forum/repository"
+)
+
+// MockPasswordResetRepository simulates password reset repository for testing
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) CreateResetToken(userID int) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) ConsumeResetToken(token string) (int, error) {
+	args := m.Called(token)
+	return args.Int(0), args.Error(1)
+}
+
+var _ repository.PasswordResetRepositoryInterface = &MockPasswordResetRepository{}
+
+// recordingMailer keeps the reset emails it is asked to send
+type recordingMailer struct {
+	sent map[string]string
+}
+
+func (m *recordingMailer) SendPasswordReset(email, token string) error {
+	if m.sent == nil {
+		m.sent = make(map[string]string)
+	}
+	m.sent[email] = token
+	return nil
+}
+
+func TestForgotPassword(t *testing.T) {
+	testCases := []struct {
+		name         string
+		email        string
+		mockBehavior func(users *MockUserRepository, resets *MockPasswordResetRepository)
+		expectedSent map[string]string
+	}{
+		{
+			name:  "Registered Email",
+			email: "alice@example.com",
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository) {
+				users.On("FindByEmail", "alice@example.com").Return(&models.User{ID: 3, Email: "alice@example.com"}, nil)
+				resets.On("CreateResetToken", 3).Return("reset-token", nil)
+			},
+			expectedSent: map[string]string{"alice@example.com": "reset-token"},
+		},
+		{
+			name:  "Unknown Email",
+			email: "nobody@example.com",
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository) {
+				users.On("FindByEmail", "nobody@example.com").Return(nil, repository.ErrUserNotFound)
+			},
+		},
+	}
+
+	var bodies []string
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := new(MockUserRepository)
+			resets := new(MockPasswordResetRepository)
+			mailer := &recordingMailer{}
+			tc.mockBehavior(users, resets)
+			handler := NewAuthHandler(users, nil, nil).WithPasswordResets(resets, nil, mailer)
+
+			payload, _ := json.Marshal(ForgotPasswordRequest{Email: tc.email})
+			w := httptest.NewRecorder()
+			handler.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(payload)))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expectedSent, mailer.sent)
+			bodies = append(bodies, w.Body.String())
+			users.AssertExpectations(t)
+			resets.AssertExpectations(t)
+		})
+	}
+
+	// Known and unknown addresses get the same answer
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+}
+
+func TestResetPassword(t *testing.T) {
+	testCases := []struct {
+		name           string
+		request        ResetPasswordRequest
+		mockBehavior   func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository)
+		expectedStatus int
+		expectedField  string
+	}{
+		{
+			name:    "Password Reset",
+			request: ResetPasswordRequest{Token: "fresh-token", Password: "NewPassword1!"},
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository) {
+				resets.On("ConsumeResetToken", "fresh-token").Return(3, nil)
+				users.On("UpdatePassword", 3, "NewPassword1!").Return(nil)
+				sessions.On("InvalidateSession", 3).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "Session Signout Fails",
+			request: ResetPasswordRequest{Token: "fresh-token", Password: "NewPassword1!"},
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository) {
+				resets.On("ConsumeResetToken", "fresh-token").Return(3, nil)
+				users.On("UpdatePassword", 3, "NewPassword1!").Return(nil)
+				sessions.On("InvalidateSession", 3).Return(errors.New("database is locked"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:    "Expired Token",
+			request: ResetPasswordRequest{Token: "stale-token", Password: "NewPassword1!"},
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository) {
+				resets.On("ConsumeResetToken", "stale-token").Return(0, repository.ErrInvalidResetToken)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "token",
+		},
+		{
+			name:    "Weak Password Keeps Token",
+			request: ResetPasswordRequest{Token: "fresh-token", Password: "password"},
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository) {
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "password",
+		},
+		{
+			name:    "Missing Token",
+			request: ResetPasswordRequest{Password: "NewPassword1!"},
+			mockBehavior: func(users *MockUserRepository, resets *MockPasswordResetRepository, sessions *mocks.MockSessionRepository) {
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := new(MockUserRepository)
+			resets := new(MockPasswordResetRepository)
+			sessions := mocks.NewMockSessionRepository()
+			tc.mockBehavior(users, resets, sessions)
+			handler := NewAuthHandler(users, nil, nil).WithPasswordResets(resets, sessions, &recordingMailer{})
+
+			payload, _ := json.Marshal(tc.request)
+			w := httptest.NewRecorder()
+			handler.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(payload)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedField != "" {
+				var body ErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, tc.expectedField, body.Field)
+			}
+			users.AssertExpectations(t)
+			resets.AssertExpectations(t)
+			sessions.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResetPasswordTokenReuse(t *testing.T) {
+	db, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.Create(&models.User{Username: "forgetful", Email: "forgetful@example.com", Password: "OldPassword1!"})
+	require.NoError(t, err)
+
+	resetRepo := repository.NewPasswordResetRepository(db)
+	token, err := resetRepo.CreateResetToken(user.ID)
+	require.NoError(t, err)
+
+	// A token issued before the reset, as held by whoever knew the old password
+	sessionRepo := repository.NewSessionRepository(db)
+	auth := middleware.NewAuthMiddleware(sessionRepo, "test-secret", 1)
+	oldToken, err := auth.GenerateToken(user.ID, user.Username, 0)
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(oldToken)
+	require.NoError(t, err)
+
+	handler := NewAuthHandler(userRepo, nil, nil).WithPasswordResets(resetRepo, sessionRepo, &recordingMailer{})
+	reset := func(password string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(ResetPasswordRequest{Token: token, Password: password})
+		w := httptest.NewRecorder()
+		handler.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(payload)))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, reset("NewPassword1!").Code)
+	_, err = userRepo.Authenticate("forgetful@example.com", "NewPassword1!")
+	require.NoError(t, err)
+
+	// The reset signed out the old session
+	_, err = auth.ValidateToken(oldToken)
+	assert.Error(t, err)
+
+	// The token was spent by the first reset
+	assert.Equal(t, http.StatusBadRequest, reset("OtherPassword1!").Code)
+	_, err = userRepo.Authenticate("forgetful@example.com", "NewPassword1!")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"forum/models"
+	"forum/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PostFullResponse is everything a post page shows, so it can be rendered
+// from a single request
+type PostFullResponse struct {
+	Post         *models.Post        `json:"post"`
+	Username     string              `json:"username"`
+	Categories   []string            `json:"categories"`
+	Comments     ListResponse        `json:"comments"`
+	Interactions InteractionResponse `json:"interactions"`
+}
+
+// GetFullPost returns a post with its author, category names, a page of its
+// comments and its like and dislike counts. It needs the category, comment
+// and interaction repositories set with WithCategories and WithEngagement
+func (h *PostHandler) GetFullPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	if h.categoryRepo == nil || h.commentRepo == nil || h.interactionRepo == nil {
+		log.Printf("Full post view requested without category, comment and interaction repositories")
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	sort := repository.CommentSortNew
+	if sortParam := r.URL.Query().Get("sort"); sortParam != "" {
+		parsed, err := repository.ParseCommentSort(sortParam)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid sort")
+			return
+		}
+		sort = parsed
+	}
+
+	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10), h.viewer(r))
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Post not found")
+			return
+		}
+		log.Printf("Error retrieving post: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+
+	categories, err := h.categoryRepo.GetCategoriesByIDs(post.Categories)
+	if err != nil {
+		log.Printf("Error retrieving categories: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+	categoryNames := make(map[int64]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+	names := []string{}
+	for _, categoryID := range post.Categories {
+		if name, ok := categoryNames[categoryID]; ok {
+			names = append(names, name)
+		}
+	}
+
+	comments, totalComments, err := h.commentRepo.GetByPostIDPaginated(postID, page, limit, sort, false)
+	if err != nil {
+		log.Printf("Error retrieving comments: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+	if comments == nil {
+		comments = []repository.Comment{}
+	}
+
+	likes, dislikes, err := h.interactionRepo.GetInteractionCounts(postID, models.EntityTypePost)
+	if err != nil {
+		log.Printf("Error retrieving interaction counts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+
+	response := PostFullResponse{
+		Post:         post,
+		Username:     post.Username,
+		Categories:   names,
+		Comments:     ListResponse{Data: comments, Pagination: newPagination(page, limit, totalComments)},
+		Interactions: InteractionResponse{Likes: likes, Dislikes: dislikes},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// PostCountsResponse holds the engagement totals shown on a post
+type PostCountsResponse struct {
+	Likes    int `json:"likes"`
+	Dislikes int `json:"dislikes"`
+	Comments int `json:"comments"`
+}
+
+// GetPostCounts returns a post's like, dislike and comment counts, answering
+// 404 like GetPost for posts the viewer may not see
+func (h *PostHandler) GetPostCounts(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || postID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	likes, dislikes, comments, err := h.postRepo.GetPostCounts(postID, h.viewer(r))
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Post not found")
+			return
+		}
+		log.Printf("Error retrieving post counts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post counts")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(PostCountsResponse{Likes: likes, Dislikes: dislikes, Comments: comments}); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"forum/models"
+	"forum/repository"
+)
+
+func TestGetFullPost(t *testing.T) {
+	newHandler := func(posts *MockPostRepository, categories *MockCategoryRepository, comments *MockCommentRepository, interactions *MockInteractionRepository) *PostHandler {
+		return NewPostHandler(posts, nil, nil).
+			WithCategories(categories).
+			WithEngagement(comments, interactions)
+	}
+	serve := func(handler *PostHandler, postID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts/"+postID+"/full"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", postID)
+		w := httptest.NewRecorder()
+		handler.GetFullPost(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		return w
+	}
+
+	t.Run("Composite Shape", func(t *testing.T) {
+		posts := new(MockPostRepository)
+		posts.On("GetByID", "4", repository.AnonymousViewer).Return(&models.Post{
+			ID: 4, UserID: 2, Title: "Full", Content: "Everything at once", Username: "alice", Categories: []int64{3, 1},
+		}, nil)
+		categories := new(MockCategoryRepository)
+		categories.On("GetCategoriesByIDs", []int64{3, 1}).Return([]models.Category{{ID: 1, Name: "Go"}, {ID: 3, Name: "Web"}}, nil)
+		comments := new(MockCommentRepository)
+		comments.On("GetByPostIDPaginated", int64(4), 2, 1, repository.CommentSortOld, false).Return([]repository.Comment{
+			{ID: 9, PostID: 4, UserID: 5, Username: "bob", Content: "Second comment"},
+		}, 2, nil)
+		interactions := new(MockInteractionRepository)
+		interactions.On("GetInteractionCounts", int64(4), models.EntityTypePost).Return(6, 1, nil)
+
+		w := serve(newHandler(posts, categories, comments, interactions), "4", "?page=2&limit=1&sort=old")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&body))
+		assert.ElementsMatch(t, []string{"post", "username", "categories", "comments", "interactions"}, mapKeys(body))
+
+		var response struct {
+			Post         models.Post         `json:"post"`
+			Username     string              `json:"username"`
+			Categories   []string            `json:"categories"`
+			Comments     json.RawMessage     `json:"comments"`
+			Interactions InteractionResponse `json:"interactions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(4), response.Post.ID)
+		assert.Equal(t, "alice", response.Username)
+		assert.Equal(t, []string{"Web", "Go"}, response.Categories)
+		assert.Equal(t, InteractionResponse{Likes: 6, Dislikes: 1}, response.Interactions)
+
+		var pageOfComments []repository.Comment
+		pagination := decodeList(t, bytes.NewReader(response.Comments), &pageOfComments)
+		require.Len(t, pageOfComments, 1)
+		assert.Equal(t, "Second comment", pageOfComments[0].Content)
+		assert.Equal(t, &Pagination{Page: 2, Limit: 1, TotalCount: 2, TotalPages: 2}, pagination)
+	})
+
+	t.Run("Post Not Found", func(t *testing.T) {
+		posts := new(MockPostRepository)
+		posts.On("GetByID", "404", repository.AnonymousViewer).Return(nil, repository.ErrPostNotFound)
+
+		w := serve(newHandler(posts, new(MockCategoryRepository), new(MockCommentRepository), new(MockInteractionRepository)), "404", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Missing Dependencies", func(t *testing.T) {
+		posts := new(MockPostRepository)
+
+		w := serve(NewPostHandler(posts, nil, nil), "4", "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		posts.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Post ID", func(t *testing.T) {
+		w := serve(newHandler(new(MockPostRepository), new(MockCategoryRepository), new(MockCommentRepository), new(MockInteractionRepository)), "abc", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// mapKeys returns the keys of a decoded JSON object
+func mapKeys(object map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestGetPostCounts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		postID         string
+		mockBehavior   func(posts *MockPostRepository)
+		expectedStatus int
+		expectedCounts PostCountsResponse
+	}{
+		{
+			name:   "Counts",
+			postID: "4",
+			mockBehavior: func(posts *MockPostRepository) {
+				posts.On("GetPostCounts", int64(4), repository.AnonymousViewer).Return(5, 2, 7, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCounts: PostCountsResponse{Likes: 5, Dislikes: 2, Comments: 7},
+		},
+		{
+			name:   "Unknown Post",
+			postID: "9",
+			mockBehavior: func(posts *MockPostRepository) {
+				posts.On("GetPostCounts", int64(9), repository.AnonymousViewer).Return(0, 0, 0, repository.ErrPostNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid ID",
+			postID:         "abc",
+			mockBehavior:   func(posts *MockPostRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			posts := new(MockPostRepository)
+			tc.mockBehavior(posts)
+			handler := NewPostHandler(posts, nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/"+tc.postID+"/counts", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.postID)
+			w := httptest.NewRecorder()
+			handler.GetPostCounts(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var counts PostCountsResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&counts))
+				assert.Equal(t, tc.expectedCounts, counts)
+			}
+			posts.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetPostBySlug(t *testing.T) {
+	serve := func(posts *MockPostRepository, slug string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts/slug/"+slug, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("slug", slug)
+		w := httptest.NewRecorder()
+		NewPostHandler(posts, nil, nil).GetPostBySlug(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		return w
+	}
+
+	t.Run("Found", func(t *testing.T) {
+		posts := new(MockPostRepository)
+		posts.On("GetBySlug", "hello-world", repository.AnonymousViewer).Return(&models.Post{ID: 4, Title: "Hello World", Slug: "hello-world"}, nil)
+
+		w := serve(posts, "hello-world")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var post models.Post
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&post))
+		assert.Equal(t, int64(4), post.ID)
+		assert.Equal(t, "hello-world", post.Slug)
+		posts.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Slug", func(t *testing.T) {
+		posts := new(MockPostRepository)
+		posts.On("GetBySlug", "missing", repository.AnonymousViewer).Return(nil, repository.ErrPostNotFound)
+
+		assert.Equal(t, http.StatusNotFound, serve(posts, "missing").Code)
+		posts.AssertExpectations(t)
+	})
+}
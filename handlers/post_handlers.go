@@ -1,23 +1,50 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"forum/middleware"
 	"forum/models"
+	"forum/pkg/features"
 	"forum/repository"
 
 	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 type PostHandler struct {
 	postRepo       repository.PostRepositoryInterface
 	authMiddleware middleware.AuthMiddlewareInterface
 	renderer       *TemplateRenderer
+	defaultSort    repository.PostSort
+	minTitle       int
+	minContent     int
+
+	// defaultCategoryID is attached to new posts filed under no category;
+	// zero leaves such posts uncategorized
+	defaultCategoryID int64
+
+	subscriptionRepo repository.SubscriptionRepositoryInterface
+	notificationRepo repository.NotificationRepositoryInterface
+	categoryRepo     repository.CategoryRepositoryInterface
+	commentRepo      repository.CommentRepositoryInterface
+	interactionRepo  repository.InteractionRepositoryInterface
+	userRepo         repository.UserRepository
+	features         *features.Flags
+
+	// roles tells moderators apart, who may read posts still waiting for
+	// moderation; without it only authors can see their pending posts
+	roles *middleware.AuthMiddleware
 }
 
 func NewPostHandler(postRepo repository.PostRepositoryInterface, authMiddleware middleware.AuthMiddlewareInterface, renderer *TemplateRenderer) *PostHandler {
@@ -25,9 +52,101 @@ func NewPostHandler(postRepo repository.PostRepositoryInterface, authMiddleware
 		postRepo:       postRepo,
 		authMiddleware: authMiddleware,
 		renderer:       renderer,
+		defaultSort:    repository.PostSortNewest,
+		minTitle:       1,
+		minContent:     1,
 	}
 }
 
+// WithDefaultSort sets the feed ordering used when a request specifies no sort
+func (h *PostHandler) WithDefaultSort(sort repository.PostSort) *PostHandler {
+	h.defaultSort = sort
+	return h
+}
+
+// WithMinLengths sets the minimum title and content lengths, in characters,
+// accepted when creating or updating a post
+func (h *PostHandler) WithMinLengths(title, content int) *PostHandler {
+	h.minTitle = title
+	h.minContent = content
+	return h
+}
+
+// WithDefaultCategory files posts created without a category under the given
+// category; zero turns this off
+func (h *PostHandler) WithDefaultCategory(categoryID int64) *PostHandler {
+	h.defaultCategoryID = categoryID
+	return h
+}
+
+// WithSubscriptions enables notifying category subscribers of new posts
+func (h *PostHandler) WithSubscriptions(subscriptionRepo repository.SubscriptionRepositoryInterface, notificationRepo repository.NotificationRepositoryInterface) *PostHandler {
+	h.subscriptionRepo = subscriptionRepo
+	h.notificationRepo = notificationRepo
+	return h
+}
+
+// WithCategories lets authors tag new posts by category name as well as by ID
+func (h *PostHandler) WithCategories(categoryRepo repository.CategoryRepositoryInterface) *PostHandler {
+	h.categoryRepo = categoryRepo
+	return h
+}
+
+// WithEngagement gives GetFullPost access to a post's comments and reactions
+func (h *PostHandler) WithEngagement(commentRepo repository.CommentRepositoryInterface, interactionRepo repository.InteractionRepositoryInterface) *PostHandler {
+	h.commentRepo = commentRepo
+	h.interactionRepo = interactionRepo
+	return h
+}
+
+// WithUsers lets responses embed the full public profile of post authors
+func (h *PostHandler) WithUsers(userRepo repository.UserRepository) *PostHandler {
+	h.userRepo = userRepo
+	return h
+}
+
+// WithRoles lets moderators read posts that are waiting for moderation
+func (h *PostHandler) WithRoles(authMiddleware *middleware.AuthMiddleware) *PostHandler {
+	h.roles = authMiddleware
+	return h
+}
+
+// viewer identifies who a request reads posts for: its logged-in user, if
+// any, and whether they moderate
+func (h *PostHandler) viewer(r *http.Request) repository.PostViewer {
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	return repository.PostViewer{
+		UserID:    int64(userID),
+		Moderator: h.roles != nil && h.roles.IsModerator(r.Context()),
+	}
+}
+
+// WithFeatures sets the deployment's feature flags; without them every
+// optional behavior stays off
+func (h *PostHandler) WithFeatures(flags *features.Flags) *PostHandler {
+	h.features = flags
+	return h
+}
+
+// validatePostText checks the title and content against the configured
+// minimum lengths, ignoring surrounding whitespace, and the optional excerpt
+// against its maximum, returning a client facing message on failure
+func (h *PostHandler) validatePostText(title, content, excerpt string) (string, bool) {
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(content) == "" {
+		return "Title and content are required", false
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(title)) < h.minTitle {
+		return fmt.Sprintf("Title must be at least %d characters", h.minTitle), false
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(content)) < h.minContent {
+		return fmt.Sprintf("Content must be at least %d characters", h.minContent), false
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(excerpt)) > models.MaxExcerptLength {
+		return fmt.Sprintf("Excerpt must be at most %d characters", models.MaxExcerptLength), false
+	}
+	return "", true
+}
+
 type PostRequest struct {
 	Categories []int64 `json:"categories"`
 	Title      string  `json:"title"`
@@ -35,16 +154,75 @@ type PostRequest struct {
 }
 
 type CreatePostRequest struct {
-	Title      string `json:"title"`
-	Content    string `json:"content"`
-	CategoryID int    `json:"category_id"`
+	Title         string   `json:"title"`
+	Content       string   `json:"content"`
+	Excerpt       string   `json:"excerpt"`
+	Categories    []int64  `json:"categories"`
+	CategoryID    int      `json:"category_id"`
+	CategoryNames []string `json:"category_names"`
+}
+
+// categoryIDs merges the single category_id older clients send into the
+// categories list, dropping duplicates. It is empty when neither was given
+func (req CreatePostRequest) categoryIDs() []int64 {
+	ids := make([]int64, 0, len(req.Categories)+1)
+	if req.CategoryID > 0 {
+		ids = append(ids, int64(req.CategoryID))
+	}
+	for _, id := range req.Categories {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// maxPostCategoryNames caps how many category names one post may be tagged with
+const maxPostCategoryNames = 10
+
+// maxPostCategories caps how many category IDs one post may be tagged with
+const maxPostCategories = 10
+
+// validateCategories checks that every category ID picked for a post exists,
+// writing a 400 for unknown ones
+func (h *PostHandler) validateCategories(w http.ResponseWriter, req CreatePostRequest) ([]int64, bool) {
+	ids := req.categoryIDs()
+	if len(req.Categories) == 0 && req.CategoryID <= 0 {
+		// No category picked; the default category may still apply
+		return ids, true
+	}
+	if len(ids) > maxPostCategories {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("At most %d categories are allowed", maxPostCategories))
+		return nil, false
+	}
+	for _, id := range ids {
+		if id <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid category ID")
+			return nil, false
+		}
+	}
+	if h.categoryRepo == nil {
+		return ids, true
+	}
+
+	missing, err := h.categoryRepo.CategoriesExist(ids)
+	if err != nil {
+		log.Printf("Error checking categories %v: %v", ids, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to check categories")
+		return nil, false
+	}
+	if len(missing) > 0 {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Unknown category %d", missing[0]))
+		return nil, false
+	}
+	return ids, true
 }
 
 func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	// Check if user is authenticated
 	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -52,126 +230,611 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	var req CreatePostRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate input
-	if req.Title == "" || req.Content == "" {
-		http.Error(w, "Title and content are required", http.StatusBadRequest)
+	if msg, ok := h.validatePostText(req.Title, req.Content, req.Excerpt); !ok {
+		writeJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	categoryIDs, ok := h.validateCategories(w, req)
+	if !ok {
 		return
 	}
+	if len(req.CategoryNames) > 0 {
+		if categoryIDs, ok = h.resolveCategoryNames(w, req, categoryIDs); !ok {
+			return
+		}
+	}
+	if h.defaultCategoryID > 0 && !slices.ContainsFunc(categoryIDs, func(id int64) bool { return id > 0 }) {
+		categoryIDs = []int64{h.defaultCategoryID}
+	}
 
 	// Create post
 	newPost := &models.Post{
 		UserID:     int64(userID),
 		Title:      req.Title,
 		Content:    req.Content,
+		Excerpt:    strings.TrimSpace(req.Excerpt),
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
-		Categories: []int64{int64(req.CategoryID)},
+		Categories: categoryIDs,
+	}
+	if h.features.Enabled(features.ModerationQueue) {
+		newPost.Status = repository.PostStatusPending
 	}
 
 	// Save post to database
-	err = h.postRepo.Create(newPost, []int64{int64(req.CategoryID)})
+	err = h.postRepo.Create(newPost, categoryIDs)
 	if err != nil {
 		log.Printf("Error creating post: %v", err)
-		http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create post")
 		return
 	}
 
+	// Subscribers of a pending post are told once it is approved
+	if newPost.Status != repository.PostStatusPending {
+		h.notifySubscribers(r.Context(), newPost)
+	}
+
 	// Prepare response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(newPost)
 }
 
-func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
-	// Optional query parameters for pagination
-	page := 1
-	limit := 10
+// resolveCategoryNames turns the category names of a new post into IDs,
+// alongside the category IDs it was given. Unknown names are created only
+// when the category_auto_create feature is on
+func (h *PostHandler) resolveCategoryNames(w http.ResponseWriter, req CreatePostRequest, categoryIDs []int64) ([]int64, bool) {
+	if h.categoryRepo == nil {
+		writeJSONError(w, http.StatusBadRequest, "Category names are not supported")
+		return nil, false
+	}
+	if len(req.CategoryNames) > maxPostCategoryNames {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("At most %d category names are allowed", maxPostCategoryNames))
+		return nil, false
+	}
+	for _, name := range req.CategoryNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			writeJSONError(w, http.StatusBadRequest, "Category names cannot be empty")
+			return nil, false
+		}
+		if utf8.RuneCountInString(name) > maxCategoryNameLength {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("Category names must be at most %d characters", maxCategoryNameLength))
+			return nil, false
+		}
+	}
 
-	pageParam := r.URL.Query().Get("page")
-	limitParam := r.URL.Query().Get("limit")
+	ids, err := h.categoryRepo.ResolveCategoryNames(req.CategoryNames, h.features.Enabled(features.CategoryAutoCreate))
+	if err != nil {
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			writeJSONError(w, http.StatusBadRequest, "Unknown category")
+			return nil, false
+		}
+		log.Printf("Error resolving category names: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create post")
+		return nil, false
+	}
 
-	if pageParam != "" {
-		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
-			page = p
+	var given []int64
+	for _, id := range categoryIDs {
+		if id > 0 && !slices.Contains(ids, id) {
+			given = append(given, id)
 		}
 	}
+	return append(given, ids...), true
+}
 
-	if limitParam != "" {
-		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
-			limit = l
+// notifySubscribers tells everyone following one of the post's categories
+// about the new post, skipping its author. Failures are logged rather than
+// returned since the post itself was saved
+func (h *PostHandler) notifySubscribers(ctx context.Context, post *models.Post) {
+	if h.subscriptionRepo == nil || h.notificationRepo == nil {
+		return
+	}
+
+	requestID := chimiddleware.GetReqID(ctx)
+
+	subscriberIDs, err := h.subscriptionRepo.ListSubscribers(post.Categories)
+	if err != nil {
+		log.Printf("[%s] Error loading category subscribers for post %d: %v", requestID, post.ID, err)
+		return
+	}
+
+	var notifications []repository.Notification
+	for _, userID := range subscriberIDs {
+		if userID == post.UserID {
+			continue
+		}
+		notifications = append(notifications, repository.Notification{
+			UserID:     userID,
+			ActorID:    post.UserID,
+			Type:       repository.NotificationTypeNewPost,
+			EntityType: models.EntityTypePost,
+			EntityID:   post.ID,
+			RequestID:  requestID,
+		})
+	}
+
+	if err := h.notificationRepo.CreateBatch(notifications); err != nil {
+		log.Printf("[%s] Error creating notifications for post %d: %v", requestID, post.ID, err)
+	}
+}
+
+// notifyApproved tells category subscribers about the posts a batch approval
+// just made public; posts that were already approved were announced before
+func (h *PostHandler) notifyApproved(ctx context.Context, results []repository.ApprovalResult) {
+	if h.subscriptionRepo == nil || h.notificationRepo == nil {
+		return
+	}
+
+	for _, result := range results {
+		if result.Result != repository.ApprovalApproved {
+			continue
 		}
+		post, err := h.postRepo.GetByID(strconv.FormatInt(result.PostID, 10), repository.PostViewer{Moderator: true})
+		if err != nil {
+			log.Printf("[%s] Error loading approved post %d: %v", chimiddleware.GetReqID(ctx), result.PostID, err)
+			continue
+		}
+		h.notifySubscribers(ctx, post)
+	}
+}
+
+func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
+	h.listPosts(w, r, nil)
+}
+
+// DiscoverPosts lists posts like ListPosts but leaves out the current user's own
+func (h *PostHandler) DiscoverPosts(w http.ResponseWriter, r *http.Request) {
+	// Check if user is authenticated
+	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	viewerID := int64(userID)
+	h.listPosts(w, r, &viewerID)
+}
+
+// listPosts serves one sorted page of posts, optionally excluding a user's posts
+func (h *PostHandler) listPosts(w http.ResponseWriter, r *http.Request, excludeUserID *int64) {
+	// Optional query parameters for pagination
+	page, limit := parsePagination(r)
+
+	sort := h.defaultSort
+	if sortParam := r.URL.Query().Get("sort"); sortParam != "" {
+		parsed, err := repository.ParsePostSort(sortParam)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid sort")
+			return
+		}
+		sort = parsed
+	}
+
+	fields, err := parsePostFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "fields", "Invalid fields: "+err.Error())
+		return
+	}
+
+	view, ok := parseUserViewParam(w, r)
+	if !ok {
+		return
+	}
+	if fields != nil && view != UserViewNone {
+		writeError(w, http.StatusBadRequest, "users", "users cannot be combined with fields")
+		return
 	}
 
 	// Retrieve posts
-	posts, totalCount, err := h.postRepo.ListPosts(page, limit)
+	posts, totalCount, err := h.postRepo.ListPosts(page, limit, sort, excludeUserID)
 	if err != nil {
 		log.Printf("Error listing posts: %v", err)
-		http.Error(w, "Failed to retrieve posts", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
 		return
 	}
 
-	// Calculate total pages: ceiling division of total count by limit
-	totalPages := (totalCount + limit - 1) / limit
+	if view != UserViewNone {
+		embedded, err := newAuthorEmbedder(view, h.userRepo).posts(posts)
+		if err != nil {
+			log.Printf("Error embedding post authors: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+			return
+		}
+		writePage(w, r, embedded, newPagination(page, limit, totalCount))
+		return
+	}
 
-	// Convert posts slice to slice of pointers
-	postPtrs := make([]*models.Post, len(posts))
-	for i := range posts {
-		postPtrs[i] = &posts[i]
+	if fields == nil {
+		writePostPage(w, r, posts, totalCount, page, limit)
+		return
 	}
 
-	// Prepare paginated response
-	response := struct {
-		Posts       []*models.Post `json:"posts"`
-		TotalCount  int            `json:"total_count"`
-		CurrentPage int            `json:"current_page"`
-		TotalPages  int            `json:"total_pages"`
-	}{
-		Posts:       postPtrs,
-		TotalCount:  totalCount,
-		CurrentPage: page,
-		TotalPages:  totalPages,
+	projected, err := projectPosts(posts, fields)
+	if err != nil {
+		log.Printf("Error projecting posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		return
+	}
+	writePage(w, r, projected, newPagination(page, limit, totalCount))
+}
+
+// postFields are the JSON fields of a post a client may select with the
+// fields query parameter
+var postFields = map[string]bool{
+	"id":            true,
+	"user_id":       true,
+	"title":         true,
+	"content":       true,
+	"excerpt":       true,
+	"preview":       true,
+	"status":        true,
+	"created_at":    true,
+	"updated_at":    true,
+	"categories":    true,
+	"like_count":    true,
+	"dislike_count": true,
+	"username":      true,
+	"category_name": true,
+}
+
+// parsePostFields validates a comma separated list of post fields. An empty
+// value selects every field and is returned as nil
+func parsePostFields(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !postFields[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectPosts reduces each post to the given JSON fields. Fields a post
+// omits when empty, such as excerpt and preview, stay omitted
+func projectPosts(posts []models.Post, fields []string) ([]map[string]json.RawMessage, error) {
+	projected := make([]map[string]json.RawMessage, 0, len(posts))
+	for _, post := range posts {
+		encoded, err := json.Marshal(post)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &all); err != nil {
+			return nil, err
+		}
+
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				selected[field] = value
+			}
+		}
+		projected = append(projected, selected)
+	}
+	return projected, nil
+}
+
+// GetCommentedPosts lists the posts the current user has commented on
+func (h *PostHandler) GetCommentedPosts(w http.ResponseWriter, r *http.Request) {
+	// Check if user is authenticated
+	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	posts, totalCount, err := h.postRepo.GetCommentedPosts(int64(userID), page, limit)
+	if err != nil {
+		log.Printf("Error listing commented posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		return
+	}
+
+	writePostPage(w, r, posts, totalCount, page, limit)
+}
+
+// GetLikedPosts lists the posts the current user has liked
+func (h *PostHandler) GetLikedPosts(w http.ResponseWriter, r *http.Request) {
+	// Check if user is authenticated
+	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	posts, err := h.postRepo.GetLikedPosts(int64(userID))
+	if err != nil {
+		log.Printf("Error listing liked posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		return
+	}
+
+	writeList(w, posts, nil)
+}
+
+// GetPostsWithoutEngagement lists posts that have no reactions or comments
+// yet, for moderators running engagement campaigns
+func (h *PostHandler) GetPostsWithoutEngagement(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	posts, totalCount, err := h.postRepo.GetPostsWithoutEngagement(page, limit)
+	if err != nil {
+		log.Printf("Error listing unengaged posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		return
+	}
+
+	writePostPage(w, r, posts, totalCount, page, limit)
+}
+
+// maxSearchQueryLength bounds the q parameter of SearchPosts, in bytes
+const maxSearchQueryLength = 200
+
+// SearchPosts lists approved posts whose title or content contains the q
+// query parameter, newest first
+func (h *PostHandler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeJSONError(w, http.StatusBadRequest, "Search query is required")
+		return
+	}
+	if len(query) > maxSearchQueryLength {
+		writeJSONError(w, http.StatusBadRequest, "Search query is too long")
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	posts, totalCount, err := h.postRepo.SearchPosts(query, page, limit)
+	if err != nil {
+		log.Printf("Error searching posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to search posts")
+		return
+	}
+
+	writePostPage(w, r, posts, totalCount, page, limit)
+}
+
+// maxCheckTitleLength bounds the title parameter of CheckTitle, in bytes
+const maxCheckTitleLength = 300
+
+// CheckTitle lists existing posts with the same title as the title query
+// parameter, ignoring case, so the post form can warn about duplicates
+func (h *PostHandler) CheckTitle(w http.ResponseWriter, r *http.Request) {
+	title := strings.TrimSpace(r.URL.Query().Get("title"))
+	if title == "" {
+		writeError(w, http.StatusBadRequest, "title", "Title is required")
+		return
+	}
+	if len(title) > maxCheckTitleLength {
+		writeError(w, http.StatusBadRequest, "title", "Title is too long")
+		return
+	}
+
+	posts, err := h.postRepo.FindByTitle(title)
+	if err != nil {
+		log.Printf("Error finding posts by title: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to check title")
+		return
+	}
+
+	writeList(w, posts, nil)
+}
+
+// GetPostChanges lists posts created, edited or deleted after the RFC 3339
+// "since" timestamp, for clients that sync incrementally. Pending posts are
+// only listed for their author and moderators
+func (h *PostHandler) GetPostChanges(w http.ResponseWriter, r *http.Request) {
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid since timestamp, expected RFC 3339")
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	changes, totalCount, err := h.postRepo.GetPostsUpdatedSince(since, h.viewer(r), page, limit)
+	if err != nil {
+		log.Printf("Error listing post changes: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post changes")
+		return
+	}
+
+	writePage(w, r, changes, newPagination(page, limit, totalCount))
+}
+
+const (
+	defaultPostsPerCategory = 3
+	maxPostsPerCategory     = 20
+)
+
+// GetLatestPerCategory serves the newest few posts in every category,
+// keyed by category ID, for homepage sections
+func (h *PostHandler) GetLatestPerCategory(w http.ResponseWriter, r *http.Request) {
+	perCategory := defaultPostsPerCategory
+	if raw := r.URL.Query().Get("per_category"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxPostsPerCategory {
+			writeJSONError(w, http.StatusBadRequest, "Invalid per_category")
+			return
+		}
+		perCategory = parsed
+	}
+
+	postsByCategory, err := h.postRepo.GetLatestPerCategory(perCategory)
+	if err != nil {
+		log.Printf("Error listing posts by category: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(postsByCategory)
+}
+
+// maxApprovalBatch caps how many posts one approval request may cover
+const maxApprovalBatch = 100
+
+type ApprovePostsRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ApprovePosts approves a batch of pending posts and reports the outcome
+// for each requested ID
+func (h *PostHandler) ApprovePosts(w http.ResponseWriter, r *http.Request) {
+	var req ApprovePostsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.IDs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "At least one post ID is required")
+		return
+	}
+	if len(req.IDs) > maxApprovalBatch {
+		writeJSONError(w, http.StatusBadRequest, "Too many post IDs")
+		return
+	}
+
+	results, err := h.postRepo.ApproveBatch(req.IDs)
+	if err != nil {
+		log.Printf("Error approving posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to approve posts")
+		return
+	}
+	h.notifyApproved(r.Context(), results)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+}
+
+// parsePagination reads the optional page and limit query parameters,
+// defaulting to the first page of 10 and capping limit at 100
+func parsePagination(r *http.Request) (page, limit int) {
+	page = 1
+	limit = 10
+
+	pageParam := r.URL.Query().Get("page")
+	limitParam := r.URL.Query().Get("limit")
+
+	if pageParam != "" {
+		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	return page, limit
+}
+
+// writePostPage encodes one page of posts with its pagination metadata
+func writePostPage(w http.ResponseWriter, r *http.Request, posts []models.Post, totalCount, page, limit int) {
+	writePage(w, r, posts, newPagination(page, limit, totalCount))
 }
 
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 	// Extract post ID
 	postIDStr := chi.URLParam(r, "id")
 	if postIDStr == "" {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	view, ok := parseUserViewParam(w, r)
+	if !ok {
 		return
 	}
 
 	// Retrieve post with details
-	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10))
+	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10), h.viewer(r))
 	if err != nil {
 		log.Printf("Error retrieving post: %v", err)
-		http.Error(w, "Post not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "Post not found")
 		return
 	}
 
+	h.writePost(w, view, post)
+}
+
+// writePost encodes a single post, embedding its author in the requested view
+func (h *PostHandler) writePost(w http.ResponseWriter, view UserView, post *models.Post) {
+	var response interface{} = post
+	if view != UserViewNone {
+		embedded, err := newAuthorEmbedder(view, h.userRepo).post(*post)
+		if err != nil {
+			log.Printf("Error embedding post author: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+			return
+		}
+		response = embedded
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(post)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPostBySlug retrieves a post by its slug, the URL name derived from the
+// title it was created with
+func (h *PostHandler) GetPostBySlug(w http.ResponseWriter, r *http.Request) {
+	view, ok := parseUserViewParam(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := h.postRepo.GetBySlug(chi.URLParam(r, "slug"), h.viewer(r))
+	if errors.Is(err, repository.ErrPostNotFound) {
+		writeJSONError(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error retrieving post by slug: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve post")
+		return
+	}
+
+	h.writePost(w, view, post)
 }
 
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	// Check user authentication
 	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -179,41 +842,46 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	var req CreatePostRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate input
-	if req.Title == "" || req.Content == "" {
-		http.Error(w, "Title and content are required", http.StatusBadRequest)
+	if msg, ok := h.validatePostText(req.Title, req.Content, req.Excerpt); !ok {
+		writeJSONError(w, http.StatusBadRequest, msg)
 		return
 	}
 
 	// Extract post ID
 	postIDStr := chi.URLParam(r, "id")
 	if postIDStr == "" {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	// Retrieve post with details
-	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10))
+	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10), repository.PostViewer{UserID: int64(userID)})
 	if err != nil {
 		log.Printf("Error retrieving post: %v", err)
-		http.Error(w, "Post not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "Post not found")
 		return
 	}
 
 	// Check if user is authorized
 	if post.UserID != int64(userID) {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+		writeJSONError(w, http.StatusForbidden, "Unauthorized")
+		return
+	}
+
+	categoryIDs, ok := h.validateCategories(w, req)
+	if !ok {
 		return
 	}
 
@@ -222,15 +890,16 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		ID:         postID,
 		Title:      req.Title,
 		Content:    req.Content,
-		Categories: []int64{int64(req.CategoryID)},
+		Excerpt:    strings.TrimSpace(req.Excerpt),
+		Categories: categoryIDs,
 		UpdatedAt:  time.Now(),
 		UserID:     post.UserID, // Add this line to ensure user ownership check
 	}
 
-	err = h.postRepo.UpdatePost(updatedPost, []int64{int64(req.CategoryID)}, post.UserID)
+	err = h.postRepo.UpdatePost(updatedPost, categoryIDs, post.UserID)
 	if err != nil {
 		log.Printf("Error updating post: %v", err)
-		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update post")
 		return
 	}
 
@@ -242,27 +911,27 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	// Check user authentication
 	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Extract post ID
 	postIDStr := chi.URLParam(r, "id")
 	if postIDStr == "" {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
 	// Retrieve post with details
-	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10))
+	post, err := h.postRepo.GetByID(strconv.FormatInt(postID, 10), repository.PostViewer{UserID: int64(userID)})
 	if err != nil || post.UserID != int64(userID) {
-		http.Error(w, "Unauthorized or post not found", http.StatusForbidden)
+		writeJSONError(w, http.StatusForbidden, "Unauthorized or post not found")
 		return
 	}
 
@@ -270,7 +939,7 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	err = h.postRepo.DeletePost(postIDStr, int64(userID))
 	if err != nil {
 		log.Printf("Error deleting post: %v", err)
-		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to delete post")
 		return
 	}
 
@@ -283,13 +952,16 @@ func (h *PostHandler) FilterPosts(w http.ResponseWriter, r *http.Request) {
 	categoryIDStr := r.URL.Query().Get("category_id")
 	userIDStr := r.URL.Query().Get("user_id")
 	likedByUserStr := r.URL.Query().Get("liked_by_user")
+	createdAfterStr := r.URL.Query().Get("created_after")
+	createdBeforeStr := r.URL.Query().Get("created_before")
 
 	var categoryID, userID, likedByUser *int64
+	var createdAfter, createdBefore *time.Time
 
 	if categoryIDStr != "" {
 		parsedCategoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
 		if err != nil {
-			http.Error(w, "Invalid category ID", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "Invalid category ID")
 			return
 		}
 		categoryID = &parsedCategoryID
@@ -298,7 +970,7 @@ func (h *PostHandler) FilterPosts(w http.ResponseWriter, r *http.Request) {
 	if userIDStr != "" {
 		parsedUserID, err := strconv.ParseInt(userIDStr, 10, 64)
 		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "Invalid user ID")
 			return
 		}
 		userID = &parsedUserID
@@ -307,40 +979,58 @@ func (h *PostHandler) FilterPosts(w http.ResponseWriter, r *http.Request) {
 	if likedByUserStr != "" {
 		parsedLikedByUser, err := strconv.ParseInt(likedByUserStr, 10, 64)
 		if err != nil {
-			http.Error(w, "Invalid liked by user ID", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "Invalid liked by user ID")
 			return
 		}
 		likedByUser = &parsedLikedByUser
 	}
 
+	if createdAfterStr != "" {
+		parsedCreatedAfter, err := time.Parse(time.RFC3339, createdAfterStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid created_after date, expected RFC 3339")
+			return
+		}
+		createdAfter = &parsedCreatedAfter
+	}
+
+	if createdBeforeStr != "" {
+		parsedCreatedBefore, err := time.Parse(time.RFC3339, createdBeforeStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid created_before date, expected RFC 3339")
+			return
+		}
+		createdBefore = &parsedCreatedBefore
+	}
+
 	// Filter posts
 	posts, err := h.postRepo.FilterPosts(struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
 	}{
-		CategoryID:  categoryID,
-		UserID:      userID,
-		LikedByUser: likedByUser,
-	})
+		CategoryID:    categoryID,
+		UserID:        userID,
+		LikedByUser:   likedByUser,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+	}, h.viewer(r))
 	if err != nil {
 		log.Printf("Failed to filter posts: %v", err)
-		http.Error(w, "Failed to filter posts", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to filter posts")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(posts); err != nil {
-		log.Printf("Failed to encode filtered posts: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	writeList(w, posts, nil)
 }
 
 func (h *PostHandler) CreatePostPage(w http.ResponseWriter, r *http.Request) {
 	// Check if user is authenticated
 	userID, ok := h.authMiddleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -355,7 +1045,7 @@ func (h *PostHandler) CreatePostPage(w http.ResponseWriter, r *http.Request) {
 	err := h.renderer.Render(w, r, "posts/create", data)
 	if err != nil {
 		log.Printf("Error rendering create post page: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 }
@@ -8,32 +8,52 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"forum/middleware"
 	"forum/mocks"
 	"forum/models"
+	"forum/pkg/features"
+	"forum/repository"
 )
 
 // PostRepositoryInterface defines the interface for post repository
 type PostRepositoryInterface interface {
 	Create(post *models.Post, categoryIDs []int64) error
-	GetByID(postID string) (*models.Post, error)
-	ListPosts(page, limit int) ([]models.Post, int, error)
+	GetByID(postID string, viewer repository.PostViewer) (*models.Post, error)
+	GetBySlug(slug string, viewer repository.PostViewer) (*models.Post, error)
+	ListPosts(page, limit int, sort repository.PostSort, excludeUserID *int64) ([]models.Post, int, error)
 	GetPostsByCategory(categoryID int64) ([]models.Post, error)
 	GetUserPosts(userID int64) ([]models.Post, error)
+	ListUserPosts(userID int64, includePending bool, page, limit int) ([]models.Post, int, error)
+	CountUserPosts(userID int64) (int, error)
+	GetPostCounts(postID int64, viewer repository.PostViewer) (likes, dislikes, comments int, err error)
 	GetLikedPosts(userID int64) ([]models.Post, error)
+	GetCommentedPosts(userID int64, page, limit int) ([]models.Post, int, error)
+	GetPostsWithoutEngagement(page, limit int) ([]models.Post, int, error)
+	SearchPosts(query string, page, limit int) ([]models.Post, int, error)
+	FindByTitle(title string) ([]models.Post, error)
+	GetLatestPerCategory(perCategory int) (map[int64][]models.Post, error)
+	GetCategoryIDsForPosts(postIDs []int64) (map[int64][]int64, error)
+	ApproveBatch(ids []int64) ([]repository.ApprovalResult, error)
+	GetPostsUpdatedSince(since time.Time, viewer repository.PostViewer, page, limit int) ([]repository.PostChange, int, error)
 	UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error
 	DeletePost(postID string, userID int64) error
 	FilterPosts(filters struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
-	}) ([]models.Post, error)
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
+	}, viewer repository.PostViewer) ([]models.Post, error)
 }
 
 // MockPostRepository simulates post repository for testing
@@ -46,16 +66,24 @@ func (m *MockPostRepository) Create(post *models.Post, categoryIDs []int64) erro
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) GetByID(postID string) (*models.Post, error) {
-	args := m.Called(postID)
+func (m *MockPostRepository) GetByID(postID string, viewer repository.PostViewer) (*models.Post, error) {
+	args := m.Called(postID, viewer)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) ListPosts(page, limit int) ([]models.Post, int, error) {
-	args := m.Called(page, limit)
+func (m *MockPostRepository) GetBySlug(slug string, viewer repository.PostViewer) (*models.Post, error) {
+	args := m.Called(slug, viewer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListPosts(page, limit int, sort repository.PostSort, excludeUserID *int64) ([]models.Post, int, error) {
+	args := m.Called(page, limit, sort, excludeUserID)
 	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
 }
 
@@ -69,11 +97,75 @@ func (m *MockPostRepository) GetUserPosts(userID int64) ([]models.Post, error) {
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) ListUserPosts(userID int64, includePending bool, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(userID, includePending, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) CountUserPosts(userID int64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPostRepository) GetPostCounts(postID int64, viewer repository.PostViewer) (likes, dislikes, comments int, err error) {
+	args := m.Called(postID, viewer)
+	return args.Int(0), args.Int(1), args.Int(2), args.Error(3)
+}
+
 func (m *MockPostRepository) GetLikedPosts(userID int64) ([]models.Post, error) {
 	args := m.Called(userID)
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) GetCommentedPosts(userID int64, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(userID, page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) GetPostsWithoutEngagement(page, limit int) ([]models.Post, int, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) SearchPosts(query string, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(query, page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) FindByTitle(title string) ([]models.Post, error) {
+	args := m.Called(title)
+	return args.Get(0).([]models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetLatestPerCategory(perCategory int) (map[int64][]models.Post, error) {
+	args := m.Called(perCategory)
+	return args.Get(0).(map[int64][]models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetCategoryIDsForPosts(postIDs []int64) (map[int64][]int64, error) {
+	args := m.Called(postIDs)
+	return args.Get(0).(map[int64][]int64), args.Error(1)
+}
+
+func (m *MockPostRepository) ApproveBatch(ids []int64) ([]repository.ApprovalResult, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ApprovalResult), args.Error(1)
+}
+
+func (m *MockPostRepository) GetPostsUpdatedSince(since time.Time, viewer repository.PostViewer, page, limit int) ([]repository.PostChange, int, error) {
+	args := m.Called(since, viewer, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]repository.PostChange), args.Int(1), args.Error(2)
+}
+
 func (m *MockPostRepository) UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error {
 	args := m.Called(post, categoryIDs, userID)
 	return args.Error(0)
@@ -85,12 +177,14 @@ func (m *MockPostRepository) DeletePost(postID string, userID int64) error {
 }
 
 func (m *MockPostRepository) FilterPosts(filters struct {
-	CategoryID  *int64
-	UserID      *int64
-	LikedByUser *int64
-},
+	CategoryID    *int64
+	UserID        *int64
+	LikedByUser   *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}, viewer repository.PostViewer,
 ) ([]models.Post, error) {
-	args := m.Called(filters)
+	args := m.Called(filters, viewer)
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
@@ -188,7 +282,7 @@ func TestGetPosts(t *testing.T) {
 			page:  1,
 			limit: 10,
 			mockBehavior: func() {
-				mockPostRepo.On("ListPosts", mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]models.Post{
+				mockPostRepo.On("ListPosts", mock.AnythingOfType("int"), mock.AnythingOfType("int"), repository.PostSortNewest, (*int64)(nil)).Return([]models.Post{
 					{ID: 1, Title: "Post 1"},
 					{ID: 2, Title: "Post 2"},
 				}, 2, nil)
@@ -239,8 +333,7 @@ func TestGetPosts(t *testing.T) {
 
 			if tc.expectedStatus == http.StatusOK {
 				var posts []models.Post
-				err := json.NewDecoder(w.Body).Decode(&posts)
-				assert.NoError(t, err)
+				decodeList(t, w.Body, &posts)
 			}
 
 			mockPostRepo.AssertExpectations(t)
@@ -264,7 +357,7 @@ func TestGetPost(t *testing.T) {
 			name:   "Get Post by ID",
 			postID: "1",
 			mockBehavior: func() {
-				mockPostRepo.On("GetByID", "1").Return(&models.Post{
+				mockPostRepo.On("GetByID", "1", repository.AnonymousViewer).Return(&models.Post{
 					ID:      1,
 					Title:   "Test Post",
 					Content: "Test Content",
@@ -310,3 +403,646 @@ func TestGetPost(t *testing.T) {
 		})
 	}
 }
+
+func TestListPostsDefaultSort(t *testing.T) {
+	newest := []models.Post{{ID: 2, Title: "Newer"}, {ID: 1, Title: "Older"}}
+	trending := []models.Post{{ID: 1, Title: "Older"}, {ID: 2, Title: "Newer"}}
+
+	testCases := []struct {
+		name        string
+		defaultSort *repository.PostSort
+		query       string
+		expectedIDs []int64
+	}{
+		{name: "Falls Back To Newest", expectedIDs: []int64{2, 1}},
+		{name: "Configured Trending", defaultSort: ptrPostSort(repository.PostSortTrending), expectedIDs: []int64{1, 2}},
+		{name: "Explicit Sort Overrides Default", defaultSort: ptrPostSort(repository.PostSortTrending), query: "?sort=newest", expectedIDs: []int64{2, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			mockPostRepo.On("ListPosts", 1, 10, repository.PostSortNewest, (*int64)(nil)).Return(newest, 2, nil).Maybe()
+			mockPostRepo.On("ListPosts", 1, 10, repository.PostSortTrending, (*int64)(nil)).Return(trending, 2, nil).Maybe()
+
+			handler := NewPostHandler(mockPostRepo, nil, nil)
+			if tc.defaultSort != nil {
+				handler = handler.WithDefaultSort(*tc.defaultSort)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/posts"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListPosts(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Data []models.Post `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+			var ids []int64
+			for _, post := range response.Data {
+				ids = append(ids, post.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestListPostsFields(t *testing.T) {
+	posts := []models.Post{
+		{ID: 2, UserID: 7, Title: "Newer", Content: "Long content", Username: "alice", Categories: []int64{1}},
+		{ID: 1, UserID: 8, Title: "Older", Content: "More content", Username: "bob"},
+	}
+
+	t.Run("Projects Requested Fields", func(t *testing.T) {
+		mockPostRepo := new(MockPostRepository)
+		mockPostRepo.On("ListPosts", 1, 10, repository.PostSortNewest, (*int64)(nil)).Return(posts, 2, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/posts?fields=id,%20title,username,id", nil)
+		w := httptest.NewRecorder()
+		NewPostHandler(mockPostRepo, nil, nil).ListPosts(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var got []map[string]interface{}
+		pagination := decodeList(t, w.Body, &got)
+		assert.Equal(t, []map[string]interface{}{
+			{"id": float64(2), "title": "Newer", "username": "alice"},
+			{"id": float64(1), "title": "Older", "username": "bob"},
+		}, got)
+		require.NotNil(t, pagination)
+		assert.Equal(t, 2, pagination.TotalCount)
+	})
+
+	t.Run("Unknown Field", func(t *testing.T) {
+		mockPostRepo := new(MockPostRepository)
+
+		req := httptest.NewRequest(http.MethodGet, "/posts?fields=id,password", nil)
+		w := httptest.NewRecorder()
+		NewPostHandler(mockPostRepo, nil, nil).ListPosts(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "fields", body.Field)
+		assert.Contains(t, body.Error, "password")
+		mockPostRepo.AssertNotCalled(t, "ListPosts", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreatePostMinimumLengths(t *testing.T) {
+	testCases := []struct {
+		name           string
+		title          string
+		content        string
+		excerpt        string
+		expectedStatus int
+	}{
+		{name: "At Minimum", title: "Hey", content: "Ten chars.", expectedStatus: http.StatusCreated},
+		{name: "Excerpt At Maximum", title: "Hey", content: "Ten chars.", excerpt: strings.Repeat("é", models.MaxExcerptLength), expectedStatus: http.StatusCreated},
+		{name: "Excerpt Too Long", title: "Hey", content: "Ten chars.", excerpt: strings.Repeat("é", models.MaxExcerptLength+1), expectedStatus: http.StatusBadRequest},
+		{name: "Title One Short", title: "Hi", content: "Ten chars.", expectedStatus: http.StatusBadRequest},
+		{name: "Content One Short", title: "Hey", content: "Nine char", expectedStatus: http.StatusBadRequest},
+		{name: "Whitespace Does Not Count", title: "Hey", content: "  Nine char  ", expectedStatus: http.StatusBadRequest},
+		{name: "Characters Not Bytes", title: "Héé", content: "Ünïcödé!!!", expectedStatus: http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			mockPostRepo.On("Create", mock.AnythingOfType("*models.Post"), mock.AnythingOfType("[]int64")).Return(nil).Maybe()
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(1, true)
+
+			handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).WithMinLengths(3, 10)
+
+			payload, _ := json.Marshal(CreatePostRequest{Title: tc.title, Content: tc.content, Excerpt: tc.excerpt, CategoryID: 1})
+			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBuffer(payload))
+			w := httptest.NewRecorder()
+
+			handler.CreatePost(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusBadRequest {
+				mockPostRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreatePostModerationQueueFlag(t *testing.T) {
+	testCases := []struct {
+		name           string
+		flags          *features.Flags
+		expectedStatus string
+	}{
+		{name: "Disabled", flags: features.New(), expectedStatus: ""},
+		{name: "Not Injected", flags: nil, expectedStatus: ""},
+		{name: "Enabled", flags: features.New(features.ModerationQueue), expectedStatus: repository.PostStatusPending},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved *models.Post
+			mockPostRepo := new(MockPostRepository)
+			mockPostRepo.On("Create", mock.AnythingOfType("*models.Post"), []int64{1}).Run(func(args mock.Arguments) {
+				saved = args.Get(0).(*models.Post)
+			}).Return(nil)
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(1, true)
+
+			handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).WithFeatures(tc.flags)
+
+			payload, _ := json.Marshal(CreatePostRequest{Title: "Flagged", Content: "Held for review?", CategoryID: 1})
+			req := httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload))
+			w := httptest.NewRecorder()
+
+			handler.CreatePost(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			if assert.NotNil(t, saved) {
+				assert.Equal(t, tc.expectedStatus, saved.Status)
+			}
+		})
+	}
+}
+
+func TestCreatePostDefaultCategory(t *testing.T) {
+	testCases := []struct {
+		name               string
+		defaultCategoryID  int64
+		categoryID         int
+		expectedCategories []int64
+	}{
+		{name: "Uncategorized Post Gets Default", defaultCategoryID: 9, expectedCategories: []int64{9}},
+		{name: "Chosen Category Kept", defaultCategoryID: 9, categoryID: 2, expectedCategories: []int64{2}},
+		{name: "Option Off", expectedCategories: []int64{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			mockPostRepo.On("Create", mock.AnythingOfType("*models.Post"), tc.expectedCategories).Return(nil)
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(1, true)
+
+			handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).WithDefaultCategory(tc.defaultCategoryID)
+
+			payload, _ := json.Marshal(CreatePostRequest{Title: "Filed", Content: "Where does it go?", CategoryID: tc.categoryID})
+			req := httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload))
+			w := httptest.NewRecorder()
+
+			handler.CreatePost(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var post models.Post
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&post))
+			assert.Equal(t, tc.expectedCategories, post.Categories)
+			mockPostRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreatePostCategoryNames(t *testing.T) {
+	testCases := []struct {
+		name               string
+		request            CreatePostRequest
+		flags              *features.Flags
+		mockBehavior       func(posts *MockPostRepository, categories *MockCategoryRepository)
+		expectedStatus     int
+		expectedCategories []int64
+	}{
+		{
+			name:    "Mixed Existing And New",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged by name", CategoryNames: []string{"golang", "Gardening"}},
+			flags:   features.New(features.CategoryAutoCreate),
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("ResolveCategoryNames", []string{"golang", "Gardening"}, true).Return([]int64{2, 7}, nil)
+				posts.On("Create", mock.AnythingOfType("*models.Post"), []int64{2, 7}).Return(nil)
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedCategories: []int64{2, 7},
+		},
+		{
+			name:    "Names Alongside Category ID",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged by name", CategoryID: 1, CategoryNames: []string{"golang", "Sports"}},
+			flags:   features.New(),
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("CategoriesExist", []int64{1}).Return(nil, nil)
+				categories.On("ResolveCategoryNames", []string{"golang", "Sports"}, false).Return([]int64{2, 1}, nil)
+				posts.On("Create", mock.AnythingOfType("*models.Post"), []int64{2, 1}).Return(nil)
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedCategories: []int64{2, 1},
+		},
+		{
+			name:    "Unknown Name Without Auto Create",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged by name", CategoryNames: []string{"Gardening"}},
+			flags:   features.New(),
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("ResolveCategoryNames", []string{"Gardening"}, false).
+					Return(nil, fmt.Errorf("%w: %q", repository.ErrCategoryNotFound, "Gardening"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Blank Name",
+			request:        CreatePostRequest{Title: "Tagged", Content: "Tagged by name", CategoryNames: []string{"golang", "  "}},
+			flags:          features.New(features.CategoryAutoCreate),
+			mockBehavior:   func(posts *MockPostRepository, categories *MockCategoryRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Name Too Long",
+			request:        CreatePostRequest{Title: "Tagged", Content: "Tagged by name", CategoryNames: []string{strings.Repeat("x", maxCategoryNameLength+1)}},
+			flags:          features.New(features.CategoryAutoCreate),
+			mockBehavior:   func(posts *MockPostRepository, categories *MockCategoryRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			mockCategoryRepo := new(MockCategoryRepository)
+			tc.mockBehavior(mockPostRepo, mockCategoryRepo)
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(1, true)
+
+			handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).
+				WithCategories(mockCategoryRepo).
+				WithFeatures(tc.flags)
+
+			payload, _ := json.Marshal(tc.request)
+			req := httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload))
+			w := httptest.NewRecorder()
+
+			handler.CreatePost(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCategories != nil {
+				var post models.Post
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&post))
+				assert.Equal(t, tc.expectedCategories, post.Categories)
+			}
+			mockPostRepo.AssertExpectations(t)
+			mockCategoryRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreatePostCategories(t *testing.T) {
+	testCases := []struct {
+		name               string
+		request            CreatePostRequest
+		mockBehavior       func(posts *MockPostRepository, categories *MockCategoryRepository)
+		expectedStatus     int
+		expectedCategories []int64
+	}{
+		{
+			name:    "Several Categories",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged twice", Categories: []int64{2, 5, 2}},
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("CategoriesExist", []int64{2, 5}).Return(nil, nil)
+				posts.On("Create", mock.AnythingOfType("*models.Post"), []int64{2, 5}).Return(nil)
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedCategories: []int64{2, 5},
+		},
+		{
+			name:    "Merged With Category ID",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged twice", CategoryID: 5, Categories: []int64{2}},
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("CategoriesExist", []int64{5, 2}).Return(nil, nil)
+				posts.On("Create", mock.AnythingOfType("*models.Post"), []int64{5, 2}).Return(nil)
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedCategories: []int64{5, 2},
+		},
+		{
+			name:    "Unknown Category",
+			request: CreatePostRequest{Title: "Tagged", Content: "Tagged twice", Categories: []int64{2, 99}},
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				categories.On("CategoriesExist", []int64{2, 99}).Return([]int64{99}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "No Categories",
+			request: CreatePostRequest{Title: "Untagged", Content: "Filed nowhere"},
+			mockBehavior: func(posts *MockPostRepository, categories *MockCategoryRepository) {
+				posts.On("Create", mock.AnythingOfType("*models.Post"), []int64{}).Return(nil)
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedCategories: []int64{},
+		},
+		{
+			name:           "Invalid Category ID",
+			request:        CreatePostRequest{Title: "Tagged", Content: "Tagged twice", Categories: []int64{0}},
+			mockBehavior:   func(posts *MockPostRepository, categories *MockCategoryRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			mockCategoryRepo := new(MockCategoryRepository)
+			tc.mockBehavior(mockPostRepo, mockCategoryRepo)
+			mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+			mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(1, true)
+
+			handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).WithCategories(mockCategoryRepo)
+
+			payload, _ := json.Marshal(tc.request)
+			req := httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload))
+			w := httptest.NewRecorder()
+
+			handler.CreatePost(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCategories != nil {
+				var post models.Post
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&post))
+				assert.Equal(t, tc.expectedCategories, post.Categories)
+			}
+			mockPostRepo.AssertExpectations(t)
+			mockCategoryRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreatePostCategoriesStored(t *testing.T) {
+	db, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	user, err := repository.NewUserRepository(db).Create(&models.User{Username: "tagger", Email: "tagger@example.com", Password: "Password1!"})
+	require.NoError(t, err)
+	categoryRepo := repository.NewCategoryRepository(db)
+	golang := &models.Category{Name: "Go"}
+	require.NoError(t, categoryRepo.Create(golang))
+	rust := &models.Category{Name: "Rust"}
+	require.NoError(t, categoryRepo.Create(rust))
+
+	mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+	mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(user.ID, true)
+	postRepo := repository.NewPostRepository(db)
+	handler := NewPostHandler(postRepo, mockAuthMiddleware, nil).WithCategories(categoryRepo)
+
+	payload, _ := json.Marshal(CreatePostRequest{Title: "Both", Content: "Go and Rust", Categories: []int64{golang.ID, rust.ID}})
+	w := httptest.NewRecorder()
+	handler.CreatePost(w, httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload)))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.Post
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	stored, err := postRepo.GetByID(fmt.Sprint(created.ID), repository.AnonymousViewer)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{golang.ID, rust.ID}, stored.Categories)
+}
+
+func TestCheckTitle(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockBehavior   func(m *MockPostRepository)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:  "Duplicate Found",
+			query: "?title=+Best+Go+Books+",
+			mockBehavior: func(m *MockPostRepository) {
+				m.On("FindByTitle", "Best Go Books").Return([]models.Post{{ID: 3, Title: "best go books"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:  "No Duplicates",
+			query: "?title=Fresh",
+			mockBehavior: func(m *MockPostRepository) {
+				m.On("FindByTitle", "Fresh").Return([]models.Post(nil), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing Title",
+			query:          "?title=%20",
+			mockBehavior:   func(m *MockPostRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			tc.mockBehavior(mockPostRepo)
+			handler := NewPostHandler(mockPostRepo, nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/check-title"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.CheckTitle(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var posts []models.Post
+				assert.Nil(t, decodeList(t, w.Body, &posts))
+				assert.Len(t, posts, tc.expectedCount)
+			}
+			mockPostRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func ptrPostSort(sort repository.PostSort) *repository.PostSort {
+	return &sort
+}
+
+func TestSearchPosts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockBehavior   func(m *MockPostRepository)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:  "Matches",
+			query: "?q=+golang+&page=2&limit=5",
+			mockBehavior: func(m *MockPostRepository) {
+				m.On("SearchPosts", "golang", 2, 5).Return([]models.Post{
+					{ID: 6, Title: "Golang tips"},
+				}, 6, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "Blank Query",
+			query:          "?q=%20%20",
+			mockBehavior:   func(m *MockPostRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing Query",
+			mockBehavior:   func(m *MockPostRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPostRepo := new(MockPostRepository)
+			tc.mockBehavior(mockPostRepo)
+			handler := NewPostHandler(mockPostRepo, mocks.NewMockAuthMiddleware(t), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/search"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.SearchPosts(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var posts []models.Post
+				pagination := decodeList(t, w.Body, &posts)
+				assert.Len(t, posts, tc.expectedCount)
+				assert.Equal(t, &Pagination{Page: 2, Limit: 5, TotalCount: 6, TotalPages: 2}, pagination)
+			}
+			mockPostRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLikedPosts(t *testing.T) {
+	db, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userRepo := repository.NewUserRepository(db)
+	author, err := userRepo.Create(&models.User{Username: "author", Email: "author@example.com", Password: "Password1!"})
+	require.NoError(t, err)
+	reader, err := userRepo.Create(&models.User{Username: "reader", Email: "reader@example.com", Password: "Password1!"})
+	require.NoError(t, err)
+
+	var categoryIDs []int64
+	for _, name := range []string{"Technology", "Music"} {
+		result, err := db.Exec(`INSERT INTO categories (name) VALUES (?)`, name)
+		require.NoError(t, err)
+		categoryID, err := result.LastInsertId()
+		require.NoError(t, err)
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	postRepo := repository.NewPostRepository(db)
+	var posts []*models.Post
+	for _, title := range []string{"Liked first", "Liked second", "Disliked", "Ignored"} {
+		post := &models.Post{UserID: int64(author.ID), Title: title, Content: "Content"}
+		// A post in two categories must still be listed once
+		var postCategories []int64
+		if len(posts) == 0 {
+			postCategories = categoryIDs
+		}
+		require.NoError(t, postRepo.Create(post, postCategories))
+		posts = append(posts, post)
+	}
+
+	interactionRepo := repository.NewInteractionRepository(db)
+	require.NoError(t, interactionRepo.AddInteraction(int64(reader.ID), posts[0].ID, models.EntityTypePost, models.Like))
+	require.NoError(t, interactionRepo.AddInteraction(int64(reader.ID), posts[1].ID, models.EntityTypePost, models.Like))
+	require.NoError(t, interactionRepo.AddInteraction(int64(reader.ID), posts[2].ID, models.EntityTypePost, models.Dislike))
+	require.NoError(t, interactionRepo.AddInteraction(int64(author.ID), posts[3].ID, models.EntityTypePost, models.Like))
+
+	mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+	mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(reader.ID, true)
+	handler := NewPostHandler(postRepo, mockAuthMiddleware, nil)
+
+	w := httptest.NewRecorder()
+	handler.GetLikedPosts(w, httptest.NewRequest(http.MethodGet, "/me/liked-posts", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var liked []models.Post
+	decodeList(t, w.Body, &liked)
+	var ids []int64
+	for _, post := range liked {
+		ids = append(ids, post.ID)
+	}
+	assert.ElementsMatch(t, []int64{posts[0].ID, posts[1].ID}, ids)
+	for _, post := range liked {
+		if post.ID == posts[0].ID {
+			assert.ElementsMatch(t, []string{"Technology", "Music"}, strings.Split(post.CategoryName, ", "))
+		}
+	}
+}
+
+func TestFilterPostsByDate(t *testing.T) {
+	db, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userRepo := repository.NewUserRepository(db)
+	author, err := userRepo.Create(&models.User{Username: "author", Email: "author@example.com", Password: "Password1!"})
+	require.NoError(t, err)
+
+	postRepo := repository.NewPostRepository(db)
+	base := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for title, createdAt := range map[string]time.Time{
+		"Last week":   base.Add(-7 * 24 * time.Hour),
+		"This week":   base.Add(time.Hour),
+		"Next week":   base.Add(7*24*time.Hour + time.Hour),
+		"Week starts": base,
+	} {
+		post := &models.Post{UserID: int64(author.ID), Title: title, Content: "Content"}
+		require.NoError(t, postRepo.Create(post, nil))
+		_, err := db.Exec(`UPDATE posts SET created_at = ? WHERE id = ?`, createdAt.Local(), post.ID)
+		require.NoError(t, err)
+	}
+
+	handler := NewPostHandler(postRepo, nil, nil)
+	filter := func(query url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.FilterPosts(w, httptest.NewRequest(http.MethodGet, "/posts/filter?"+query.Encode(), nil))
+		return w
+	}
+
+	t.Run("Within Range", func(t *testing.T) {
+		w := filter(url.Values{
+			"created_after":  {base.Format(time.RFC3339)},
+			"created_before": {base.Add(7 * 24 * time.Hour).Format(time.RFC3339)},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		var posts []models.Post
+		decodeList(t, w.Body, &posts)
+		var titles []string
+		for _, post := range posts {
+			titles = append(titles, post.Title)
+		}
+		assert.Equal(t, []string{"This week", "Week starts"}, titles)
+	})
+
+	t.Run("Offset Bounds", func(t *testing.T) {
+		// 2024-03-10T14:00:00+02:00 is the same instant as the week start
+		zone := time.FixedZone("", 2*60*60)
+		w := filter(url.Values{
+			"created_after":  {base.In(zone).Format(time.RFC3339)},
+			"created_before": {base.Add(7 * 24 * time.Hour).In(zone).Format(time.RFC3339)},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		var posts []models.Post
+		decodeList(t, w.Body, &posts)
+		var titles []string
+		for _, post := range posts {
+			titles = append(titles, post.Title)
+		}
+		assert.Equal(t, []string{"This week", "Week starts"}, titles)
+	})
+
+	for _, param := range []string{"created_after", "created_before"} {
+		t.Run("Unparseable "+param, func(t *testing.T) {
+			w := filter(url.Values{param: {"last tuesday"}})
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), param)
+		})
+	}
+}
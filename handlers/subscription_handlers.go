@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"forum/middleware"
+	"forum/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SubscriptionHandler struct {
+	subscriptionRepo repository.SubscriptionRepositoryInterface
+}
+
+func NewSubscriptionHandler(subscriptionRepo repository.SubscriptionRepositoryInterface) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionRepo: subscriptionRepo}
+}
+
+// SubscribeCategory follows the category in the URL for the current user
+func (h *SubscriptionHandler) SubscribeCategory(w http.ResponseWriter, r *http.Request) {
+	userID, categoryID, ok := subscriptionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.subscriptionRepo.Subscribe(userID, categoryID); err != nil {
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error subscribing to category: %v", err)
+		http.Error(w, "Failed to subscribe to category", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnsubscribeCategory stops following the category in the URL
+func (h *SubscriptionHandler) UnsubscribeCategory(w http.ResponseWriter, r *http.Request) {
+	userID, categoryID, ok := subscriptionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.subscriptionRepo.Unsubscribe(userID, categoryID); err != nil {
+		log.Printf("Error unsubscribing from category: %v", err)
+		http.Error(w, "Failed to unsubscribe from category", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// subscriptionTarget reads the current user and the category ID from the URL,
+// writing an error response when either is missing
+func subscriptionTarget(w http.ResponseWriter, r *http.Request) (userID, categoryID int64, ok bool) {
+	id, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	categoryID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return int64(id), categoryID, true
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"forum/middleware"
+	"forum/mocks"
+	"forum/models"
+	"forum/pkg/features"
+	"forum/repository"
+)
+
+// MockSubscriptionRepository simulates subscription repository for testing
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionRepository) Subscribe(userID, categoryID int64) error {
+	args := m.Called(userID, categoryID)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) Unsubscribe(userID, categoryID int64) error {
+	args := m.Called(userID, categoryID)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) ListSubscribers(categoryIDs []int64) ([]int64, error) {
+	args := m.Called(categoryIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+var _ repository.SubscriptionRepositoryInterface = &MockSubscriptionRepository{}
+
+func TestSubscribeCategory(t *testing.T) {
+	testCases := []struct {
+		name           string
+		categoryID     string
+		mockBehavior   func(m *MockSubscriptionRepository)
+		expectedStatus int
+	}{
+		{
+			name:       "Subscribed",
+			categoryID: "2",
+			mockBehavior: func(m *MockSubscriptionRepository) {
+				m.On("Subscribe", int64(7), int64(2)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Unknown Category",
+			categoryID: "99",
+			mockBehavior: func(m *MockSubscriptionRepository) {
+				m.On("Subscribe", int64(7), int64(99)).Return(repository.ErrCategoryNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Category ID",
+			categoryID:     "abc",
+			mockBehavior:   func(m *MockSubscriptionRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockSubscriptionRepository)
+			tc.mockBehavior(mockRepo)
+			handler := NewSubscriptionHandler(mockRepo)
+
+			req := httptest.NewRequest(http.MethodPut, "/categories/"+tc.categoryID+"/subscription", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.categoryID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, middleware.UserClaimsKey, &middleware.Claims{UserID: 7})
+			w := httptest.NewRecorder()
+
+			handler.SubscribeCategory(w, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreatePostNotifiesCategorySubscribers(t *testing.T) {
+	const authorID, subscriberID = 1, 4
+
+	mockPostRepo := new(MockPostRepository)
+	mockPostRepo.On("Create", mock.AnythingOfType("*models.Post"), []int64{3}).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Post).ID = 11
+	}).Return(nil)
+	mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+	mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(authorID, true)
+
+	mockSubscriptionRepo := new(MockSubscriptionRepository)
+	mockSubscriptionRepo.On("ListSubscribers", []int64{3}).Return([]int64{authorID, subscriberID}, nil)
+
+	var notifications []repository.Notification
+	mockNotificationRepo := new(MockNotificationRepository)
+	mockNotificationRepo.On("CreateBatch", mock.Anything).Run(func(args mock.Arguments) {
+		notifications = args.Get(0).([]repository.Notification)
+	}).Return(nil)
+
+	handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).
+		WithSubscriptions(mockSubscriptionRepo, mockNotificationRepo)
+
+	payload, _ := json.Marshal(CreatePostRequest{Title: "New in Go", Content: "Generics are here", CategoryID: 3})
+	req := httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload))
+	w := httptest.NewRecorder()
+
+	handler.CreatePost(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, int64(subscriberID), notifications[0].UserID)
+		assert.Equal(t, int64(authorID), notifications[0].ActorID)
+		assert.Equal(t, repository.NotificationTypeNewPost, notifications[0].Type)
+		assert.Equal(t, models.EntityTypePost, notifications[0].EntityType)
+		assert.Equal(t, int64(11), notifications[0].EntityID)
+	}
+	mockSubscriptionRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestPendingPostNotifiesOnApproval(t *testing.T) {
+	const authorID, subscriberID = 1, 4
+
+	mockPostRepo := new(MockPostRepository)
+	mockPostRepo.On("Create", mock.AnythingOfType("*models.Post"), []int64{3}).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Post).ID = 11
+	}).Return(nil)
+	mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+	mockAuthMiddleware.On("GetUserIDFromContext", mock.Anything).Return(authorID, true)
+	mockSubscriptionRepo := new(MockSubscriptionRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	handler := NewPostHandler(mockPostRepo, mockAuthMiddleware, nil).
+		WithSubscriptions(mockSubscriptionRepo, mockNotificationRepo).
+		WithFeatures(features.New(features.ModerationQueue))
+
+	payload, _ := json.Marshal(CreatePostRequest{Title: "New in Go", Content: "Generics are here", CategoryID: 3})
+	w := httptest.NewRecorder()
+	handler.CreatePost(w, httptest.NewRequest(http.MethodPost, "/posts/create", bytes.NewBuffer(payload)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// Nobody hears about the post while it waits for moderation
+	mockSubscriptionRepo.AssertNotCalled(t, "ListSubscribers", mock.Anything)
+	mockNotificationRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+
+	mockPostRepo.On("ApproveBatch", []int64{11, 12}).Return([]repository.ApprovalResult{
+		{PostID: 11, Result: repository.ApprovalApproved},
+		{PostID: 12, Result: repository.ApprovalAlreadyApproved},
+	}, nil)
+	mockPostRepo.On("GetByID", "11", repository.PostViewer{Moderator: true}).
+		Return(&models.Post{ID: 11, UserID: authorID, Title: "New in Go", Categories: []int64{3}}, nil)
+	mockSubscriptionRepo.On("ListSubscribers", []int64{3}).Return([]int64{authorID, subscriberID}, nil)
+	var notifications []repository.Notification
+	mockNotificationRepo.On("CreateBatch", mock.Anything).Run(func(args mock.Arguments) {
+		notifications = args.Get(0).([]repository.Notification)
+	}).Return(nil).Once()
+
+	w = httptest.NewRecorder()
+	handler.ApprovePosts(w, httptest.NewRequest(http.MethodPost, "/admin/posts/approve", bytes.NewBufferString(`{"ids": [11, 12]}`)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, int64(subscriberID), notifications[0].UserID)
+		assert.Equal(t, int64(11), notifications[0].EntityID)
+	}
+	mockPostRepo.AssertExpectations(t)
+	mockSubscriptionRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
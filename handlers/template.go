@@ -1,12 +1,22 @@
 package handlers
 
 import (
-	"html/template"
 	"fmt"
+	"html/template"
 	"net/http"
+	"os"
 	"reflect"
 	"strings"
 	"time"
+
+	"forum/models"
+)
+
+const (
+	// DefaultTemplateGlob is the pattern used to locate the HTML templates
+	DefaultTemplateGlob = "frontend/templates/**/*.html"
+	// DefaultStaticDir is the directory served under /static/
+	DefaultStaticDir = "./frontend/static"
 )
 
 // TemplateData is a generic struct for passing data to templates
@@ -27,20 +37,48 @@ type TemplateRenderer struct {
 
 // NewTemplateRenderer creates a new template renderer
 func NewTemplateRenderer(authMiddleware AuthMiddleware) *TemplateRenderer {
-	// Parse all templates, including base and component templates
-	templates := template.Must(template.New("").Funcs(template.FuncMap{
+	renderer, err := LoadTemplateRenderer(authMiddleware, DefaultTemplateGlob)
+	if err != nil {
+		panic(err)
+	}
+	return renderer
+}
+
+// LoadTemplateRenderer parses all templates matching the pattern, including base
+// and component templates, and returns an error instead of panicking so that
+// startup can fail fast when templates are missing or invalid
+func LoadTemplateRenderer(authMiddleware AuthMiddleware, pattern string) (*TemplateRenderer, error) {
+	templates, err := template.New("").Funcs(template.FuncMap{
 		"truncate": func(s string, length int) string {
 			if len(s) <= length {
 				return s
 			}
 			return s[:length] + "..."
 		},
+		"previewLength": func() int {
+			return models.PreviewLength
+		},
 		"formatDate": func(t time.Time) string {
 			return t.Format("Jan 2, 2006")
 		},
-	}).ParseGlob("frontend/templates/**/*.html"))
+	}).ParseGlob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates %q: %w", pattern, err)
+	}
+
+	return &TemplateRenderer{templates: templates, authMiddleware: authMiddleware}, nil
+}
 
-	return &TemplateRenderer{templates: templates, authMiddleware: authMiddleware}
+// CheckStaticDir verifies that the static asset directory exists
+func CheckStaticDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("static directory %q is not accessible: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("static path %q is not a directory", dir)
+	}
+	return nil
 }
 
 // Render renders a specific template with given data
@@ -85,7 +123,7 @@ func (tr *TemplateRenderer) RenderError(w http.ResponseWriter, r *http.Request,
 
 // ParseTemplates is a utility function to manually parse templates if needed
 func (tr *TemplateRenderer) ParseTemplates() error {
-	templates, err := template.ParseGlob("frontend/templates/**/*.html")
+	templates, err := template.ParseGlob(DefaultTemplateGlob)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"forum/mocks"
+)
+
+func TestLoadTemplateRenderer(t *testing.T) {
+	mockAuthMiddleware := mocks.NewMockAuthMiddleware(t)
+
+	t.Run("Missing Templates", func(t *testing.T) {
+		pattern := filepath.Join(t.TempDir(), "templates", "**", "*.html")
+
+		renderer, err := LoadTemplateRenderer(mockAuthMiddleware, pattern)
+		assert.Error(t, err)
+		assert.Nil(t, renderer)
+		assert.Contains(t, err.Error(), pattern)
+	})
+
+	t.Run("Templates Present", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "templates", "errors")
+		assert.NoError(t, os.MkdirAll(dir, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "500.html"), []byte(`{{ define "base" }}ok{{ end }}`), 0o644))
+
+		renderer, err := LoadTemplateRenderer(mockAuthMiddleware, filepath.Join(filepath.Dir(dir), "**", "*.html"))
+		assert.NoError(t, err)
+		assert.NotNil(t, renderer)
+	})
+}
+
+func TestCheckStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.css")
+	assert.NoError(t, os.WriteFile(file, []byte("body {}"), 0o644))
+
+	assert.NoError(t, CheckStaticDir(dir))
+	assert.Error(t, CheckStaticDir(filepath.Join(dir, "missing")))
+	assert.Error(t, CheckStaticDir(file))
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"forum/middleware"
+	"forum/models"
+	"forum/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type UserHandler struct {
+	userRepo        repository.UserRepository
+	postRepo        repository.PostRepositoryInterface
+	commentRepo     repository.CommentRepositoryInterface
+	interactionRepo repository.InteractionRepositoryInterface
+	authMiddleware  *middleware.AuthMiddleware
+}
+
+func NewUserHandler(
+	userRepo repository.UserRepository,
+	postRepo repository.PostRepositoryInterface,
+	commentRepo repository.CommentRepositoryInterface,
+	interactionRepo repository.InteractionRepositoryInterface,
+) *UserHandler {
+	return &UserHandler{
+		userRepo:        userRepo,
+		postRepo:        postRepo,
+		commentRepo:     commentRepo,
+		interactionRepo: interactionRepo,
+	}
+}
+
+// WithRoles lets the handler look up the moderator and admin roles granted
+// by the auth middleware
+func (h *UserHandler) WithRoles(authMiddleware *middleware.AuthMiddleware) *UserHandler {
+	h.authMiddleware = authMiddleware
+	return h
+}
+
+// UserProfileResponse is the public view of a user; email and password are
+// never included
+type UserProfileResponse struct {
+	ID            int           `json:"id"`
+	Username      string        `json:"username"`
+	CreatedAt     time.Time     `json:"created_at"`
+	PostCount     int           `json:"post_count"`
+	CommentCount  int           `json:"comment_count"`
+	LikesReceived int           `json:"likes_received"`
+	Posts         []models.Post `json:"posts"`
+}
+
+// GetUserProfile returns a user's public profile with a page of their approved
+// posts and stats
+func (h *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || userID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			writeJSONError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("Failed to retrieve user: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user")
+		return
+	}
+
+	// Only approved posts are public; the author sees pending ones under /me/posts
+	page, limit := parsePagination(r)
+	posts, _, err := h.postRepo.ListUserPosts(int64(userID), false, page, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve user posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user profile")
+		return
+	}
+	if posts == nil {
+		posts = []models.Post{}
+	}
+
+	postCount, err := h.postRepo.CountUserPosts(int64(userID))
+	if err != nil {
+		log.Printf("Failed to count user posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user profile")
+		return
+	}
+
+	commentCount, err := h.commentRepo.CountUserComments(int64(userID))
+	if err != nil {
+		log.Printf("Failed to count user comments: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user profile")
+		return
+	}
+
+	_, _, likesReceived, err := h.interactionRepo.GetUserInteractionSummary(int64(userID))
+	if err != nil {
+		log.Printf("Failed to retrieve interaction summary: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user profile")
+		return
+	}
+
+	response := UserProfileResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		CreatedAt:     user.CreatedAt,
+		PostCount:     postCount,
+		CommentCount:  commentCount,
+		LikesReceived: likesReceived,
+		Posts:         posts,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// GetUserComments returns one page of a user's comments, each with the title
+// of the post it was left on
+func (h *UserHandler) GetUserComments(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || userID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if _, err := h.userRepo.FindByID(userID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			writeJSONError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("Failed to retrieve user: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user comments")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	comments, totalCount, err := h.commentRepo.GetUserComments(int64(userID), page, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve user comments: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user comments")
+		return
+	}
+
+	writePage(w, r, comments, newPagination(page, limit, totalCount))
+}
+
+// GetUserPosts returns one page of a user's approved posts, newest first
+func (h *UserHandler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || userID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if _, err := h.userRepo.FindByID(userID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			writeJSONError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("Failed to retrieve user: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user posts")
+		return
+	}
+
+	h.writeUserPosts(w, r, userID, false)
+}
+
+// GetMyPosts returns one page of the current user's posts, including those
+// still waiting for moderation
+func (h *UserHandler) GetMyPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	h.writeUserPosts(w, r, userID, true)
+}
+
+func (h *UserHandler) writeUserPosts(w http.ResponseWriter, r *http.Request, userID int, includePending bool) {
+	page, limit := parsePagination(r)
+	posts, totalCount, err := h.postRepo.ListUserPosts(int64(userID), includePending, page, limit)
+	if err != nil {
+		log.Printf("Failed to retrieve user posts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to retrieve user posts")
+		return
+	}
+
+	writePostPage(w, r, posts, totalCount, page, limit)
+}
+
+// PermissionsResponse is the current user's role and what it allows, so
+// clients can decide which controls to show
+type PermissionsResponse struct {
+	Role        string `json:"role"`
+	CanModerate bool   `json:"can_moderate"`
+	CanAdmin    bool   `json:"can_admin"`
+}
+
+// GetMyPermissions returns the current user's role and permission flags
+func (h *UserHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.GetUserIDFromContext(r.Context()); !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	response := PermissionsResponse{Role: middleware.RoleUser}
+	if h.authMiddleware != nil {
+		response = PermissionsResponse{
+			Role:        h.authMiddleware.Role(r.Context()),
+			CanModerate: h.authMiddleware.IsModerator(r.Context()),
+			CanAdmin:    h.authMiddleware.IsAdmin(r.Context()),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode permissions response: %v", err)
+	}
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"forum/middleware"
+	"forum/models"
+	"forum/repository"
+)
+
+func TestGetUserProfile(t *testing.T) {
+	joined := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		userID         string
+		mockBehavior   func(users *MockUserRepository, posts *MockPostRepository, comments *MockCommentRepository, interactions *MockInteractionRepository)
+		expectedStatus int
+	}{
+		{
+			name:   "Profile Found",
+			userID: "5",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository, comments *MockCommentRepository, interactions *MockInteractionRepository) {
+				users.On("FindByID", 5).Return(&models.User{
+					ID: 5, Username: "alice", Email: "alice@example.com", Password: "hash", CreatedAt: joined,
+				}, nil)
+				posts.On("ListUserPosts", int64(5), false, 1, 10).Return([]models.Post{
+					{ID: 8, UserID: 5, Title: "Hello", Content: "World"},
+				}, 1, nil)
+				posts.On("CountUserPosts", int64(5)).Return(1, nil)
+				comments.On("CountUserComments", int64(5)).Return(3, nil)
+				interactions.On("GetUserInteractionSummary", int64(5)).Return(2, 1, 7, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "User Not Found",
+			userID: "6",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository, comments *MockCommentRepository, interactions *MockInteractionRepository) {
+				users.On("FindByID", 6).Return(nil, repository.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Invalid User ID",
+			userID: "abc",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository, comments *MockCommentRepository, interactions *MockInteractionRepository) {
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Count Fails",
+			userID: "5",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository, comments *MockCommentRepository, interactions *MockInteractionRepository) {
+				users.On("FindByID", 5).Return(&models.User{ID: 5, Username: "alice", CreatedAt: joined}, nil)
+				posts.On("ListUserPosts", int64(5), false, 1, 10).Return(nil, 0, nil)
+				posts.On("CountUserPosts", int64(5)).Return(0, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := new(MockUserRepository)
+			posts := new(MockPostRepository)
+			comments := new(MockCommentRepository)
+			interactions := new(MockInteractionRepository)
+			tc.mockBehavior(users, posts, comments, interactions)
+			handler := NewUserHandler(users, posts, comments, interactions)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/"+tc.userID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.userID)
+			w := httptest.NewRecorder()
+
+			handler.GetUserProfile(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				var body ErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.NotEmpty(t, body.Error)
+			}
+			users.AssertExpectations(t)
+			posts.AssertExpectations(t)
+			comments.AssertExpectations(t)
+			interactions.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUserProfileJSONShape(t *testing.T) {
+	users := new(MockUserRepository)
+	users.On("FindByID", 5).Return(&models.User{
+		ID: 5, Username: "alice", Email: "alice@example.com", Password: "hash", CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}, nil)
+	posts := new(MockPostRepository)
+	posts.On("ListUserPosts", int64(5), false, 1, 10).Return([]models.Post(nil), 0, nil)
+	posts.On("CountUserPosts", int64(5)).Return(0, nil)
+	comments := new(MockCommentRepository)
+	comments.On("CountUserComments", int64(5)).Return(3, nil)
+	interactions := new(MockInteractionRepository)
+	interactions.On("GetUserInteractionSummary", int64(5)).Return(2, 1, 7, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/5", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	w := httptest.NewRecorder()
+
+	NewUserHandler(users, posts, comments, interactions).GetUserProfile(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, map[string]interface{}{
+		"id":             float64(5),
+		"username":       "alice",
+		"created_at":     "2024-03-01T12:00:00Z",
+		"post_count":     float64(0),
+		"comment_count":  float64(3),
+		"likes_received": float64(7),
+		"posts":          []interface{}{},
+	}, body)
+}
+
+func TestGetUserComments(t *testing.T) {
+	testCases := []struct {
+		name           string
+		userID         string
+		query          string
+		mockBehavior   func(users *MockUserRepository, comments *MockCommentRepository)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:   "Second Page",
+			userID: "5",
+			query:  "?page=2&limit=2",
+			mockBehavior: func(users *MockUserRepository, comments *MockCommentRepository) {
+				users.On("FindByID", 5).Return(&models.User{ID: 5, Username: "alice"}, nil)
+				comments.On("GetUserComments", int64(5), 2, 2).Return([]repository.Comment{
+					{ID: 3, PostID: 8, UserID: 5, PostTitle: "Hello", Content: "Nice post"},
+				}, 3, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:   "User Not Found",
+			userID: "6",
+			mockBehavior: func(users *MockUserRepository, comments *MockCommentRepository) {
+				users.On("FindByID", 6).Return(nil, repository.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid User ID",
+			userID:         "abc",
+			mockBehavior:   func(users *MockUserRepository, comments *MockCommentRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Database Error",
+			userID: "5",
+			mockBehavior: func(users *MockUserRepository, comments *MockCommentRepository) {
+				users.On("FindByID", 5).Return(&models.User{ID: 5, Username: "alice"}, nil)
+				comments.On("GetUserComments", int64(5), 1, 10).Return(nil, 0, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := new(MockUserRepository)
+			comments := new(MockCommentRepository)
+			tc.mockBehavior(users, comments)
+			handler := NewUserHandler(users, new(MockPostRepository), comments, new(MockInteractionRepository))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/"+tc.userID+"/comments"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.userID)
+			w := httptest.NewRecorder()
+
+			handler.GetUserComments(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var got []repository.Comment
+				pagination := decodeList(t, w.Body, &got)
+				assert.Len(t, got, tc.expectedCount)
+				require.NotNil(t, pagination)
+				assert.Equal(t, 3, pagination.TotalCount)
+			} else {
+				var body ErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.NotEmpty(t, body.Error)
+			}
+			users.AssertExpectations(t)
+			comments.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUserPosts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		userID         string
+		query          string
+		mockBehavior   func(users *MockUserRepository, posts *MockPostRepository)
+		expectedStatus int
+		expectedCount  int
+		expectedTotal  int
+	}{
+		{
+			name:   "Populated",
+			userID: "5",
+			query:  "?page=2&limit=2",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository) {
+				users.On("FindByID", 5).Return(&models.User{ID: 5, Username: "alice"}, nil)
+				posts.On("ListUserPosts", int64(5), false, 2, 2).Return([]models.Post{
+					{ID: 3, UserID: 5, Title: "Third"},
+				}, 3, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTotal:  3,
+		},
+		{
+			name:   "No Posts",
+			userID: "6",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository) {
+				users.On("FindByID", 6).Return(&models.User{ID: 6, Username: "lurker"}, nil)
+				posts.On("ListUserPosts", int64(6), false, 1, 10).Return(nil, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "User Not Found",
+			userID: "7",
+			mockBehavior: func(users *MockUserRepository, posts *MockPostRepository) {
+				users.On("FindByID", 7).Return(nil, repository.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid User ID",
+			userID:         "abc",
+			mockBehavior:   func(users *MockUserRepository, posts *MockPostRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			users := new(MockUserRepository)
+			posts := new(MockPostRepository)
+			tc.mockBehavior(users, posts)
+			handler := NewUserHandler(users, posts, new(MockCommentRepository), new(MockInteractionRepository))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/"+tc.userID+"/posts"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.userID)
+			w := httptest.NewRecorder()
+
+			handler.GetUserPosts(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				got := []models.Post{{}}
+				pagination := decodeList(t, w.Body, &got)
+				assert.NotNil(t, got, "an empty page is a list, not null")
+				assert.Len(t, got, tc.expectedCount)
+				require.NotNil(t, pagination)
+				assert.Equal(t, tc.expectedTotal, pagination.TotalCount)
+			} else {
+				var body ErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.NotEmpty(t, body.Error)
+			}
+			users.AssertExpectations(t)
+			posts.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetMyPosts(t *testing.T) {
+	t.Run("Includes Pending", func(t *testing.T) {
+		posts := new(MockPostRepository)
+		posts.On("ListUserPosts", int64(2), true, 1, 10).Return([]models.Post{
+			{ID: 9, UserID: 2, Title: "Awaiting review"},
+		}, 1, nil)
+		handler := NewUserHandler(new(MockUserRepository), posts, new(MockCommentRepository), new(MockInteractionRepository))
+
+		req := httptest.NewRequest(http.MethodGet, "/me/posts", nil)
+		ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: 2})
+		w := httptest.NewRecorder()
+		handler.GetMyPosts(w, req.WithContext(ctx))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []models.Post
+		pagination := decodeList(t, w.Body, &got)
+		assert.Len(t, got, 1)
+		require.NotNil(t, pagination)
+		assert.Equal(t, 1, pagination.TotalCount)
+		posts.AssertExpectations(t)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		handler := NewUserHandler(new(MockUserRepository), new(MockPostRepository), new(MockCommentRepository), new(MockInteractionRepository))
+
+		w := httptest.NewRecorder()
+		handler.GetMyPosts(w, httptest.NewRequest(http.MethodGet, "/me/posts", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "Unauthorized", body.Error)
+	})
+}
+
+func TestGetMyPermissions(t *testing.T) {
+	authMiddleware := middleware.NewAuthMiddleware(nil, "secret", 1).
+		WithModerators(2).
+		WithAdmins(3)
+	handler := NewUserHandler(new(MockUserRepository), new(MockPostRepository), new(MockCommentRepository), new(MockInteractionRepository)).
+		WithRoles(authMiddleware)
+
+	testCases := []struct {
+		name     string
+		userID   int
+		expected PermissionsResponse
+	}{
+		{
+			name:     "Regular User",
+			userID:   1,
+			expected: PermissionsResponse{Role: middleware.RoleUser},
+		},
+		{
+			name:     "Moderator",
+			userID:   2,
+			expected: PermissionsResponse{Role: middleware.RoleModerator, CanModerate: true},
+		},
+		{
+			name:     "Admin",
+			userID:   3,
+			expected: PermissionsResponse{Role: middleware.RoleAdmin, CanModerate: true, CanAdmin: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me/permissions", nil)
+			ctx := context.WithValue(req.Context(), middleware.UserClaimsKey, &middleware.Claims{UserID: tc.userID})
+			w := httptest.NewRecorder()
+			handler.GetMyPermissions(w, req.WithContext(ctx))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var got PermissionsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.GetMyPermissions(w, httptest.NewRequest(http.MethodGet, "/me/permissions", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "Unauthorized", body.Error)
+	})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"forum/models"
+	"forum/repository"
+)
+
+// UserView selects how the authors embedded in post and comment responses
+// are rendered
+type UserView string
+
+const (
+	// UserViewNone leaves responses as they were, without an author object
+	UserViewNone UserView = ""
+	// UserViewCompact embeds only the author's ID and username
+	UserViewCompact UserView = "compact"
+	// UserViewFull embeds the author's public profile
+	UserViewFull UserView = "full"
+)
+
+// parseUserView reads the view from the users query parameter, falling back
+// to the profile parameter of the Accept header, as in
+// "application/json; profile=compact"
+func parseUserView(r *http.Request) (UserView, error) {
+	value := r.URL.Query().Get("users")
+	if value == "" {
+		for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+			if _, params, err := mime.ParseMediaType(accept); err == nil && params["profile"] != "" {
+				value = params["profile"]
+				break
+			}
+		}
+	}
+
+	switch view := UserView(strings.ToLower(strings.TrimSpace(value))); view {
+	case UserViewNone, UserViewCompact, UserViewFull:
+		return view, nil
+	default:
+		return "", fmt.Errorf("unknown user view %q, expected compact or full", value)
+	}
+}
+
+// parseUserViewParam reads the requested user view, answering 400 when it is
+// not one of the known views
+func parseUserViewParam(w http.ResponseWriter, r *http.Request) (UserView, bool) {
+	view, err := parseUserView(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "users", "Invalid users: "+err.Error())
+		return "", false
+	}
+	return view, true
+}
+
+// EmbeddedUser is an author embedded in a post or comment. CreatedAt is only
+// set in the full view
+type EmbeddedUser struct {
+	ID        int64      `json:"id"`
+	Username  string     `json:"username"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// PostWithAuthor is a post with its author embedded
+type PostWithAuthor struct {
+	models.Post
+	Author *EmbeddedUser `json:"author"`
+}
+
+// CommentWithAuthor is a comment with its author embedded
+type CommentWithAuthor struct {
+	repository.Comment
+	Author *EmbeddedUser `json:"author"`
+}
+
+// authorEmbedder builds the embedded authors of one response. In the full
+// view the profiles of every author on the page are loaded in one query.
+// Without a user repository the full view falls back to compact
+type authorEmbedder struct {
+	view    UserView
+	users   repository.UserRepository
+	authors map[int64]*EmbeddedUser
+}
+
+func newAuthorEmbedder(view UserView, users repository.UserRepository) *authorEmbedder {
+	return &authorEmbedder{view: view, users: users, authors: make(map[int64]*EmbeddedUser)}
+}
+
+// preload fetches the full profiles of the given authors that are not known
+// yet. Authors that no longer exist keep the username from the row
+func (e *authorEmbedder) preload(userIDs []int64) error {
+	if e.view != UserViewFull || e.users == nil {
+		return nil
+	}
+
+	var missing []int
+	for _, userID := range userIDs {
+		if _, ok := e.authors[userID]; !ok {
+			missing = append(missing, int(userID))
+			// Reserve the ID so duplicates on the page are fetched once
+			e.authors[userID] = nil
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	users, err := e.users.FindByIDs(missing)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		createdAt := user.CreatedAt
+		e.authors[int64(user.ID)] = &EmbeddedUser{ID: int64(user.ID), Username: user.Username, CreatedAt: &createdAt}
+	}
+	return nil
+}
+
+func (e *authorEmbedder) author(userID int64, username string) *EmbeddedUser {
+	if author := e.authors[userID]; author != nil {
+		return author
+	}
+	author := &EmbeddedUser{ID: userID, Username: username}
+	e.authors[userID] = author
+	return author
+}
+
+func (e *authorEmbedder) post(post models.Post) (PostWithAuthor, error) {
+	if err := e.preload([]int64{post.UserID}); err != nil {
+		return PostWithAuthor{}, err
+	}
+	return PostWithAuthor{Post: post, Author: e.author(post.UserID, post.Username)}, nil
+}
+
+func (e *authorEmbedder) posts(posts []models.Post) ([]PostWithAuthor, error) {
+	userIDs := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		userIDs = append(userIDs, post.UserID)
+	}
+	if err := e.preload(userIDs); err != nil {
+		return nil, err
+	}
+
+	embedded := make([]PostWithAuthor, 0, len(posts))
+	for _, post := range posts {
+		embedded = append(embedded, PostWithAuthor{Post: post, Author: e.author(post.UserID, post.Username)})
+	}
+	return embedded, nil
+}
+
+func (e *authorEmbedder) comment(comment repository.Comment) (CommentWithAuthor, error) {
+	if err := e.preload([]int64{comment.UserID}); err != nil {
+		return CommentWithAuthor{}, err
+	}
+	return CommentWithAuthor{Comment: comment, Author: e.author(comment.UserID, comment.Username)}, nil
+}
+
+func (e *authorEmbedder) comments(comments []repository.Comment) ([]CommentWithAuthor, error) {
+	userIDs := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	if err := e.preload(userIDs); err != nil {
+		return nil, err
+	}
+
+	embedded := make([]CommentWithAuthor, 0, len(comments))
+	for _, comment := range comments {
+		embedded = append(embedded, CommentWithAuthor{Comment: comment, Author: e.author(comment.UserID, comment.Username)})
+	}
+	return embedded, nil
+}
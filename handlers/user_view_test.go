@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"forum/models"
+	"forum/repository"
+)
+
+func TestPostUserViews(t *testing.T) {
+	joined := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	serve := func(users *MockUserRepository, target string, accept string) *httptest.ResponseRecorder {
+		posts := new(MockPostRepository)
+		posts.On("GetByID", "4", repository.AnonymousViewer).Return(&models.Post{ID: 4, UserID: 2, Title: "Hello", Content: "World", Username: "alice"}, nil).Maybe()
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "4")
+		w := httptest.NewRecorder()
+		NewPostHandler(posts, nil, nil).WithUsers(users).
+			GetPost(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		return w
+	}
+	author := func(t *testing.T, w *httptest.ResponseRecorder) map[string]json.RawMessage {
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		var embedded map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(body["author"], &embedded))
+		return embedded
+	}
+
+	t.Run("Compact", func(t *testing.T) {
+		users := new(MockUserRepository)
+		embedded := author(t, serve(users, "/posts/4?users=compact", ""))
+
+		assert.ElementsMatch(t, []string{"id", "username"}, mapKeys(embedded))
+		assert.JSONEq(t, `"alice"`, string(embedded["username"]))
+		users.AssertNotCalled(t, "FindByIDs", []int{2})
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		users := new(MockUserRepository)
+		users.On("FindByIDs", []int{2}).Return(map[int]*models.User{
+			2: {ID: 2, Username: "alice", Email: "alice@example.com", CreatedAt: joined},
+		}, nil)
+		embedded := author(t, serve(users, "/posts/4?users=full", ""))
+
+		assert.ElementsMatch(t, []string{"id", "username", "created_at"}, mapKeys(embedded))
+		assert.JSONEq(t, `2`, string(embedded["id"]))
+		assert.JSONEq(t, `"2024-03-01T09:00:00Z"`, string(embedded["created_at"]))
+		users.AssertExpectations(t)
+	})
+
+	t.Run("Accept Profile", func(t *testing.T) {
+		embedded := author(t, serve(new(MockUserRepository), "/posts/4", `application/json; profile="compact"`))
+		assert.ElementsMatch(t, []string{"id", "username"}, mapKeys(embedded))
+	})
+
+	t.Run("Default Has No Author", func(t *testing.T) {
+		w := serve(new(MockUserRepository), "/posts/4", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.NotContains(t, body, "author")
+	})
+
+	t.Run("Unknown View", func(t *testing.T) {
+		w := serve(new(MockUserRepository), "/posts/4?users=everything", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "users", body.Field)
+	})
+}
+
+func TestCommentUserViews(t *testing.T) {
+	comments := new(MockCommentRepository)
+	comments.On("GetByPostIDPaginated", int64(4), 1, 10, repository.CommentSortNew, false).Return([]repository.Comment{
+		{ID: 7, PostID: 4, UserID: 5, Username: "bob", Content: "First"},
+		{ID: 8, PostID: 4, UserID: 5, Username: "bob", Content: "Second"},
+		{ID: 9, PostID: 4, UserID: 6, Username: "carol", Content: "Third"},
+	}, 3, nil)
+	users := new(MockUserRepository)
+	// Every author on the page is looked up in one query; carol has since
+	// been deleted and keeps the username from her comment
+	users.On("FindByIDs", []int{5, 6}).Return(map[int]*models.User{
+		5: {ID: 5, Username: "bob", CreatedAt: time.Now()},
+	}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/4/comments?users=full&limit=10", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("postId", "4")
+	w := httptest.NewRecorder()
+	NewCommentHandler(comments, nil).WithUsers(users).
+		GetComments(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var page []map[string]json.RawMessage
+	decodeList(t, w.Body, &page)
+	require.Len(t, page, 3)
+	for _, comment := range page[:2] {
+		var embedded map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(comment["author"], &embedded))
+		assert.ElementsMatch(t, []string{"id", "username", "created_at"}, mapKeys(embedded))
+	}
+	var deleted map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(page[2]["author"], &deleted))
+	assert.ElementsMatch(t, []string{"id", "username"}, mapKeys(deleted))
+	assert.JSONEq(t, `"carol"`, string(deleted["username"]))
+	users.AssertExpectations(t)
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"forum/config"
 	"forum/db"
 	"forum/handlers"
 	"forum/middleware"
+	"forum/pkg/worker"
 	"forum/repository"
 	"forum/routes"
 
@@ -26,11 +29,17 @@ func main() {
 	}
 	log.Printf("✅ Environment Configuration Loaded (Took %v)", time.Since(startupTimer))
 
+	// Parse and validate all settings before touching any resources
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Configuration Validation Failed: %v", err)
+	}
+
 	// Set up logging
-	setupLogging()
+	setupLogging(cfg)
 
 	// Initialize database with comprehensive error tracking
-	database, err := initializeDatabase()
+	database, err := initializeDatabase(cfg)
 	if err != nil {
 		log.Fatalf("❌ Database Initialization Failed: %v\n%+v", err, err)
 	}
@@ -42,47 +51,76 @@ func main() {
 	log.Printf("✅ Database Initialized (Took %v)", time.Since(startupTimer))
 
 	// Run database migrations with detailed error context
-	if err := runMigrations(database); err != nil {
+	if err := runMigrations(database, cfg); err != nil {
 		log.Fatalf("❌ Database Migration Failed: %v\n%+v", err, err)
 	}
 	log.Printf("✅ Database Migrations Completed (Took %v)", time.Since(startupTimer))
 
+	// Run an operator subcommand instead of serving when one is given
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], database); err != nil {
+			log.Fatalf("❌ Command %q Failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(database.Conn)
-	postRepo := repository.NewPostRepository(database.Conn)
+	postRepo := repository.NewPostRepository(database.Conn).WithReplica(database.Replica)
 	sessionRepo := repository.NewSessionRepository(database.Conn)
 
 	// Initialize auth middleware
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		log.Fatal("JWT_SECRET not set in environment")
-	}
-	authMiddleware := middleware.NewAuthMiddleware(sessionRepo, secretKey, 24)
+	authMiddleware := middleware.NewAuthMiddleware(sessionRepo, cfg.JWTSecret, int(cfg.JWTExpiration.Hours())).
+		WithModerators(cfg.ModeratorUserIDs...).
+		WithAdmins(cfg.AdminUserIDs...)
 
-	// Initialize template renderer
-	templateRenderer := handlers.NewTemplateRenderer(authMiddleware)
+	// Initialize template renderer and verify static assets before serving requests
+	templateRenderer, err := initializeTemplates(authMiddleware, cfg)
+	if err != nil {
+		log.Fatalf("❌ Template Initialization Failed: %v", err)
+	}
+	log.Printf("✅ Templates and Static Assets Verified (Took %v)", time.Since(startupTimer))
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userRepo, authMiddleware, templateRenderer)
-	postHandler := handlers.NewPostHandler(postRepo, authMiddleware, templateRenderer)
+	postHandler := handlers.NewPostHandler(postRepo, authMiddleware, templateRenderer).
+		WithDefaultSort(repository.PostSort(cfg.FeedDefaultSort)).
+		WithMinLengths(cfg.PostMinTitle, cfg.PostMinContent).
+		WithDefaultCategory(cfg.DefaultCategoryID).
+		WithFeatures(cfg.Features)
 
 	// Create router with comprehensive initialization
-	router, err := createRouter(database, authHandler, postHandler, authMiddleware)
+	router, err := createRouter(database, cfg, authHandler, postHandler, authMiddleware, templateRenderer)
 	if err != nil {
 		log.Fatalf("❌ Router Initialization Failed: %v\n%+v", err, err)
 	}
 	log.Printf("✅ Router Configured (Took %v)", time.Since(startupTimer))
 
-	// Get server configuration
-	port := getServerPort()
+	// Background jobs run alongside the server and stop after it does
+	workers := worker.NewWorkerManager(context.Background())
+	startWorkers(workers, database, cfg)
 
 	// Start server with detailed startup logging
-	log.Printf("🚀 Attempting to start server on port %s", port)
-	if err := router.Start(port); err != nil {
-		log.Fatalf("❌ Server Startup Failed: %v\n%+v", err, err)
+	log.Printf("🚀 Attempting to start server on port %s", cfg.ServerPort)
+	// A failed start is logged rather than fatal, so the workers still stop
+	// and the deferred close still releases the database
+	serveErr := router.Start(cfg.Address())
+	if serveErr != nil {
+		log.Printf("❌ Server Startup Failed: %v\n%+v", serveErr, serveErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerTimeouts.Shutdown)
+	defer cancel()
+	if err := workers.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Background Workers Did Not Stop: %v", err)
+	}
+	if serveErr != nil {
+		return
 	}
 
-	log.Printf("🎉 Application Startup Completed Successfully (Total Time: %v)", time.Since(startupTimer))
+	// Start returns once in-flight requests have drained; the deferred close
+	// releases the database afterwards
+	log.Printf("👋 Server Stopped (Uptime: %v)", time.Since(startupTimer))
 }
 
 // Enhanced environment loading with multiple path support
@@ -109,42 +147,107 @@ func loadEnvironment() error {
 }
 
 // Comprehensive database initialization
-func initializeDatabase() (*db.Database, error) {
-	dbConnString := os.Getenv("DB_CONNECTION_STRING")
-	if dbConnString == "" {
-		dbConnString = "file:forum.db?_foreign_keys=on"
-		log.Println("⚠️ Using default database connection string")
-	}
-
-	log.Printf("Initializing database with connection string: %s", dbConnString)
-	database, err := db.NewDatabase(dbConnString)
+func initializeDatabase(cfg *config.Config) (*db.Database, error) {
+	log.Printf("Initializing database with connection string: %s", cfg.DBConnectionString)
+	database, err := db.NewDatabase(cfg.DBConnectionString)
 	if err != nil {
 		return nil, fmt.Errorf("database initialization error: %w", err)
 	}
+	database.Conn.SetMaxOpenConns(cfg.DBMaxConnections)
+
+	if cfg.DBReplicaString != "" {
+		log.Println("Initializing read replica")
+		if err := database.OpenReplica(cfg.DBReplicaString); err != nil {
+			database.Close()
+			return nil, fmt.Errorf("database initialization error: %w", err)
+		}
+		database.Replica.SetMaxOpenConns(cfg.DBMaxConnections)
+	}
 
 	return database, nil
 }
 
 // Enhanced migration process
-func runMigrations(database *db.Database) error {
-	migrationPath := os.Getenv("MIGRATION_PATH")
-	if migrationPath == "" {
-		migrationPath = "./db/migrations/001_create_tables.sql"
-		log.Println("⚠️ Using default migration script path")
-	}
-
-	log.Printf("Running migrations from: %s", migrationPath)
-	if err := database.Migrate(migrationPath); err != nil {
+func runMigrations(database *db.Database, cfg *config.Config) error {
+	log.Printf("Running migrations from: %s", cfg.MigrationPath)
+	if err := database.Migrate(cfg.MigrationPath); err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
 	return nil
 }
 
+// Operator subcommands run against the migrated database
+func runCommand(name string, database *db.Database) error {
+	switch name {
+	case "reconcile-counts":
+		repaired, err := repository.NewInteractionRepository(database.Conn).ReconcileInteractionCounts()
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Interaction counts reconciled (%d posts repaired)", repaired)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (available: reconcile-counts)", name)
+	}
+}
+
+// startWorkers registers the scheduled background jobs the configuration enables
+func startWorkers(workers *worker.WorkerManager, database *db.Database, cfg *config.Config) {
+	sessions := repository.NewSessionRepository(database.Conn)
+	workers.Every("session-cleanup", cfg.SessionCleanupInterval, func(ctx context.Context) {
+		deleted, err := sessions.DeleteExpiredSessions()
+		if err != nil {
+			log.Printf("⚠️ Session Cleanup Failed: %v", err)
+			return
+		}
+		log.Printf("🧹 Deleted %d expired sessions", deleted)
+	})
+
+	if cfg.PurgeDeletedAfter > 0 {
+		posts := repository.NewPostRepository(database.Conn)
+		comments := repository.NewCommentRepository(database.Conn)
+		workers.Every("purge-deleted", cfg.PurgeInterval, func(ctx context.Context) {
+			purgeDeleted(posts, comments, time.Now().Add(-cfg.PurgeDeletedAfter))
+		})
+	}
+}
+
+// purgeDeleted permanently removes posts and comments deleted before cutoff
+func purgeDeleted(posts *repository.PostRepository, comments *repository.CommentRepository, cutoff time.Time) {
+	purgedComments, err := comments.PurgeDeleted(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Purging Deleted Comments Failed: %v", err)
+	}
+	purgedPosts, err := posts.PurgeDeleted(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Purging Deleted Posts Failed: %v", err)
+	}
+	if purgedComments > 0 || purgedPosts > 0 {
+		log.Printf("🧹 Purged content deleted before %s (%d posts, %d comments)", cutoff.Format(time.RFC3339), purgedPosts, purgedComments)
+	}
+}
+
+// Template and static asset validation
+func initializeTemplates(authMiddleware *middleware.AuthMiddleware, cfg *config.Config) (*handlers.TemplateRenderer, error) {
+	log.Printf("Parsing templates from: %s", handlers.DefaultTemplateGlob)
+	templateRenderer, err := handlers.LoadTemplateRenderer(authMiddleware, handlers.DefaultTemplateGlob)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Checking static directory: %s", cfg.StaticDir)
+	if err := handlers.CheckStaticDir(cfg.StaticDir); err != nil {
+		return nil, err
+	}
+
+	return templateRenderer, nil
+}
+
 // Router creation with error handling
-func createRouter(database *db.Database, authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler, authMiddleware *middleware.AuthMiddleware) (*routes.Router, error) {
+func createRouter(database *db.Database, cfg *config.Config, authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler, authMiddleware *middleware.AuthMiddleware, templateRenderer *handlers.TemplateRenderer) (*routes.Router, error) {
 	log.Println("Creating application router")
-	router := routes.NewRouter(database, authHandler, postHandler, authMiddleware)
+	router := routes.NewRouter(database, cfg, authHandler, postHandler, authMiddleware, templateRenderer)
 
 	// Additional validation can be added here
 	if router == nil {
@@ -154,20 +257,10 @@ func createRouter(database *db.Database, authHandler *handlers.AuthHandler, post
 	return router, nil
 }
 
-// Server port configuration
-func getServerPort() string {
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080"
-		log.Println("⚠️ Using default port 8080")
-	}
-	return ":" + port
-}
-
 // setupLogging configures application logging
-func setupLogging() {
-	logLevel := os.Getenv("LOG_LEVEL")
-	logOutputPath := os.Getenv("LOG_OUTPUT_PATH")
+func setupLogging(cfg *config.Config) {
+	logLevel := cfg.LogLevel
+	logOutputPath := cfg.LogOutputPath
 
 	// Default to stdout
 	log.SetOutput(os.Stdout)
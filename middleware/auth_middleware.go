@@ -2,11 +2,14 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"forum/models"
 	"forum/repository"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,22 +17,26 @@ import (
 )
 
 type AuthMiddlewareInterface interface {
-	GenerateToken(userID int, username string) (string, error)
+	GenerateToken(userID int, username string, duration time.Duration) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	ProtectRoute(next http.Handler) http.Handler
 	GetUserIDFromContext(ctx context.Context) (int, bool)
 	IsAuthenticated(r *http.Request) bool
+	RevokeSession(r *http.Request) error
 }
 
 type AuthMiddleware struct {
-	sessionRepo repository.SessionRepositoryInterface
-	secretKey   []byte
-	expiration  time.Duration
+	sessionRepo  repository.SessionRepositoryInterface
+	secretKey    []byte
+	expiration   time.Duration
+	moderatorIDs map[int]bool
+	adminIDs     map[int]bool
 }
 
 type Claims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserID       int    `json:"user_id"`
+	Username     string `json:"username"`
+	SessionToken string `json:"session_token,omitempty"` // server-side session backing the JWT
 	jwt.RegisteredClaims
 }
 
@@ -45,39 +52,184 @@ func NewAuthMiddleware(sessionRepo repository.SessionRepositoryInterface, secret
 	}
 }
 
-func (m *AuthMiddleware) GenerateToken(userID int, username string) (string, error) {
-	return generateToken(m.secretKey, m.expiration, userID, username)
+// WithModerators grants moderator privileges to the given user IDs
+func (m *AuthMiddleware) WithModerators(userIDs ...int) *AuthMiddleware {
+	m.moderatorIDs = make(map[int]bool, len(userIDs))
+	for _, id := range userIDs {
+		m.moderatorIDs[id] = true
+	}
+	return m
+}
+
+// WithAdmins grants admin privileges, which include moderation, to the given
+// user IDs
+func (m *AuthMiddleware) WithAdmins(userIDs ...int) *AuthMiddleware {
+	m.adminIDs = make(map[int]bool, len(userIDs))
+	for _, id := range userIDs {
+		m.adminIDs[id] = true
+	}
+	return m
+}
+
+// IsModerator reports whether the authenticated user in the context is a
+// moderator or an admin
+func (m *AuthMiddleware) IsModerator(ctx context.Context) bool {
+	userID, ok := GetUserIDFromContext(ctx)
+	return ok && (m.moderatorIDs[userID] || m.adminIDs[userID])
+}
+
+// IsAdmin reports whether the authenticated user in the context is an admin
+func (m *AuthMiddleware) IsAdmin(ctx context.Context) bool {
+	userID, ok := GetUserIDFromContext(ctx)
+	return ok && m.adminIDs[userID]
+}
+
+// Roles a user can hold, from least to most privileged
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Role returns the most privileged role of the authenticated user in the context
+func (m *AuthMiddleware) Role(ctx context.Context) string {
+	switch {
+	case m.IsAdmin(ctx):
+		return RoleAdmin
+	case m.IsModerator(ctx):
+		return RoleModerator
+	default:
+		return RoleUser
+	}
+}
+
+// RequireModerator rejects requests whose authenticated user is not a moderator
+func (m *AuthMiddleware) RequireModerator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.IsModerator(r.Context()) {
+			http.Error(w, "Forbidden: moderator access required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
+// GenerateToken issues a JWT for the user that expires after duration, or
+// after the configured expiration when duration is zero. With a session
+// repository configured it also starts a server-side session of the same
+// lifetime, replacing any earlier one, and binds the token to it so the token
+// stops working once it is revoked
+func (m *AuthMiddleware) GenerateToken(userID int, username string, duration time.Duration) (string, error) {
+	if duration <= 0 {
+		duration = m.expiration
+	}
+
+	sessionToken := ""
+	if m.sessionRepo != nil {
+		user := &models.User{ID: userID, Username: username}
+		if err := m.sessionRepo.CreateSession(user, duration); err != nil {
+			return "", fmt.Errorf("failed to create session: %w", err)
+		}
+		sessionToken = user.SessionToken
+	}
+	return generateToken(m.secretKey, duration, userID, username, sessionToken)
+}
+
+// ValidateToken checks the JWT signature and expiry and, with a session
+// repository configured, that its session has not been revoked
 func (m *AuthMiddleware) ValidateToken(tokenString string) (*Claims, error) {
-	return validateToken(m.secretKey, tokenString)
+	claims, err := validateToken(m.secretKey, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.sessionRepo != nil {
+		if claims.SessionToken == "" {
+			return nil, fmt.Errorf("token has no session")
+		}
+		if _, err := m.sessionRepo.ValidateSession(claims.SessionToken); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// RevokeSession ends the server-side session behind the request's token so
+// the token can no longer be used
+func (m *AuthMiddleware) RevokeSession(r *http.Request) error {
+	tokenString, err := m.extractToken(r)
+	if err != nil || tokenString == "" {
+		return fmt.Errorf("no token in request")
+	}
+
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		return err
+	}
+
+	if m.sessionRepo == nil {
+		return nil
+	}
+	return m.sessionRepo.InvalidateSession(claims.UserID)
 }
 
 func (m *AuthMiddleware) ProtectRoute(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header or cookie
+		// Extract token from Authorization header or cookie; a missing
+		// cookie just means the visitor is not logged in
 		tokenString, err := m.extractToken(r)
+		if err != nil || tokenString == "" {
+			rejectUnauthenticated(w, r, "Unauthorized")
+			return
+		}
+
+		claims, err := m.ValidateToken(tokenString)
 		if err != nil {
-			http.Error(w, "Failed to extract token", http.StatusInternalServerError)
+			rejectUnauthenticated(w, r, "Invalid token")
 			return
 		}
-		if tokenString == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+		// Add user info to request context
+		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuth adds the user's claims to the request context when it carries
+// a valid token, so public routes can serve logged-in users differently, and
+// passes every other request through as anonymous
+func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := m.extractToken(r)
+		if err != nil || tokenString == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
 
 		claims, err := m.ValidateToken(tokenString)
 		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Add user info to request context
 		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// rejectUnauthenticated sends browsers to the login page, remembering where
+// they were headed, and gives API clients a JSON 401
+func rejectUnauthenticated(w http.ResponseWriter, r *http.Request, message string) {
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
 func (m *AuthMiddleware) extractToken(r *http.Request) (string, error) {
 	// Check Authorization header
 	bearerToken := r.Header.Get("Authorization")
@@ -118,11 +270,12 @@ func (m *AuthMiddleware) GetUserIDFromContext(ctx context.Context) (int, bool) {
 	return GetUserIDFromContext(ctx)
 }
 
-func generateToken(secretKey []byte, expiration time.Duration, userID int, username string) (string, error) {
+func generateToken(secretKey []byte, expiration time.Duration, userID int, username, sessionToken string) (string, error) {
 	expirationTime := time.Now().Add(expiration)
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:       userID,
+		Username:     username,
+		SessionToken: sessionToken,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
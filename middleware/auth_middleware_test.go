@@ -4,11 +4,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"forum/models"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockSessionRepository simulates session repository for testing
@@ -16,8 +18,8 @@ type MockSessionRepository struct {
 	mock.Mock
 }
 
-func (m *MockSessionRepository) CreateSession(user *models.User) error {
-	args := m.Called(user)
+func (m *MockSessionRepository) CreateSession(user *models.User, duration time.Duration) error {
+	args := m.Called(user, duration)
 	return args.Error(0)
 }
 
@@ -141,3 +143,78 @@ func TestProtectRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestProtectRouteUnauthenticated(t *testing.T) {
+	middleware := NewAuthMiddleware(new(MockSessionRepository), "test-secret-key", 24)
+	protectedHandler := middleware.ProtectRoute(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("protected handler must not run without authentication")
+	}))
+
+	t.Run("Browser Is Redirected To Login", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/dashboard?tab=posts", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		w := httptest.NewRecorder()
+
+		protectedHandler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login?next=%2Fdashboard%3Ftab%3Dposts", w.Header().Get("Location"))
+	})
+
+	t.Run("API Client Gets JSON 401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/interactions/mine", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+
+		protectedHandler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"Unauthorized"}`, w.Body.String())
+	})
+
+	t.Run("Invalid Token From Browser", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posts/create", nil)
+		req.Header.Set("Accept", "text/html")
+		req.AddCookie(&http.Cookie{Name: "token", Value: "not-a-jwt"})
+		w := httptest.NewRecorder()
+
+		protectedHandler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login?next=%2Fposts%2Fcreate", w.Header().Get("Location"))
+	})
+}
+
+func TestOptionalAuth(t *testing.T) {
+	middleware := NewAuthMiddleware(nil, "test-secret-key", 24)
+	serve := func(token string) (int, bool) {
+		var userID int
+		var ok bool
+		handler := middleware.OptionalAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok = GetUserIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return userID, ok
+	}
+
+	token, err := middleware.GenerateToken(7, "reader", 0)
+	require.NoError(t, err)
+
+	userID, ok := serve(token)
+	assert.True(t, ok)
+	assert.Equal(t, 7, userID)
+
+	_, ok = serve("")
+	assert.False(t, ok, "anonymous requests pass through without claims")
+
+	_, ok = serve("not-a-jwt")
+	assert.False(t, ok, "invalid tokens are ignored rather than rejected")
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	cors := NewCORSMiddleware([]string{"http://localhost:8080"}, []string{"/healthz", "/metrics"})
+
+	r := chi.NewRouter()
+	r.Use(cors.Handler)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.Get("/healthz", ok)
+	r.Get("/api/v1/posts", ok)
+
+	testCases := []struct {
+		name           string
+		path           string
+		origin         string
+		expectedStatus int
+		expectedHeader string
+	}{
+		{
+			name:           "Health Check Without Origin",
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Health Check From Unknown Origin",
+			path:           "/healthz",
+			origin:         "http://monitoring.example.com",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Health Check From Allowed Origin",
+			path:           "/healthz",
+			origin:         "http://localhost:8080",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Posts From Allowed Origin",
+			path:           "/api/v1/posts",
+			origin:         "http://localhost:8080",
+			expectedStatus: http.StatusOK,
+			expectedHeader: "http://localhost:8080",
+		},
+		{
+			name:           "Posts From Unknown Origin",
+			path:           "/api/v1/posts",
+			origin:         "http://evil.example.com",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedHeader, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORSMiddlewarePreflight(t *testing.T) {
+	cors := NewCORSMiddleware([]string{"http://localhost:8080"}, nil).
+		WithAllowedMethods(http.MethodGet, http.MethodPost).
+		WithAllowedHeaders("Authorization", "Content-Type", "X-Request-ID")
+
+	reached := false
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/posts/create", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Allowed Origin", func(t *testing.T) {
+		reached = false
+		w := preflight("http://localhost:8080")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.False(t, reached)
+		assert.Equal(t, "http://localhost:8080", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type, X-Request-ID", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("Unknown Origin", func(t *testing.T) {
+		reached = false
+		w := preflight("http://evil.example.com")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.False(t, reached)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,8 +11,8 @@ type MockAuthMiddleware struct {
 	mock.Mock
 }
 
-func (m *MockAuthMiddleware) GenerateToken(userID int, username string) (string, error) {
-	args := m.Called(userID, username)
+func (m *MockAuthMiddleware) GenerateToken(userID int, username string, duration time.Duration) (string, error) {
+	args := m.Called(userID, username, duration)
 	return args.String(0), args.Error(1)
 }
 
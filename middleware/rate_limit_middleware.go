@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter blocks clients that keep failing to log in or register. It
+// counts failed attempts per client IP and per submitted email over a sliding
+// window and answers 429 once either reaches the limit. A limit of zero
+// disables it
+type RateLimiter struct {
+	maxFailures int
+	window      time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	failures  map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewRateLimiter(maxFailures int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		now:         time.Now,
+		failures:    make(map[string][]time.Time),
+	}
+}
+
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	if l.maxFailures <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []string{"ip:" + clientIP(r)}
+		// Reading the form here leaves it parsed for the handler
+		if email := strings.ToLower(strings.TrimSpace(r.PostFormValue("email"))); email != "" {
+			keys = append(keys, "email:"+email)
+		}
+
+		if wait := l.retryAfter(keys); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if attemptFailed(recorder) {
+			l.recordFailure(keys)
+		}
+	})
+}
+
+// retryAfter returns how long until every key is below the limit again, or
+// zero when the request may go ahead
+func (l *RateLimiter) retryAfter(keys []string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for _, key := range keys {
+		attempts := l.prune(key, now)
+		if len(attempts) < l.maxFailures {
+			continue
+		}
+		// The key drops below the limit once enough of its oldest failures age out
+		if until := attempts[len(attempts)-l.maxFailures].Add(l.window).Sub(now); until > wait {
+			wait = until
+		}
+	}
+	return wait
+}
+
+func (l *RateLimiter) recordFailure(keys []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for _, key := range keys {
+		l.failures[key] = append(l.prune(key, now), now)
+	}
+
+	// Forget clients whose failures have all expired
+	if now.Sub(l.lastSweep) >= l.window {
+		for key := range l.failures {
+			l.prune(key, now)
+		}
+		l.lastSweep = now
+	}
+}
+
+// prune drops the failures of a key that fell out of the window. Callers must
+// hold l.mu
+func (l *RateLimiter) prune(key string, now time.Time) []time.Time {
+	attempts := l.failures[key]
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(attempts) && !attempts[i].After(cutoff) {
+		i++
+	}
+	attempts = attempts[i:]
+	if len(attempts) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = attempts
+	return attempts
+}
+
+// attemptFailed reports whether a login or register response was a failure:
+// an error status, or a redirect back to the form carrying an error message
+func attemptFailed(recorder *statusRecorder) bool {
+	if recorder.status >= http.StatusBadRequest {
+		return true
+	}
+	if recorder.status < http.StatusMultipleChoices {
+		return false
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	return err == nil && location.Query().Has("error")
+}
+
+// clientIP returns the address of the connecting client without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
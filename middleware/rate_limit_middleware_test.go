@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingLogin redirects back to the login form with an error, like the real
+// handler does for bad credentials
+var failingLogin = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/login?error=invalid_credentials", http.StatusSeeOther)
+})
+
+func loginRequest(remoteAddr, email string) *http.Request {
+	form := url.Values{"email": {email}, "password": {"wrong"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestRateLimiter(t *testing.T) {
+	const maxFailures = 3
+	limiter := NewRateLimiter(maxFailures, time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Handler(failingLogin)
+
+	for i := 0; i < maxFailures; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.1:5000", "victim@example.com"))
+		assert.Equal(t, http.StatusSeeOther, w.Code, "attempt %d", i+1)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("10.0.0.1:5001", "victim@example.com"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	t.Run("Same Email From Another IP", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.2:5000", "VICTIM@example.com"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Same IP With Another Email", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.1:5000", "other@example.com"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Unrelated Client", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.3:5000", "other@example.com"))
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+	})
+
+	t.Run("Window Slides", func(t *testing.T) {
+		now = now.Add(time.Minute + time.Second)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.1:5000", "victim@example.com"))
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+	})
+}
+
+func TestRateLimiterIgnoresSuccess(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("10.0.0.1:5000", "user@example.com"))
+		assert.Equal(t, http.StatusSeeOther, w.Code, "attempt %d", i+1)
+	}
+}
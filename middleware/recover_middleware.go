@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer turns a panic in a later handler into a JSON 500 like the rest of
+// the API's errors. The panic value and stack are only logged, tagged with the
+// request ID, so nothing internal reaches the client
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let net/http abort the response as the handler intended
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			log.Printf("[%s] panic serving %s %s: %v\n%s",
+				chimiddleware.GetReqID(r.Context()), r.Method, r.URL.Path, rec, debug.Stack())
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
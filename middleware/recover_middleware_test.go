@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverer(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var requestID string
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = chimiddleware.GetReqID(r.Context())
+		panic("database handle is nil")
+	})
+	handler := chimiddleware.RequestID(Recoverer(panicking))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "database handle is nil")
+	assert.NotContains(t, w.Body.String(), "goroutine")
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, map[string]string{"error": "Internal server error"}, body)
+
+	// The details stay in the server log, tagged with the request ID
+	assert.Contains(t, logs.String(), "database handle is nil")
+	require.NotEmpty(t, requestID)
+	assert.Contains(t, logs.String(), "["+requestID+"]")
+	assert.Contains(t, logs.String(), "goroutine")
+
+	t.Run("No Panic", func(t *testing.T) {
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		w := httptest.NewRecorder()
+		Recoverer(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// StringIDs rewrites integer ID fields in JSON responses as strings so that
+// JavaScript clients do not lose precision on IDs beyond 2^53. Fields named
+// "id", "ID" or ending in "_id" or "ID" are converted; other numbers are kept
+func StringIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &stringIDsWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		rw.flush()
+	})
+}
+
+// stringIDsWriter buffers JSON bodies for rewriting and passes any other
+// content type straight through
+type stringIDsWriter struct {
+	http.ResponseWriter
+	status      int
+	decided     bool
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (w *stringIDsWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.passthrough = !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *stringIDsWriter) WriteHeader(status int) {
+	w.status = status
+	w.decide()
+}
+
+func (w *stringIDsWriter) Write(p []byte) (int, error) {
+	w.decide()
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.buf.Write(p)
+}
+
+func (w *stringIDsWriter) flush() {
+	w.decide()
+	if w.passthrough {
+		return
+	}
+
+	body := w.buf.Bytes()
+	if rewritten, err := stringifyIDs(body); err == nil {
+		body = rewritten
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// stringifyIDs decodes a JSON document and re-encodes it with integer ID
+// fields converted to strings
+func stringifyIDs(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.NewEncoder(&out).Encode(convertIDs(document, false)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func convertIDs(value interface{}, isID bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = convertIDs(field, isIDField(key))
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertIDs(item, false)
+		}
+		return v
+	case json.Number:
+		if _, err := v.Int64(); isID && err == nil {
+			return v.String()
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isIDField(name string) bool {
+	return name == "id" || name == "ID" || strings.HasSuffix(name, "_id") || strings.HasSuffix(name, "ID")
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringIDs(t *testing.T) {
+	jsonHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":          int64(9007199254740993),
+			"post_id":     5,
+			"title":       "Big numbers",
+			"like_count":  3,
+			"comments":    []map[string]interface{}{{"ID": 7, "UserID": 8, "Content": "Hi"}},
+			"total_count": 1,
+		})
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		StringIDs(jsonHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "9007199254740993", body["id"])
+		assert.Equal(t, "5", body["post_id"])
+		assert.Equal(t, "Big numbers", body["title"])
+		assert.Equal(t, float64(3), body["like_count"])
+		assert.Equal(t, float64(1), body["total_count"])
+
+		comment := body["comments"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "7", comment["ID"])
+		assert.Equal(t, "8", comment["UserID"])
+	})
+
+	t.Run("Non JSON Passes Through", func(t *testing.T) {
+		textHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`{"id": 1}`))
+		})
+
+		w := httptest.NewRecorder()
+		StringIDs(textHandler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"id": 1}`, w.Body.String())
+	})
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"forum/middleware"
 
@@ -19,8 +20,8 @@ func NewMockAuthMiddleware(t interface{}) *MockAuthMiddleware {
 	}
 }
 
-func (m *MockAuthMiddleware) GenerateToken(userID int, username string) (string, error) {
-	args := m.Called(userID, username)
+func (m *MockAuthMiddleware) GenerateToken(userID int, username string, duration time.Duration) (string, error) {
+	args := m.Called(userID, username, duration)
 	return args.String(0), args.Error(1)
 }
 
@@ -46,3 +47,8 @@ func (m *MockAuthMiddleware) IsAuthenticated(r *http.Request) bool {
 	args := m.Called(r)
 	return args.Bool(0)
 }
+
+func (m *MockAuthMiddleware) RevokeSession(r *http.Request) error {
+	args := m.Called(r)
+	return args.Error(0)
+}
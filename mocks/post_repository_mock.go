@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"time"
+
 	"forum/models"
+	"forum/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -14,16 +17,24 @@ func (m *MockPostRepository) Create(post *models.Post, categoryIDs []int64) erro
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) GetByID(postID string) (*models.Post, error) {
-	args := m.Called(postID)
+func (m *MockPostRepository) GetByID(postID string, viewer repository.PostViewer) (*models.Post, error) {
+	args := m.Called(postID, viewer)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) ListPosts(page, limit int) ([]models.Post, int, error) {
-	args := m.Called(page, limit)
+func (m *MockPostRepository) GetBySlug(slug string, viewer repository.PostViewer) (*models.Post, error) {
+	args := m.Called(slug, viewer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListPosts(page, limit int, sort repository.PostSort, excludeUserID *int64) ([]models.Post, int, error) {
+	args := m.Called(page, limit, sort, excludeUserID)
 	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
 }
 
@@ -37,11 +48,75 @@ func (m *MockPostRepository) GetUserPosts(userID int64) ([]models.Post, error) {
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) ListUserPosts(userID int64, includePending bool, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(userID, includePending, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) CountUserPosts(userID int64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPostRepository) GetPostCounts(postID int64, viewer repository.PostViewer) (likes, dislikes, comments int, err error) {
+	args := m.Called(postID, viewer)
+	return args.Int(0), args.Int(1), args.Int(2), args.Error(3)
+}
+
 func (m *MockPostRepository) GetLikedPosts(userID int64) ([]models.Post, error) {
 	args := m.Called(userID)
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) GetCommentedPosts(userID int64, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(userID, page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) GetPostsWithoutEngagement(page, limit int) ([]models.Post, int, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) SearchPosts(query string, page, limit int) ([]models.Post, int, error) {
+	args := m.Called(query, page, limit)
+	return args.Get(0).([]models.Post), args.Int(1), args.Error(2)
+}
+
+func (m *MockPostRepository) FindByTitle(title string) ([]models.Post, error) {
+	args := m.Called(title)
+	return args.Get(0).([]models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetLatestPerCategory(perCategory int) (map[int64][]models.Post, error) {
+	args := m.Called(perCategory)
+	return args.Get(0).(map[int64][]models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetCategoryIDsForPosts(postIDs []int64) (map[int64][]int64, error) {
+	args := m.Called(postIDs)
+	return args.Get(0).(map[int64][]int64), args.Error(1)
+}
+
+func (m *MockPostRepository) ApproveBatch(ids []int64) ([]repository.ApprovalResult, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ApprovalResult), args.Error(1)
+}
+
+func (m *MockPostRepository) GetPostsUpdatedSince(since time.Time, viewer repository.PostViewer, page, limit int) ([]repository.PostChange, int, error) {
+	args := m.Called(since, viewer, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]repository.PostChange), args.Int(1), args.Error(2)
+}
+
 func (m *MockPostRepository) UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error {
 	args := m.Called(post, categoryIDs, userID)
 	return args.Error(0)
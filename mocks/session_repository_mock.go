@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"forum/models"
 	"forum/repository"
@@ -14,8 +16,8 @@ func NewMockSessionRepository() *MockSessionRepository {
 	return &MockSessionRepository{}
 }
 
-func (m *MockSessionRepository) CreateSession(user *models.User) error {
-	args := m.Called(user)
+func (m *MockSessionRepository) CreateSession(user *models.User, duration time.Duration) error {
+	args := m.Called(user, duration)
 	return args.Error(0)
 }
 
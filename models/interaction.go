@@ -20,10 +20,30 @@ func (it InteractionType) String() string {
 	}
 }
 
+// EntityType names the kind of content a user can like or dislike
+type EntityType string
+
+// Entity types that can be liked or disliked; the interactions table rejects
+// any other value
+const (
+	EntityTypePost    EntityType = "post"
+	EntityTypeComment EntityType = "comment"
+)
+
+// Valid reports whether t is one of the known entity types
+func (t EntityType) Valid() bool {
+	switch t {
+	case EntityTypePost, EntityTypeComment:
+		return true
+	default:
+		return false
+	}
+}
+
 type Interaction struct {
 	ID         int64           `json:"id" db:"id"`
 	UserID     int64           `json:"user_id" db:"user_id"`
-	EntityType string          `json:"entity_type" db:"entity_type"` // 'post' or 'comment'
+	EntityType EntityType      `json:"entity_type" db:"entity_type"`
 	EntityID   int64           `json:"entity_id" db:"entity_id"`
 	Type       InteractionType `json:"type" db:"type"`
 	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityTypeValid(t *testing.T) {
+	testCases := []struct {
+		entityType EntityType
+		expected   bool
+	}{
+		{EntityTypePost, true},
+		{EntityTypeComment, true},
+		{"", false},
+		{"Post", false},
+		{"user", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.entityType), func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.entityType.Valid())
+		})
+	}
+}
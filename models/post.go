@@ -3,16 +3,30 @@ package models
 import (
 	"fmt"
 	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxExcerptLength is the longest author-written excerpt accepted, in characters
+	MaxExcerptLength = 300
+	// PreviewLength is how much content a listing previews when a post has no excerpt
+	PreviewLength = 200
 )
 
 type Post struct {
 	ID         int64     `json:"id" db:"id"`
 	UserID     int64     `json:"user_id" db:"user_id"`
 	Title      string    `json:"title" db:"title"`
+	Slug       string    `json:"slug,omitempty" db:"slug"` // Set once from the title when the post is created
 	Content    string    `json:"content" db:"content"`
+	Excerpt    string    `json:"excerpt,omitempty" db:"excerpt"` // Optional author-written preview
+	Preview    string    `json:"preview,omitempty" db:"-"`       // Set by listings from PreviewText
+	Status     string    `json:"status,omitempty" db:"status"`   // Moderation state; empty means approved
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 	Categories []int64   `json:"categories" db:"categories"` // Category IDs
+	LikeCount    int     `json:"like_count" db:"like_count"`
+	DislikeCount int     `json:"dislike_count" db:"dislike_count"`
 	Username   string    `json:"username" db:"username"`     // Add this line
 	CategoryName string  `json:"category_name" db:"category_name"` // Add this line
 }
@@ -25,5 +39,20 @@ func (p *Post) Validate() error {
 	if p.Content == "" {
 		return fmt.Errorf("content is required")
 	}
+	if utf8.RuneCountInString(p.Excerpt) > MaxExcerptLength {
+		return fmt.Errorf("excerpt must be at most %d characters", MaxExcerptLength)
+	}
 	return nil
 }
+
+// PreviewText returns the author's excerpt when set, otherwise the content
+// truncated to PreviewLength characters
+func (p *Post) PreviewText() string {
+	if p.Excerpt != "" {
+		return p.Excerpt
+	}
+	if utf8.RuneCountInString(p.Content) <= PreviewLength {
+		return p.Content
+	}
+	return string([]rune(p.Content)[:PreviewLength]) + "..."
+}
@@ -6,6 +6,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Session lifetimes; a "remember me" login stays signed in for much longer
+const (
+	DefaultSessionDuration  = 24 * time.Hour
+	RememberSessionDuration = 30 * 24 * time.Hour
+)
+
 type Session struct {
 	ID        int64     `json:"id" db:"id"`
 	UserID    int64     `json:"user_id" db:"user_id"`
@@ -13,12 +19,16 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
 }
 
-// NewSession creates a new session with a UUID and expiration
-func NewSession(userID int64) *Session {
+// NewSession creates a new session with a UUID that expires after duration,
+// or after DefaultSessionDuration when duration is zero
+func NewSession(userID int64, duration time.Duration) *Session {
+	if duration <= 0 {
+		duration = DefaultSessionDuration
+	}
 	return &Session{
 		UserID:    userID,
 		UUID:      uuid.New().String(),
-		ExpiresAt: time.Now().Add(24 * time.Hour), // Session valid for 24 hours
+		ExpiresAt: time.Now().Add(duration),
 	}
 }
 
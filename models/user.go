@@ -48,10 +48,14 @@ func isValidEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// GenerateSessionToken creates a new session token and sets expiry
-func (u *User) GenerateSessionToken() {
+// GenerateSessionToken creates a new session token that expires after
+// duration, or after DefaultSessionDuration when duration is zero
+func (u *User) GenerateSessionToken(duration time.Duration) {
+	if duration <= 0 {
+		duration = DefaultSessionDuration
+	}
 	u.SessionToken = uuid.New().String()
-	u.SessionExpiry = time.Now().Add(24 * time.Hour) // Session valid for 24 hours
+	u.SessionExpiry = time.Now().Add(duration)
 	u.LastLogin = time.Now()
 }
 
@@ -0,0 +1,85 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Flag names an optional behavior that can be toggled per deployment
+type Flag string
+
+const (
+	// ModerationQueue holds new posts as pending until a moderator approves them
+	ModerationQueue Flag = "moderation_queue"
+	// CategoryAutoCreate lets authors create categories by naming them on a new post
+	CategoryAutoCreate Flag = "category_auto_create"
+)
+
+// Known lists every flag accepted in configuration
+var Known = []Flag{ModerationQueue, CategoryAutoCreate}
+
+// Flags is the set of features enabled for this deployment. A nil *Flags has
+// every feature disabled, so components work unchanged when none are injected
+type Flags struct {
+	enabled map[Flag]bool
+}
+
+// New enables exactly the given flags
+func New(enabled ...Flag) *Flags {
+	f := &Flags{enabled: make(map[Flag]bool, len(enabled))}
+	for _, flag := range enabled {
+		f.enabled[flag] = true
+	}
+	return f
+}
+
+// Parse enables the named flags, rejecting names that are not in Known
+func Parse(names []string) (*Flags, error) {
+	var enabled []Flag
+	for _, name := range names {
+		flag := Flag(strings.ToLower(strings.TrimSpace(name)))
+		if !isKnown(flag) {
+			return nil, fmt.Errorf("unknown feature flag %q", name)
+		}
+		enabled = append(enabled, flag)
+	}
+	return New(enabled...), nil
+}
+
+// Enabled reports whether the flag is switched on
+func (f *Flags) Enabled(flag Flag) bool {
+	return f != nil && f.enabled[flag]
+}
+
+// List returns the enabled flags in name order
+func (f *Flags) List() []Flag {
+	if f == nil {
+		return nil
+	}
+	flags := make([]Flag, 0, len(f.enabled))
+	for flag := range f.enabled {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i] < flags[j] })
+	return flags
+}
+
+// MarshalJSON encodes the enabled flags as a list of names
+func (f *Flags) MarshalJSON() ([]byte, error) {
+	flags := f.List()
+	if flags == nil {
+		flags = []Flag{}
+	}
+	return json.Marshal(flags)
+}
+
+func isKnown(flag Flag) bool {
+	for _, known := range Known {
+		if flag == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package features
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	flags, err := Parse([]string{" Moderation_Queue "})
+	require.NoError(t, err)
+	assert.True(t, flags.Enabled(ModerationQueue))
+
+	_, err = Parse([]string{"time_travel"})
+	assert.ErrorContains(t, err, `unknown feature flag "time_travel"`)
+
+	flags, err = Parse(nil)
+	require.NoError(t, err)
+	assert.False(t, flags.Enabled(ModerationQueue))
+}
+
+func TestNilFlagsAreDisabled(t *testing.T) {
+	var flags *Flags
+	assert.False(t, flags.Enabled(ModerationQueue))
+	assert.Empty(t, flags.List())
+}
+
+func TestMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(New(ModerationQueue))
+	require.NoError(t, err)
+	assert.JSONEq(t, `["moderation_queue"]`, string(encoded))
+
+	encoded, err = json.Marshal(New())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(encoded))
+}
@@ -0,0 +1,121 @@
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the per-subscriber queue length used when none is given
+const DefaultBufferSize = 16
+
+// Event is a message published to a topic
+type Event struct {
+	Topic string
+	Data  interface{}
+}
+
+// Subscription receives the events published to one topic
+type Subscription struct {
+	topic   string
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Events returns the channel delivering the subscription's events; it is
+// closed once the subscription is removed from the broker
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped reports how many events were discarded because the subscriber's
+// buffer was full
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Broker fans events out to live subscribers, such as WebSocket or SSE
+// connections. Publishing never blocks: a subscriber that falls behind has
+// events dropped rather than stalling everyone else
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+	bufferSize  int
+}
+
+// NewBroker creates a broker whose subscribers buffer up to bufferSize events
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Broker{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers a new subscription to the topic
+func (b *Broker) Subscribe(topic string) *Subscription {
+	sub := &Subscription{
+		topic:  topic,
+		events: make(chan Event, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[topic][sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes the subscription and closes its channel; calling it
+// more than once is safe
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subscribers[sub.topic]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.topic)
+	}
+	close(sub.events)
+}
+
+// Publish delivers data to every subscriber of the topic without blocking
+// and returns how many subscribers received it
+func (b *Broker) Publish(topic string, data interface{}) int {
+	event := Event{Topic: topic, Data: data}
+
+	// Sends happen under the read lock so Unsubscribe cannot close a
+	// channel mid-send
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	delivered := 0
+	for sub := range b.subscribers[topic] {
+		select {
+		case sub.events <- event:
+			delivered++
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+	return delivered
+}
+
+// SubscriberCount reports how many subscriptions the topic has
+func (b *Broker) SubscriberCount(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[topic])
+}
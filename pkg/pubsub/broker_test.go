@@ -0,0 +1,116 @@
+package pubsub
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerDelivery(t *testing.T) {
+	broker := NewBroker(2)
+	sub := broker.Subscribe("post:1")
+	other := broker.Subscribe("post:2")
+
+	assert.Equal(t, 1, broker.Publish("post:1", "first"))
+	event := <-sub.Events()
+	assert.Equal(t, Event{Topic: "post:1", Data: "first"}, event)
+	assert.Empty(t, other.Events())
+
+	t.Run("Slow Subscriber Drops", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			broker.Publish("post:1", i)
+		}
+		assert.Len(t, sub.Events(), 2)
+		assert.Equal(t, int64(3), sub.Dropped())
+	})
+
+	t.Run("Unsubscribe Closes Channel", func(t *testing.T) {
+		broker.Unsubscribe(sub)
+		broker.Unsubscribe(sub)
+
+		// Buffered events drain before the close is observed
+		<-sub.Events()
+		<-sub.Events()
+		_, open := <-sub.Events()
+		assert.False(t, open)
+		assert.Equal(t, 0, broker.Publish("post:1", "after"))
+		assert.Equal(t, 0, broker.SubscriberCount("post:1"))
+	})
+}
+
+func TestBrokerConcurrency(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	const (
+		topic       = "feed"
+		subscribers = 50
+		publishers  = 20
+		messages    = 200
+	)
+
+	broker := NewBroker(8)
+	var readers sync.WaitGroup
+	subs := make([]*Subscription, subscribers)
+	for i := range subs {
+		subs[i] = broker.Subscribe(topic)
+
+		// Every other subscriber never reads, so publishers must not wait on it
+		if i%2 == 1 {
+			continue
+		}
+		readers.Add(1)
+		go func(sub *Subscription) {
+			defer readers.Done()
+			for range sub.Events() {
+			}
+		}(subs[i])
+	}
+
+	var writers sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		writers.Add(1)
+		go func(p int) {
+			defer writers.Done()
+			for m := 0; m < messages; m++ {
+				broker.Publish(topic, p*messages+m)
+			}
+		}(p)
+	}
+
+	// Churn subscriptions while publishing is in flight
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for i := 0; i < messages; i++ {
+			broker.Unsubscribe(broker.Subscribe(topic))
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		writers.Wait()
+		for _, sub := range subs {
+			broker.Unsubscribe(sub)
+		}
+		readers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishers or subscribers deadlocked")
+	}
+
+	assert.Equal(t, 0, broker.SubscriberCount(topic))
+
+	// All reader goroutines exit once their subscriptions are closed
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "goroutines leaked")
+}
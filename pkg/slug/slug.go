@@ -0,0 +1,49 @@
+package slug
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Fallback is the slug of a title without any letters or digits
+const Fallback = "post"
+
+// Make turns a title into a URL name: lowercase letters and digits, with
+// every other run of characters collapsed into a single hyphen
+func Make(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	if b.Len() == 0 {
+		return Fallback
+	}
+	return b.String()
+}
+
+// Unique returns base, or base with the lowest numeric suffix from 2 up
+// that no other post uses yet
+func Unique(tx *sql.Tx, base string) (string, error) {
+	slug := base
+	for n := 2; ; n++ {
+		var taken bool
+		if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM posts WHERE slug = ?)`, slug).Scan(&taken); err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
@@ -0,0 +1,20 @@
+package slug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":            "hello-world",
+		"  Go 1.21: what's new?": "go-1-21-what-s-new",
+		"Ünïcode Títle":          "ünïcode-títle",
+		"???":                    Fallback,
+		"":                       Fallback,
+	}
+	for title, want := range tests {
+		assert.Equal(t, want, Make(title), title)
+	}
+}
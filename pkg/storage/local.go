@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps objects as files under a root directory
+type LocalStorage struct {
+	root    string
+	baseURL string
+	signer  *URLSigner
+}
+
+// NewLocalStorage creates the root directory if needed and returns a backend
+// serving object URLs under baseURL, signed by signer for private objects
+func NewLocalStorage(root, baseURL string, signer *URLSigner) (*LocalStorage, error) {
+	if root == "" {
+		return nil, errors.New("storage: local root directory cannot be empty")
+	}
+	if signer == nil {
+		return nil, errors.New("storage: URL signer cannot be nil")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: failed to create root directory: %w", err)
+	}
+
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		signer:  signer,
+	}, nil
+}
+
+// Put writes the object to a temporary file first so readers never observe
+// a partially written upload
+func (s *LocalStorage) Put(key string, r io.Reader) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// URL returns the public path of the object without checking that it exists
+func (s *LocalStorage) URL(key string) string {
+	segments := strings.Split(path.Clean("/"+key), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.baseURL + strings.Join(segments, "/")
+}
+
+// SignedURL returns the object URL with a signature that expires after ttl,
+// for attachments that must not be guessable
+func (s *LocalStorage) SignedURL(key string, ttl time.Duration) string {
+	return s.signer.Sign(s.URL(key), ttl)
+}
+
+// path maps a slash separated key onto the filesystem, rejecting keys that
+// would resolve outside the root directory
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Ensure LocalStorage implements the interface
+var _ Storage = &LocalStorage{}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/uploads/", NewURLSigner("test-signing-key"))
+	require.NoError(t, err)
+
+	key := "posts/42/diagram.png"
+	require.NoError(t, store.Put(key, strings.NewReader("first version")))
+
+	t.Run("Get Returns Content", func(t *testing.T) {
+		reader, err := store.Get(key)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "first version", string(content))
+	})
+
+	t.Run("Put Overwrites", func(t *testing.T) {
+		require.NoError(t, store.Put(key, strings.NewReader("second version")))
+
+		reader, err := store.Get(key)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "second version", string(content))
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		assert.Equal(t, "/uploads/posts/42/diagram.png", store.URL(key))
+		assert.Equal(t, "/uploads/my%20file.txt", store.URL("my file.txt"))
+	})
+
+	t.Run("Delete Removes Object", func(t *testing.T) {
+		require.NoError(t, store.Delete(key))
+
+		_, err := store.Get(key)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, store.Delete(key), ErrNotFound)
+	})
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/uploads", NewURLSigner("test-signing-key"))
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape.txt", "posts/../../escape.txt", "posts//double"} {
+		t.Run(key, func(t *testing.T) {
+			assert.ErrorIs(t, store.Put(key, strings.NewReader("x")), ErrInvalidKey)
+			_, err := store.Get(key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+			assert.ErrorIs(t, store.Delete(key), ErrInvalidKey)
+		})
+	}
+}
+
+func TestNewSelectsBackend(t *testing.T) {
+	store, err := New(BackendLocal, t.TempDir(), "/uploads", NewURLSigner("test-signing-key"))
+	require.NoError(t, err)
+	assert.IsType(t, &LocalStorage{}, store)
+
+	_, err = New("s3", t.TempDir(), "/uploads", NewURLSigner("test-signing-key"))
+	assert.Error(t, err)
+}
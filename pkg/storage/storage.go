@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// BackendLocal stores uploads on the local filesystem
+const BackendLocal = "local"
+
+var (
+	// ErrNotFound is returned when no object exists under a key
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for empty keys or keys escaping the storage root
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage abstracts where uploaded files are kept so that backends such as
+// S3 can be added without touching the handlers
+type Storage interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+	SignedURL(key string, ttl time.Duration) string
+}
+
+// New returns the storage backend selected by name
+func New(backend, localDir, baseURL string, signer *URLSigner) (Storage, error) {
+	switch backend {
+	case BackendLocal:
+		return NewLocalStorage(localDir, baseURL, signer)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// WorkerManager runs background tasks (session cleanup, scheduled publishing,
+// notification batching) under one shared context, so a single Shutdown call
+// stops all of them and waits for them to drain
+type WorkerManager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorkerManager creates a manager whose workers are cancelled when parent
+// is done or Shutdown is called
+func NewWorkerManager(parent context.Context) *WorkerManager {
+	ctx, cancel := context.WithCancel(parent)
+	return &WorkerManager{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context shared by all registered workers
+func (m *WorkerManager) Context() context.Context {
+	return m.ctx
+}
+
+// Go starts fn in its own goroutine; fn must return once ctx is done
+func (m *WorkerManager) Go(name string, fn func(ctx context.Context)) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Worker %s panicked: %v", name, r)
+			}
+		}()
+		fn(m.ctx)
+	}()
+}
+
+// Every runs fn once per interval until the shared context is cancelled. A
+// tick that panics is logged and the next tick runs as scheduled
+func (m *WorkerManager) Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	m.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runTick(ctx, name, fn)
+			}
+		}
+	})
+}
+
+// runTick runs one tick of a periodic worker, recovering from a panic in it
+func runTick(ctx context.Context, name string, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker %s panicked during a tick: %v", name, r)
+		}
+	}()
+	fn(ctx)
+}
+
+// Shutdown cancels every worker and waits for them to return, giving up
+// when ctx expires first
+func (m *WorkerManager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkersStopOnCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	manager := NewWorkerManager(parent)
+
+	var ticks atomic.Int64
+	var stopped atomic.Int64
+	manager.Every("ticker", 5*time.Millisecond, func(ctx context.Context) {
+		ticks.Add(1)
+	})
+	manager.Go("loop", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped.Add(1)
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	ctx, done := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer done()
+	require.NoError(t, manager.Shutdown(ctx))
+
+	assert.Positive(t, ticks.Load())
+	assert.Equal(t, int64(1), stopped.Load())
+
+	// No further ticks once drained
+	after := ticks.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, after, ticks.Load())
+}
+
+func TestShutdownTimesOut(t *testing.T) {
+	manager := NewWorkerManager(context.Background())
+	release := make(chan struct{})
+	manager.Go("stuck", func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, done := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer done()
+	assert.ErrorIs(t, manager.Shutdown(ctx), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, manager.Shutdown(context.Background()))
+}
+
+func TestWorkerPanicIsContained(t *testing.T) {
+	manager := NewWorkerManager(context.Background())
+	manager.Go("panics", func(ctx context.Context) {
+		panic("boom")
+	})
+
+	ctx, done := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer done()
+	assert.NoError(t, manager.Shutdown(ctx))
+}
+
+func TestEveryRecoversFromPanickingTick(t *testing.T) {
+	manager := NewWorkerManager(context.Background())
+
+	var ticks atomic.Int64
+	manager.Every("flaky", 5*time.Millisecond, func(ctx context.Context) {
+		if ticks.Add(1) == 1 {
+			panic("boom")
+		}
+	})
+
+	assert.Eventually(t, func() bool { return ticks.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	ctx, done := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer done()
+	assert.NoError(t, manager.Shutdown(ctx))
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"forum/models"
 )
@@ -10,19 +13,76 @@ import (
 type CategoryRepositoryInterface interface {
 	Create(category *models.Category) error
 	GetByID(categoryID int64) (*models.Category, error)
+	CategoriesExist(ids []int64) (missing []int64, err error)
+	GetCategoriesByIDs(ids []int64) ([]models.Category, error)
 	ListCategories() ([]models.Category, error)
+	ListCategoriesWithCounts() ([]CategoryWithCount, error)
 	Update(category *models.Category) error
 	Delete(categoryID int64) error
+	MergeCategories(sourceID, targetID int64, dryRun bool) (*MergeResult, error)
+	GetCategoryUsageFrequency() ([]CategoryCount, error)
+	GetTrendingCategories(window time.Duration, limit int) ([]TrendingCategory, error)
+	ResolveCategoryNames(names []string, create bool) ([]int64, error)
 }
 
+// CategoryCount is how many posts are tagged with a category
+type CategoryCount struct {
+	CategoryID int64  `json:"category_id"`
+	Name       string `json:"name"`
+	Count      int    `json:"count"`
+}
+
+// CategoryWithCount is a category together with the number of posts tagged
+// with it
+type CategoryWithCount struct {
+	models.Category
+	PostCount int `json:"post_count"`
+}
+
+// TrendingCategory is a category ranked by its recent activity: new posts plus
+// likes and dislikes on its posts
+type TrendingCategory struct {
+	CategoryID   int64  `json:"category_id"`
+	Name         string `json:"name"`
+	Posts        int    `json:"posts"`
+	Interactions int    `json:"interactions"`
+	Activity     int    `json:"activity"`
+}
+
+// MergeResult reports the impact of a category merge
+type MergeResult struct {
+	PostsMoved        int64 `json:"posts_moved"`
+	DuplicatesDropped int64 `json:"duplicates_dropped"`
+	DryRun            bool  `json:"dry_run"`
+}
+
+var (
+	// ErrCategoryNotFound is returned when a category lookup matches no rows
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrMergeIntoSelf is returned when a category is merged into itself
+	ErrMergeIntoSelf = errors.New("cannot merge a category into itself")
+	// ErrDuplicateCategory is returned when a category name is already taken
+	ErrDuplicateCategory = errors.New("category name already exists")
+)
+
 type CategoryRepository struct {
-	conn *sql.DB
+	conn   *sql.DB
+	reader *sql.DB
 }
 
 var _ CategoryRepositoryInterface = &CategoryRepository{}
 
 func NewCategoryRepository(conn *sql.DB) *CategoryRepository {
-	return &CategoryRepository{conn: conn}
+	return &CategoryRepository{conn: conn, reader: conn}
+}
+
+// WithReplica sends the repository's queries to a read-only replica; writes
+// stay on the primary. A nil replica keeps reads on the primary
+func (r *CategoryRepository) WithReplica(replica *sql.DB) *CategoryRepository {
+	if replica != nil {
+		r.reader = replica
+	}
+	return r
 }
 
 // Create a new category
@@ -35,7 +95,7 @@ func (r *CategoryRepository) Create(category *models.Category) error {
 	query := `INSERT INTO categories (name, description) VALUES (?, ?)`
 	result, err := r.conn.Exec(query, category.Name, category.Description)
 	if err != nil {
-		return err
+		return categoryWriteError(err)
 	}
 
 	id, err := result.LastInsertId()
@@ -50,21 +110,95 @@ func (r *CategoryRepository) Create(category *models.Category) error {
 func (r *CategoryRepository) GetByID(categoryID int64) (*models.Category, error) {
 	query := `SELECT id, name, description FROM categories WHERE id = ?`
 	category := &models.Category{}
-	err := r.conn.QueryRow(query, categoryID).Scan(
+	err := r.reader.QueryRow(query, categoryID).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Description,
 	)
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return category, nil
 }
 
+// CategoriesExist checks many category IDs in one query and returns those
+// that match no category, in the order given. It reads from the primary
+// because callers use it just before writing the IDs
+func (r *CategoryRepository) CategoriesExist(ids []int64) (missing []int64, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.conn.Query(`SELECT id FROM categories WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]bool, len(ids))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// GetCategoriesByIDs loads the given categories in one query, ordered by
+// name; IDs that match no category are skipped
+func (r *CategoryRepository) GetCategoriesByIDs(ids []int64) ([]models.Category, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	query := `SELECT id, name, COALESCE(description, '') FROM categories WHERE id IN (` + placeholders + `) ORDER BY name`
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
 // List all categories
 func (r *CategoryRepository) ListCategories() ([]models.Category, error) {
 	query := `SELECT id, name, description FROM categories ORDER BY name`
-	rows, err := r.conn.Query(query)
+	rows, err := r.reader.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +221,33 @@ func (r *CategoryRepository) ListCategories() ([]models.Category, error) {
 	return categories, nil
 }
 
+// ListCategoriesWithCounts lists all categories by name with how many
+// approved posts use each; unused categories are included with a count of zero
+func (r *CategoryRepository) ListCategoriesWithCounts() ([]CategoryWithCount, error) {
+	query := `SELECT c.id, c.name, COALESCE(c.description, ''), COUNT(p.id)
+			  FROM categories c
+			  LEFT JOIN post_categories pc ON pc.category_id = c.id
+			  LEFT JOIN posts p ON p.id = pc.post_id AND p.status = ?
+			  GROUP BY c.id, c.name, c.description
+			  ORDER BY c.name`
+	rows, err := r.reader.Query(query, PostStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []CategoryWithCount
+	for rows.Next() {
+		var category CategoryWithCount
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.PostCount); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
 // Update an existing category
 func (r *CategoryRepository) Update(category *models.Category) error {
 	if category.ID == 0 {
@@ -95,6 +256,18 @@ func (r *CategoryRepository) Update(category *models.Category) error {
 
 	query := `UPDATE categories SET name = ?, description = ? WHERE id = ?`
 	_, err := r.conn.Exec(query, category.Name, category.Description, category.ID)
+	if err != nil {
+		return categoryWriteError(err)
+	}
+	return nil
+}
+
+// categoryWriteError maps a violation of the unique name constraint to
+// ErrDuplicateCategory
+func categoryWriteError(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicateCategory
+	}
 	return err
 }
 
@@ -117,3 +290,181 @@ func (r *CategoryRepository) Delete(categoryID int64) error {
 	_, err = r.conn.Exec(query, categoryID)
 	return err
 }
+
+// MergeCategories moves every post from the source category onto the target,
+// skipping posts already tagged with the target, then deletes the source.
+// All steps run in one transaction, which is rolled back when dryRun is set
+// so the returned counts describe the merge without applying it
+func (r *CategoryRepository) MergeCategories(sourceID, targetID int64, dryRun bool) (*MergeResult, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	var result *MergeResult
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		// Both categories must exist
+		var found int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM categories WHERE id IN (?, ?)`, sourceID, targetID).Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found != 2 {
+			return ErrCategoryNotFound
+		}
+
+		// Re-tag posts onto the target, ignoring posts that already have it
+		inserted, err := tx.Exec(`INSERT OR IGNORE INTO post_categories (post_id, category_id)
+						  SELECT post_id, ? FROM post_categories WHERE category_id = ?`, targetID, sourceID)
+		if err != nil {
+			return err
+		}
+		moved, err := inserted.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		removed, err := tx.Exec(`DELETE FROM post_categories WHERE category_id = ?`, sourceID)
+		if err != nil {
+			return err
+		}
+		unlinked, err := removed.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM categories WHERE id = ?`, sourceID)
+		if err != nil {
+			return err
+		}
+
+		result = &MergeResult{
+			PostsMoved:        moved,
+			DuplicatesDropped: unlinked - moved,
+			DryRun:            dryRun,
+		}
+		if dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetCategoryUsageFrequency counts the approved posts tagged with each
+// category, most used first; unused categories are included with a count of zero
+func (r *CategoryRepository) GetCategoryUsageFrequency() ([]CategoryCount, error) {
+	query := `SELECT c.id, c.name, COUNT(p.id) AS usage
+			  FROM categories c
+			  LEFT JOIN post_categories pc ON pc.category_id = c.id
+			  LEFT JOIN posts p ON p.id = pc.post_id AND p.status = ?
+			  GROUP BY c.id, c.name
+			  ORDER BY usage DESC, c.name`
+	rows, err := r.reader.Query(query, PostStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []CategoryCount
+	for rows.Next() {
+		var count CategoryCount
+		if err := rows.Scan(&count.CategoryID, &count.Name, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// GetTrendingCategories ranks categories by the posts created and the
+// reactions left on their posts within window, most active first. Categories
+// with no activity in the window are left out
+func (r *CategoryRepository) GetTrendingCategories(window time.Duration, limit int) ([]TrendingCategory, error) {
+	if window <= 0 || limit <= 0 {
+		return nil, errors.New("invalid input parameters")
+	}
+
+	query := `SELECT id, name, posts, interactions, posts + interactions AS activity
+			  FROM (
+				  SELECT c.id, c.name,
+					  (SELECT COUNT(*) FROM post_categories pc
+					   JOIN posts p ON p.id = pc.post_id
+					   WHERE pc.category_id = c.id AND p.status = ? AND p.created_at >= ?) AS posts,
+					  (SELECT COUNT(*) FROM post_categories pc
+					   JOIN posts p ON p.id = pc.post_id
+					   JOIN interactions i ON i.entity_type = 'post' AND i.entity_id = p.id
+					   WHERE pc.category_id = c.id AND p.status = ? AND i.created_at >= ?) AS interactions
+				  FROM categories c
+			  )
+			  WHERE posts + interactions > 0
+			  ORDER BY activity DESC, name
+			  LIMIT ?`
+	since := time.Now().Add(-window)
+	rows, err := r.reader.Query(query, PostStatusApproved, since, PostStatusApproved, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []TrendingCategory
+	for rows.Next() {
+		var category TrendingCategory
+		if err := rows.Scan(&category.CategoryID, &category.Name, &category.Posts, &category.Interactions, &category.Activity); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// ResolveCategoryNames maps category names to IDs, matching existing
+// categories case-insensitively and ignoring repeated names. Missing
+// categories are created when create is set; otherwise the first one is
+// reported as ErrCategoryNotFound
+func (r *CategoryRepository) ResolveCategoryNames(names []string, create bool) ([]int64, error) {
+	var ids []int64
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		seenNames := make(map[string]bool)
+		seenIDs := make(map[int64]bool)
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			key := strings.ToLower(name)
+			if name == "" || seenNames[key] {
+				continue
+			}
+			seenNames[key] = true
+
+			var id int64
+			err := tx.QueryRow(`SELECT id FROM categories WHERE name = ? COLLATE NOCASE ORDER BY id LIMIT 1`, name).Scan(&id)
+			switch {
+			case err == sql.ErrNoRows && create:
+				result, err := tx.Exec(`INSERT INTO categories (name) VALUES (?)`, name)
+				if err != nil {
+					return categoryWriteError(err)
+				}
+				if id, err = result.LastInsertId(); err != nil {
+					return err
+				}
+			case err == sql.ErrNoRows:
+				return fmt.Errorf("%w: %q", ErrCategoryNotFound, name)
+			case err != nil:
+				return err
+			}
+
+			if !seenIDs[id] {
+				seenIDs[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -2,9 +2,311 @@ package repository
 
 import (
 	"testing"
+	"time"
+
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCategoryRepository(t *testing.T) {
 	// Placeholder test
 	t.Log("Category repository tests")
-}
\ No newline at end of file
+}
+
+func TestCategoryRepository_DuplicateName(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	repo := NewCategoryRepository(db)
+	golang := &models.Category{Name: "Go"}
+	require.NoError(t, repo.Create(golang))
+	rust := &models.Category{Name: "Rust"}
+	require.NoError(t, repo.Create(rust))
+
+	assert.ErrorIs(t, repo.Create(&models.Category{Name: "Go"}), ErrDuplicateCategory)
+
+	rust.Name = "Go"
+	assert.ErrorIs(t, repo.Update(rust), ErrDuplicateCategory)
+}
+
+func TestCategoryRepository_MergeCategories(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	source, target := categoryIDs[0], categoryIDs[1]
+
+	postRepo := NewPostRepository(db)
+	onlySource := &models.Post{UserID: userID, Title: "Only source", Content: "Content"}
+	require.NoError(t, postRepo.Create(onlySource, []int64{source}))
+	both := &models.Post{UserID: userID, Title: "Both", Content: "Content"}
+	require.NoError(t, postRepo.Create(both, []int64{source, target}))
+	onlyTarget := &models.Post{UserID: userID, Title: "Only target", Content: "Content"}
+	require.NoError(t, postRepo.Create(onlyTarget, []int64{target}))
+
+	repo := NewCategoryRepository(db)
+
+	t.Run("Into Self", func(t *testing.T) {
+		_, err := repo.MergeCategories(source, source, false)
+		assert.ErrorIs(t, err, ErrMergeIntoSelf)
+	})
+
+	t.Run("Missing Target", func(t *testing.T) {
+		_, err := repo.MergeCategories(source, target+100, false)
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+	})
+
+	result, err := repo.MergeCategories(source, target, false)
+	require.NoError(t, err)
+	assert.Equal(t, &MergeResult{PostsMoved: 1, DuplicatesDropped: 1}, result)
+
+	rows, err := db.Query(`SELECT post_id FROM post_categories WHERE category_id = ? ORDER BY post_id`, target)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var postIDs []int64
+	for rows.Next() {
+		var postID int64
+		require.NoError(t, rows.Scan(&postID))
+		postIDs = append(postIDs, postID)
+	}
+	assert.Equal(t, []int64{onlySource.ID, both.ID, onlyTarget.ID}, postIDs)
+
+	var sourceLinks, sourceRows int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM post_categories WHERE category_id = ?`, source).Scan(&sourceLinks))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM categories WHERE id = ?`, source).Scan(&sourceRows))
+	assert.Zero(t, sourceLinks)
+	assert.Zero(t, sourceRows)
+}
+
+func TestCategoryRepository_MergeCategoriesDryRun(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	source, target := categoryIDs[0], categoryIDs[1]
+
+	postRepo := NewPostRepository(db)
+	for _, categories := range [][]int64{{source}, {source}, {source, target}} {
+		post := &models.Post{UserID: userID, Title: "Post", Content: "Content"}
+		require.NoError(t, postRepo.Create(post, categories))
+	}
+
+	countLinks := func(categoryID int64) int {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM post_categories WHERE category_id = ?`, categoryID).Scan(&count))
+		return count
+	}
+
+	repo := NewCategoryRepository(db)
+	result, err := repo.MergeCategories(source, target, true)
+	require.NoError(t, err)
+	assert.Equal(t, &MergeResult{PostsMoved: 2, DuplicatesDropped: 1, DryRun: true}, result)
+
+	// Nothing was applied
+	assert.Equal(t, 3, countLinks(source))
+	assert.Equal(t, 1, countLinks(target))
+	var sourceRows int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM categories WHERE id = ?`, source).Scan(&sourceRows))
+	assert.Equal(t, 1, sourceRows)
+}
+
+func TestCategoryRepository_GetCategoryUsageFrequency(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	tech, sports, music := categoryIDs[0], categoryIDs[1], categoryIDs[2]
+
+	postRepo := NewPostRepository(db)
+	for _, categories := range [][]int64{{sports}, {sports, tech}, {sports}, {tech}} {
+		post := &models.Post{UserID: userID, Title: "Post", Content: "Content"}
+		require.NoError(t, postRepo.Create(post, categories))
+	}
+	// Pending posts would otherwise put Technology ahead and Music above zero
+	for i := 0; i < 2; i++ {
+		pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+		require.NoError(t, postRepo.Create(pending, []int64{tech, music}))
+	}
+
+	counts, err := NewCategoryRepository(db).GetCategoryUsageFrequency()
+	require.NoError(t, err)
+	assert.Equal(t, []CategoryCount{
+		{CategoryID: sports, Name: "Sports", Count: 3},
+		{CategoryID: tech, Name: "Technology", Count: 2},
+		{CategoryID: music, Name: "Music", Count: 0},
+	}, counts)
+}
+
+func TestCategoryRepository_CategoriesExist(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	categoryIDs := setupTestCategories(t, db)
+	repo := NewCategoryRepository(db)
+
+	missing, err := repo.CategoriesExist([]int64{categoryIDs[0], 404, categoryIDs[2], 405})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{404, 405}, missing)
+
+	missing, err = repo.CategoriesExist(categoryIDs)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	missing, err = repo.CategoriesExist(nil)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestCategoryRepository_GetCategoriesByIDs(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	categoryIDs := setupTestCategories(t, db)
+	repo := NewCategoryRepository(db)
+
+	categories, err := repo.GetCategoriesByIDs([]int64{categoryIDs[2], 404, categoryIDs[0]})
+	require.NoError(t, err)
+	var ids []int64
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	assert.ElementsMatch(t, []int64{categoryIDs[0], categoryIDs[2]}, ids)
+
+	categories, err = repo.GetCategoriesByIDs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+}
+
+func TestCategoryRepository_ListCategoriesWithCounts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	tech, sports, music := categoryIDs[0], categoryIDs[1], categoryIDs[2]
+
+	postRepo := NewPostRepository(db)
+	for _, categories := range [][]int64{{tech}, {tech, sports}, {tech}} {
+		post := &models.Post{UserID: userID, Title: "Post", Content: "Content"}
+		require.NoError(t, postRepo.Create(post, categories))
+	}
+	// Posts waiting for moderation are not public, so they are not counted
+	pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+	require.NoError(t, postRepo.Create(pending, []int64{sports, music}))
+
+	categories, err := NewCategoryRepository(db).ListCategoriesWithCounts()
+	require.NoError(t, err)
+	counts := make(map[int64]int)
+	var names []string
+	for _, category := range categories {
+		counts[category.ID] = category.PostCount
+		names = append(names, category.Name)
+	}
+	assert.Equal(t, []string{"Music", "Sports", "Technology"}, names)
+	assert.Equal(t, map[int64]int{tech: 3, sports: 1, music: 0}, counts)
+}
+
+func TestCategoryRepository_GetTrendingCategories(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	tech, sports, music := categoryIDs[0], categoryIDs[1], categoryIDs[2]
+
+	postRepo := NewPostRepository(db)
+	newPost := func(categories ...int64) int64 {
+		post := &models.Post{UserID: userID, Title: "Post", Content: "Content"}
+		require.NoError(t, postRepo.Create(post, categories))
+		return post.ID
+	}
+
+	// Sports had the busiest history, but all of it is old
+	for i := 0; i < 4; i++ {
+		newPost(sports)
+	}
+	_, err := db.Exec(`UPDATE posts SET created_at = ?`, time.Now().Add(-30*24*time.Hour))
+	require.NoError(t, err)
+
+	// Recently, technology gets a post with two reactions and music one post
+	techPost := newPost(tech)
+	newPost(music)
+
+	interactions := NewInteractionRepository(db)
+	require.NoError(t, interactions.AddInteraction(userID, techPost, models.EntityTypePost, models.Like))
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "other", "other@example.com", "hashedpassword")
+	require.NoError(t, err)
+	otherID, err := result.LastInsertId()
+	require.NoError(t, err)
+	require.NoError(t, interactions.AddInteraction(otherID, techPost, models.EntityTypePost, models.Dislike))
+
+	repo := NewCategoryRepository(db)
+
+	t.Run("Recent Window", func(t *testing.T) {
+		trending, err := repo.GetTrendingCategories(7*24*time.Hour, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []TrendingCategory{
+			{CategoryID: tech, Name: "Technology", Posts: 1, Interactions: 2, Activity: 3},
+			{CategoryID: music, Name: "Music", Posts: 1, Interactions: 0, Activity: 1},
+		}, trending)
+	})
+
+	t.Run("Wider Window", func(t *testing.T) {
+		trending, err := repo.GetTrendingCategories(60*24*time.Hour, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []TrendingCategory{
+			{CategoryID: sports, Name: "Sports", Posts: 4, Interactions: 0, Activity: 4},
+			{CategoryID: tech, Name: "Technology", Posts: 1, Interactions: 2, Activity: 3},
+			{CategoryID: music, Name: "Music", Posts: 1, Interactions: 0, Activity: 1},
+		}, trending)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		trending, err := repo.GetTrendingCategories(7*24*time.Hour, 1)
+		require.NoError(t, err)
+		require.Len(t, trending, 1)
+		assert.Equal(t, tech, trending[0].CategoryID)
+	})
+}
+
+func TestCategoryRepository_ResolveCategoryNames(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	categoryIDs := setupTestCategories(t, db)
+	tech, sports := categoryIDs[0], categoryIDs[1]
+	repo := NewCategoryRepository(db)
+
+	t.Run("Missing Without Create", func(t *testing.T) {
+		ids, err := repo.ResolveCategoryNames([]string{"technology", "Gardening"}, false)
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+		assert.Nil(t, ids)
+
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM categories WHERE name = 'Gardening'`).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("Mixed Existing And New", func(t *testing.T) {
+		ids, err := repo.ResolveCategoryNames([]string{" technology ", "Gardening", "SPORTS", "gardening", "Technology"}, true)
+		require.NoError(t, err)
+		require.Len(t, ids, 3)
+		assert.Equal(t, tech, ids[0])
+		assert.Equal(t, sports, ids[2])
+
+		var gardening int64
+		require.NoError(t, db.QueryRow(`SELECT id FROM categories WHERE name = 'Gardening'`).Scan(&gardening))
+		assert.Equal(t, gardening, ids[1])
+
+		// Resolving again reuses the category created above
+		again, err := repo.ResolveCategoryNames([]string{"GARDENING"}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{gardening}, again)
+	})
+}
@@ -2,36 +2,124 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
+
+	"forum/models"
 )
 
+// ErrCommentNotFound is returned when a comment lookup matches no rows
+var ErrCommentNotFound = errors.New("comment not found")
+
+// ErrParentCommentNotFound is returned when a reply targets a comment that is
+// missing or belongs to a different post
+var ErrParentCommentNotFound = errors.New("parent comment not found on this post")
+
+// CommentSort selects the ordering used when paging through a thread
+type CommentSort string
+
+const (
+	CommentSortNew CommentSort = "new"
+	CommentSortOld CommentSort = "old"
+	CommentSortTop CommentSort = "top"
+)
+
+// commentSortOrder maps each supported CommentSort to its ORDER BY clause;
+// top ranks comments by the likes recorded in interactions
+var commentSortOrder = map[CommentSort]string{
+	CommentSortNew: "c.created_at DESC, c.id DESC",
+	CommentSortOld: "c.created_at ASC, c.id ASC",
+	CommentSortTop: fmt.Sprintf(`(SELECT COUNT(*) FROM interactions i
+		WHERE i.entity_type = 'comment' AND i.entity_id = c.id AND i.type = %d) DESC,
+		c.created_at DESC, c.id DESC`, models.Like),
+}
+
+// ParseCommentSort validates a user supplied comment sort name
+func ParseCommentSort(value string) (CommentSort, error) {
+	sort := CommentSort(value)
+	if _, ok := commentSortOrder[sort]; !ok {
+		return "", fmt.Errorf("unknown comment sort %q", value)
+	}
+	return sort, nil
+}
+
+// DeletedCommentContent replaces the content of soft-deleted comments in responses
+const DeletedCommentContent = "[deleted]"
+
 type Comment struct {
 	ID        int64
 	PostID    int64
+	ParentID  *int64
 	UserID    int64
+	Username  string
+	PostTitle string
 	Content   string
+	Deleted   bool
 	CreatedAt time.Time
 }
 
+// tombstone marks a soft-deleted comment and, unless the caller may see deleted
+// content, hides its text while keeping its position in the thread so replies
+// retain their context
+func (c *Comment) tombstone(deletedAt sql.NullTime, includeDeleted bool) {
+	if deletedAt.Valid {
+		c.Deleted = true
+		if !includeDeleted {
+			c.Content = DeletedCommentContent
+		}
+	}
+}
+
 // CommentRepositoryInterface defines the methods that a comment repository must implement
 type CommentRepositoryInterface interface {
 	Create(comment *Comment) error
-	GetByPostID(postID int64) ([]Comment, error)
+	GetByPostID(postID int64, includeDeleted bool) ([]Comment, error)
+	GetByPostIDPaginated(postID int64, page, limit int, sort CommentSort, includeDeleted bool) ([]Comment, int, error)
+	GetByID(commentID int64, includeDeleted bool) (*Comment, error)
+	UpdateComment(commentID, userID int64, content string) error
+	DeleteComment(commentID, userID int64) error
+	GetParticipantIDs(postID int64) ([]int64, error)
+	GetRecentComments(limit int) ([]Comment, error)
+	CountUserComments(userID int64) (int, error)
+	GetUserComments(userID int64, page, limit int) ([]Comment, int, error)
 }
 
 type CommentRepository struct {
-	conn *sql.DB
+	conn   *sql.DB
+	reader *sql.DB
 }
 
 func NewCommentRepository(conn *sql.DB) *CommentRepository {
-	return &CommentRepository{conn: conn}
+	return &CommentRepository{conn: conn, reader: conn}
+}
+
+// WithReplica sends the repository's queries to a read-only replica; writes
+// stay on the primary. A nil replica keeps reads on the primary
+func (r *CommentRepository) WithReplica(replica *sql.DB) *CommentRepository {
+	if replica != nil {
+		r.reader = replica
+	}
+	return r
 }
 
 func (r *CommentRepository) Create(comment *Comment) error {
-	query := `INSERT INTO comments (post_id, user_id, content, created_at) 
-			  VALUES (?, ?, ?, ?)`
-	
-	result, err := r.conn.Exec(query, comment.PostID, comment.UserID, comment.Content, time.Now())
+	// Replies must point at a comment in the same thread
+	if comment.ParentID != nil {
+		var parentPostID int64
+		err := r.conn.QueryRow(`SELECT post_id FROM comments WHERE id = ?`, *comment.ParentID).Scan(&parentPostID)
+		if err == sql.ErrNoRows || (err == nil && parentPostID != comment.PostID) {
+			return ErrParentCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO comments (post_id, parent_id, user_id, content, created_at)
+			  VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.conn.Exec(query, comment.PostID, comment.ParentID, comment.UserID, comment.Content, time.Now())
 	if err != nil {
 		return err
 	}
@@ -46,34 +134,319 @@ func (r *CommentRepository) Create(comment *Comment) error {
 	return nil
 }
 
-func (r *CommentRepository) GetByPostID(postID int64) ([]Comment, error) {
-	query := `SELECT id, post_id, user_id, content, created_at 
-			  FROM comments WHERE post_id = ? ORDER BY created_at DESC`
-	
-	rows, err := r.conn.Query(query, postID)
+// commentColumns selects a comment with its author's username, in the order
+// scanComments expects
+const commentColumns = `c.id, c.post_id, c.parent_id, c.user_id, u.username, c.content, c.created_at, c.deleted_at
+			  FROM comments c
+			  JOIN users u ON c.user_id = u.id`
+
+// GetByPostID retrieves the comments on a post with their authors' usernames;
+// includeDeleted keeps the original content of soft-deleted comments for
+// moderation audits
+func (r *CommentRepository) GetByPostID(postID int64, includeDeleted bool) ([]Comment, error) {
+	query := `SELECT ` + commentColumns + `
+			  WHERE c.post_id = ?
+			  ORDER BY c.created_at DESC`
+
+	rows, err := r.reader.Query(query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComments(rows, includeDeleted)
+}
+
+// GetByPostIDPaginated retrieves one page of a post's comments in the given
+// order, along with the total number of comments on the post
+func (r *CommentRepository) GetByPostIDPaginated(postID int64, page, limit int, sort CommentSort, includeDeleted bool) ([]Comment, int, error) {
+	offset := (page - 1) * limit
+
+	orderBy, ok := commentSortOrder[sort]
+	if !ok {
+		orderBy = commentSortOrder[CommentSortNew]
+	}
+
+	var totalCount int
+	if err := r.reader.QueryRow(`SELECT COUNT(*) FROM comments WHERE post_id = ?`, postID).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + commentColumns + `
+			  WHERE c.post_id = ?
+			  ORDER BY ` + orderBy + `
+			  LIMIT ? OFFSET ?`
+
+	rows, err := r.reader.Query(query, postID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return comments, totalCount, nil
+}
+
+// scanComments reads rows selected with commentColumns
+func scanComments(rows *sql.Rows, includeDeleted bool) ([]Comment, error) {
+	var comments []Comment
+	for rows.Next() {
+		var comment Comment
+		var deletedAt sql.NullTime
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.ParentID,
+			&comment.UserID,
+			&comment.Username,
+			&comment.Content,
+			&comment.CreatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comment.tombstone(deletedAt, includeDeleted)
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// GetByID retrieves a single comment along with its author's username;
+// includeDeleted keeps the original content of a soft-deleted comment
+func (r *CommentRepository) GetByID(commentID int64, includeDeleted bool) (*Comment, error) {
+	query := `SELECT c.id, c.post_id, c.parent_id, c.user_id, u.username, c.content, c.created_at, c.deleted_at
+			  FROM comments c
+			  JOIN users u ON c.user_id = u.id
+			  WHERE c.id = ?`
+
+	comment := &Comment{}
+	var deletedAt sql.NullTime
+	err := r.reader.QueryRow(query, commentID).Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.Username,
+		&comment.Content,
+		&comment.CreatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	comment.tombstone(deletedAt, includeDeleted)
+
+	return comment, nil
+}
+
+// UpdateComment replaces the content of a comment owned by the user;
+// soft-deleted comments can no longer be edited
+func (r *CommentRepository) UpdateComment(commentID, userID int64, content string) error {
+	query := `UPDATE comments SET content = ?
+			  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	result, err := r.conn.Exec(query, content, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// DeleteComment soft-deletes a comment owned by the user, keeping the row so
+// that replies to it remain attached to the thread
+func (r *CommentRepository) DeleteComment(commentID, userID int64) error {
+	query := `UPDATE comments SET deleted_at = ?
+			  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	result, err := r.conn.Exec(query, time.Now(), commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// purgeableComments matches soft-deleted comments older than the cutoff that
+// no longer have replies; a reply still needs its parent's tombstone
+const purgeableComments = `SELECT id FROM comments
+			  WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			  AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = comments.id)`
+
+// PurgeDeleted permanently removes comments soft-deleted before olderThan,
+// along with their likes and dislikes, and returns how many were removed.
+// Deleted comments with live replies are kept; once their replies are purged
+// they go too
+func (r *CommentRepository) PurgeDeleted(olderThan time.Time) (int64, error) {
+	var purged int64
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		// Each round frees the parents whose deleted replies the previous one removed
+		for {
+			if _, err := tx.Exec(`DELETE FROM interactions WHERE entity_type = ? AND entity_id IN (`+purgeableComments+`)`,
+				models.EntityTypeComment, olderThan); err != nil {
+				return err
+			}
+
+			result, err := tx.Exec(`DELETE FROM comments WHERE id IN (`+purgeableComments+`)`, olderThan)
+			if err != nil {
+				return err
+			}
+			removed, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if removed == 0 {
+				return nil
+			}
+			purged += removed
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// GetParticipantIDs returns the distinct IDs of the post's author and every
+// user who has commented on it
+func (r *CommentRepository) GetParticipantIDs(postID int64) ([]int64, error) {
+	query := `SELECT user_id FROM posts WHERE id = ?
+			  UNION
+			  SELECT user_id FROM comments WHERE post_id = ?`
+
+	rows, err := r.reader.Query(query, postID, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// CountUserComments counts the comments a user has written, leaving out the
+// ones they deleted
+func (r *CommentRepository) CountUserComments(userID int64) (int, error) {
+	var count int
+	err := r.reader.QueryRow(`SELECT COUNT(*) FROM comments WHERE user_id = ? AND deleted_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// GetRecentComments retrieves the newest comments across all posts with their
+// post title and author username. It is meant for moderators, so soft-deleted
+// comments keep their original content
+func (r *CommentRepository) GetRecentComments(limit int) ([]Comment, error) {
+	query := `SELECT c.id, c.post_id, c.parent_id, c.user_id, u.username, p.title,
+				 c.content, c.created_at, c.deleted_at
+			  FROM comments c
+			  JOIN users u ON c.user_id = u.id
+			  JOIN posts p ON c.post_id = p.id
+			  ORDER BY c.created_at DESC, c.id DESC
+			  LIMIT ?`
+
+	rows, err := r.reader.Query(query, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanCommentsWithPostTitle(rows, true)
+}
+
+// GetUserComments retrieves one page of a user's comments, newest first, with
+// the title of the post each was left on, along with the user's total number
+// of comments. Soft-deleted comments are left out
+func (r *CommentRepository) GetUserComments(userID int64, page, limit int) ([]Comment, int, error) {
+	offset := (page - 1) * limit
+
+	totalCount, err := r.CountUserComments(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT c.id, c.post_id, c.parent_id, c.user_id, u.username, p.title,
+				 c.content, c.created_at, c.deleted_at
+			  FROM comments c
+			  JOIN users u ON c.user_id = u.id
+			  JOIN posts p ON c.post_id = p.id
+			  WHERE c.user_id = ? AND c.deleted_at IS NULL
+			  ORDER BY c.created_at DESC, c.id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := r.reader.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments, err := scanCommentsWithPostTitle(rows, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return comments, totalCount, nil
+}
+
+// scanCommentsWithPostTitle reads comment rows that also select the title of
+// the post, right after the author's username
+func scanCommentsWithPostTitle(rows *sql.Rows, includeDeleted bool) ([]Comment, error) {
 	var comments []Comment
 	for rows.Next() {
 		var comment Comment
+		var deletedAt sql.NullTime
 		err := rows.Scan(
-			&comment.ID, 
-			&comment.PostID, 
-			&comment.UserID, 
-			&comment.Content, 
+			&comment.ID,
+			&comment.PostID,
+			&comment.ParentID,
+			&comment.UserID,
+			&comment.Username,
+			&comment.PostTitle,
+			&comment.Content,
 			&comment.CreatedAt,
+			&deletedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		comment.tombstone(deletedAt, includeDeleted)
 		comments = append(comments, comment)
 	}
 
-	return comments, nil
+	return comments, rows.Err()
 }
 
 // Ensure CommentRepository implements the interface
-var _ CommentRepositoryInterface = &CommentRepository{}
\ No newline at end of file
+var _ CommentRepositoryInterface = &CommentRepository{}
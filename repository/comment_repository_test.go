@@ -1,10 +1,457 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommentRepository(t *testing.T) {
 	// Placeholder test
 	t.Log("Comment repository tests")
-}
\ No newline at end of file
+}
+
+func TestCommentRepository_GetByID(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	comment := &Comment{PostID: post.ID, UserID: userID, Content: "Permalink me"}
+	require.NoError(t, repo.Create(comment))
+
+	t.Run("Found", func(t *testing.T) {
+		found, err := repo.GetByID(comment.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, comment.ID, found.ID)
+		assert.Equal(t, post.ID, found.PostID)
+		assert.Equal(t, "Permalink me", found.Content)
+		assert.Equal(t, "testuser", found.Username)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		found, err := repo.GetByID(comment.ID+100, false)
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+		assert.Nil(t, found)
+	})
+}
+
+func TestCommentRepository_GetByPostIDUsernames(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "replier", "replier@example.com", "hashedpassword")
+	require.NoError(t, err)
+	replierID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	require.NoError(t, repo.Create(&Comment{PostID: post.ID, UserID: userID, Content: "Mine"}))
+	require.NoError(t, repo.Create(&Comment{PostID: post.ID, UserID: replierID, Content: "Theirs"}))
+
+	comments, err := repo.GetByPostID(post.ID, false)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+
+	usernames := make(map[string]string)
+	for _, c := range comments {
+		usernames[c.Content] = c.Username
+	}
+	assert.Equal(t, "testuser", usernames["Mine"])
+	assert.Equal(t, "replier", usernames["Theirs"])
+}
+
+func TestCommentRepository_GetByPostIDPaginated(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	post := &models.Post{UserID: userID, Title: "Busy", Content: "Post content"}
+	require.NoError(t, postRepo.Create(post, nil))
+	quiet := &models.Post{UserID: userID, Title: "Quiet", Content: "Post content"}
+	require.NoError(t, postRepo.Create(quiet, nil))
+
+	repo := NewCommentRepository(db)
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, repo.Create(&Comment{PostID: post.ID, UserID: userID, Content: fmt.Sprintf("Comment %d", i)}))
+	}
+	require.NoError(t, repo.Create(&Comment{PostID: quiet.ID, UserID: userID, Content: "Elsewhere"}))
+
+	contents := func(comments []Comment) []string {
+		var out []string
+		for _, c := range comments {
+			out = append(out, c.Content)
+		}
+		return out
+	}
+
+	first, total, err := repo.GetByPostIDPaginated(post.ID, 1, 2, CommentSortNew, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, []string{"Comment 5", "Comment 4"}, contents(first))
+
+	second, total, err := repo.GetByPostIDPaginated(post.ID, 2, 2, CommentSortNew, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, []string{"Comment 3", "Comment 2"}, contents(second))
+	assert.Equal(t, "testuser", second[0].Username)
+
+	last, _, err := repo.GetByPostIDPaginated(post.ID, 3, 2, CommentSortNew, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Comment 1"}, contents(last))
+}
+
+func TestCommentRepository_GetByPostIDPaginatedSort(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	var voterIDs []int64
+	for i := 1; i <= 2; i++ {
+		result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", fmt.Sprintf("voter%d", i), fmt.Sprintf("voter%d@example.com", i), "hashedpassword")
+		require.NoError(t, err)
+		voterID, err := result.LastInsertId()
+		require.NoError(t, err)
+		voterIDs = append(voterIDs, voterID)
+	}
+
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	comments := make([]*Comment, 3)
+	for i := range comments {
+		comments[i] = &Comment{PostID: post.ID, UserID: userID, Content: fmt.Sprintf("Comment %d", i+1)}
+		require.NoError(t, repo.Create(comments[i]))
+	}
+
+	// The newest comment gets the most likes, so top differs from both old
+	// and new; dislikes don't count towards top
+	interactions := NewInteractionRepository(db)
+	require.NoError(t, interactions.AddInteraction(voterIDs[0], comments[2].ID, models.EntityTypeComment, models.Like))
+	require.NoError(t, interactions.AddInteraction(voterIDs[1], comments[2].ID, models.EntityTypeComment, models.Like))
+	require.NoError(t, interactions.AddInteraction(voterIDs[0], comments[0].ID, models.EntityTypeComment, models.Like))
+	require.NoError(t, interactions.AddInteraction(voterIDs[0], comments[1].ID, models.EntityTypeComment, models.Dislike))
+	require.NoError(t, interactions.AddInteraction(voterIDs[1], comments[1].ID, models.EntityTypeComment, models.Dislike))
+
+	contents := func(comments []Comment) []string {
+		var out []string
+		for _, c := range comments {
+			out = append(out, c.Content)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		sort     CommentSort
+		expected []string
+	}{
+		{CommentSortTop, []string{"Comment 3", "Comment 1", "Comment 2"}},
+		{CommentSortNew, []string{"Comment 3", "Comment 2", "Comment 1"}},
+		{CommentSortOld, []string{"Comment 1", "Comment 2", "Comment 3"}},
+	} {
+		page, total, err := repo.GetByPostIDPaginated(post.ID, 1, 10, tc.sort, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, tc.expected, contents(page), "sort %s", tc.sort)
+	}
+
+	top, _, err := repo.GetByPostIDPaginated(post.ID, 1, 1, CommentSortTop, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Comment 3"}, contents(top))
+}
+
+func TestCommentRepository_CountUserComments(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	comments := make([]*Comment, 3)
+	for i := range comments {
+		comments[i] = &Comment{PostID: post.ID, UserID: userID, Content: fmt.Sprintf("Comment %d", i+1)}
+		require.NoError(t, repo.Create(comments[i]))
+	}
+	require.NoError(t, repo.DeleteComment(comments[0].ID, userID))
+
+	count, err := repo.CountUserComments(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestCommentRepository_GetUserComments(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "other", "other@example.com", "hashedpassword")
+	require.NoError(t, err)
+	otherID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	postRepo := NewPostRepository(db)
+	first := &models.Post{UserID: userID, Title: "First post", Content: "Post content"}
+	require.NoError(t, postRepo.Create(first, nil))
+	second := &models.Post{UserID: otherID, Title: "Second post", Content: "Post content"}
+	require.NoError(t, postRepo.Create(second, nil))
+
+	repo := NewCommentRepository(db)
+	comment := func(postID, authorID int64, content string) *Comment {
+		c := &Comment{PostID: postID, UserID: authorID, Content: content}
+		require.NoError(t, repo.Create(c))
+		return c
+	}
+	comment(first.ID, userID, "Mine 1")
+	comment(second.ID, otherID, "Theirs 1")
+	comment(second.ID, userID, "Mine 2")
+	deleted := comment(first.ID, userID, "Mine deleted")
+	comment(first.ID, otherID, "Theirs 2")
+	comment(second.ID, userID, "Mine 3")
+	require.NoError(t, repo.DeleteComment(deleted.ID, userID))
+
+	page1, total, err := repo.GetUserComments(userID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, page1, 2)
+	assert.Equal(t, "Mine 3", page1[0].Content)
+	assert.Equal(t, "Second post", page1[0].PostTitle)
+	assert.Equal(t, "Mine 2", page1[1].Content)
+
+	page2, total, err := repo.GetUserComments(userID, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, page2, 1)
+	assert.Equal(t, "Mine 1", page2[0].Content)
+	assert.Equal(t, "First post", page2[0].PostTitle)
+
+	for _, c := range append(page1, page2...) {
+		assert.Equal(t, userID, c.UserID)
+		assert.False(t, c.Deleted)
+	}
+}
+
+func TestCommentRepository_UpdateComment(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	comment := &Comment{PostID: post.ID, UserID: userID, Content: "Frist"}
+	require.NoError(t, repo.Create(comment))
+
+	t.Run("Wrong Owner", func(t *testing.T) {
+		err := repo.UpdateComment(comment.ID, userID+1, "Hijacked")
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+
+		found, err := repo.GetByID(comment.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, "Frist", found.Content)
+	})
+
+	t.Run("Owner", func(t *testing.T) {
+		require.NoError(t, repo.UpdateComment(comment.ID, userID, "First"))
+
+		found, err := repo.GetByID(comment.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, "First", found.Content)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		require.NoError(t, repo.DeleteComment(comment.ID, userID))
+		err := repo.UpdateComment(comment.ID, userID, "Back again")
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+	})
+}
+
+func TestCommentRepository_SoftDelete(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	parent := &Comment{PostID: post.ID, UserID: userID, Content: "Original thought"}
+	require.NoError(t, repo.Create(parent))
+	reply := &Comment{PostID: post.ID, ParentID: &parent.ID, UserID: userID, Content: "A reply"}
+	require.NoError(t, repo.Create(reply))
+
+	t.Run("Wrong Owner", func(t *testing.T) {
+		err := repo.DeleteComment(parent.ID, userID+1)
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+	})
+
+	require.NoError(t, repo.DeleteComment(parent.ID, userID))
+
+	t.Run("Tombstone Keeps Thread", func(t *testing.T) {
+		comments, err := repo.GetByPostID(post.ID, false)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+
+		byID := make(map[int64]Comment)
+		for _, c := range comments {
+			byID[c.ID] = c
+		}
+
+		deleted := byID[parent.ID]
+		assert.True(t, deleted.Deleted)
+		assert.Equal(t, DeletedCommentContent, deleted.Content)
+
+		kept := byID[reply.ID]
+		assert.False(t, kept.Deleted)
+		assert.Equal(t, "A reply", kept.Content)
+		require.NotNil(t, kept.ParentID)
+		assert.Equal(t, parent.ID, *kept.ParentID)
+	})
+
+	t.Run("Lookup By ID", func(t *testing.T) {
+		found, err := repo.GetByID(parent.ID, false)
+		require.NoError(t, err)
+		assert.True(t, found.Deleted)
+		assert.Equal(t, DeletedCommentContent, found.Content)
+	})
+
+	t.Run("Include Deleted", func(t *testing.T) {
+		comments, err := repo.GetByPostID(post.ID, true)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		for _, c := range comments {
+			if c.ID == parent.ID {
+				assert.True(t, c.Deleted)
+				assert.Equal(t, "Original thought", c.Content)
+			}
+		}
+
+		found, err := repo.GetByID(parent.ID, true)
+		require.NoError(t, err)
+		assert.True(t, found.Deleted)
+		assert.Equal(t, "Original thought", found.Content)
+	})
+
+	t.Run("Already Deleted", func(t *testing.T) {
+		err := repo.DeleteComment(parent.ID, userID)
+		assert.ErrorIs(t, err, ErrCommentNotFound)
+	})
+
+	t.Run("Parent On Other Post", func(t *testing.T) {
+		orphan := &Comment{PostID: post.ID + 1, ParentID: &parent.ID, UserID: userID, Content: "Lost"}
+		assert.ErrorIs(t, repo.Create(orphan), ErrParentCommentNotFound)
+	})
+}
+
+func TestCommentRepository_PurgeDeleted(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Post", Content: "Post content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewCommentRepository(db)
+	interactions := NewInteractionRepository(db)
+	now := time.Now()
+	comment := func(parent *Comment, deletedAt *time.Time) *Comment {
+		c := &Comment{PostID: post.ID, UserID: userID, Content: "Comment"}
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		require.NoError(t, repo.Create(c))
+		require.NoError(t, interactions.AddInteraction(userID, c.ID, models.EntityTypeComment, models.Like))
+		if deletedAt != nil {
+			_, err := db.Exec(`UPDATE comments SET deleted_at = ? WHERE id = ?`, *deletedAt, c.ID)
+			require.NoError(t, err)
+		}
+		return c
+	}
+	exists := func(c *Comment) bool {
+		_, err := repo.GetByID(c.ID, true)
+		if errors.Is(err, ErrCommentNotFound) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+	hasInteractions := func(c *Comment) bool {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM interactions WHERE entity_type = 'comment' AND entity_id = ?`, c.ID).Scan(&count))
+		return count > 0
+	}
+
+	longAgo := now.Add(-48 * time.Hour)
+	lately := now.Add(-time.Hour)
+
+	oldDeleted := comment(nil, &longAgo)
+	recentDeleted := comment(nil, &lately)
+	live := comment(nil, nil)
+	// A deleted thread goes in full; a deleted parent with a live reply stays
+	deletedParent := comment(nil, &longAgo)
+	deletedReply := comment(deletedParent, &longAgo)
+	answeredParent := comment(nil, &longAgo)
+	liveReply := comment(answeredParent, nil)
+
+	purged, err := repo.PurgeDeleted(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	for _, c := range []*Comment{oldDeleted, deletedParent, deletedReply} {
+		assert.False(t, exists(c), "comment %d should be purged", c.ID)
+		assert.False(t, hasInteractions(c), "comment %d interactions should be purged", c.ID)
+	}
+	for _, c := range []*Comment{recentDeleted, live, answeredParent, liveReply} {
+		assert.True(t, exists(c), "comment %d should remain", c.ID)
+		assert.True(t, hasInteractions(c), "comment %d interactions should remain", c.ID)
+	}
+}
+
+func TestCommentRepository_GetRecentComments(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	first := &models.Post{UserID: userID, Title: "First post", Content: "Content"}
+	require.NoError(t, postRepo.Create(first, nil))
+	second := &models.Post{UserID: userID, Title: "Second post", Content: "Content"}
+	require.NoError(t, postRepo.Create(second, nil))
+
+	repo := NewCommentRepository(db)
+	var created []*Comment
+	for _, postID := range []int64{first.ID, second.ID, first.ID, second.ID} {
+		comment := &Comment{PostID: postID, UserID: userID, Content: "Hello"}
+		require.NoError(t, repo.Create(comment))
+		created = append(created, comment)
+	}
+
+	comments, err := repo.GetRecentComments(3)
+	require.NoError(t, err)
+	require.Len(t, comments, 3)
+
+	assert.Equal(t, created[3].ID, comments[0].ID)
+	assert.Equal(t, created[2].ID, comments[1].ID)
+	assert.Equal(t, created[1].ID, comments[2].ID)
+
+	assert.Equal(t, "Second post", comments[0].PostTitle)
+	assert.Equal(t, "First post", comments[1].PostTitle)
+	assert.Equal(t, "testuser", comments[0].Username)
+}
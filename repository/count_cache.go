@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultPostCountTTL is how long ListPosts reuses the total post count
+// before counting again
+const DefaultPostCountTTL = 30 * time.Second
+
+// countCache holds a row count for a short TTL so paging does not rescan the
+// table on every request. Writers through the owning repository invalidate
+// it; writes made elsewhere become visible once the TTL expires
+type countCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	value   int
+	expires time.Time
+}
+
+// get returns the cached count, or calls load and caches its result when the
+// entry is missing or expired
+func (c *countCache) get(load func() (int, error)) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl > 0 && time.Now().Before(c.expires) {
+		return c.value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return 0, err
+	}
+	c.value = value
+	c.expires = time.Now().Add(c.ttl)
+	return value, nil
+}
+
+// invalidate forces the next get to reload the count
+func (c *countCache) invalidate() {
+	c.mu.Lock()
+	c.expires = time.Time{}
+	c.mu.Unlock()
+}
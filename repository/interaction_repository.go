@@ -3,7 +3,9 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"forum/models"
+	"strings"
 	"time"
 )
 
@@ -11,99 +13,200 @@ type Interaction struct {
 	ID         int64           `json:"id"`
 	UserID     int64           `json:"user_id"`
 	EntityID   int64           `json:"entity_id"`
-	EntityType string          `json:"entity_type"` // 'post' or 'comment'
+	EntityType models.EntityType `json:"entity_type"`
 	Type       models.InteractionType `json:"type"`
 	CreatedAt  time.Time       `json:"created_at"`
 }
 
 type InteractionRepositoryInterface interface {
-	AddInteraction(userID, entityID int64, entityType string, interactionType models.InteractionType) error
-	GetInteractionCounts(entityID int64, entityType string) (likes, dislikes int, err error)
-	RemoveInteraction(userID, entityID int64, entityType string) error
+	AddInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) error
+	ToggleInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) (models.InteractionType, error)
+	GetInteractionCounts(entityID int64, entityType models.EntityType) (likes, dislikes int, err error)
+	RemoveInteraction(userID, entityID int64, entityType models.EntityType) error
+	GetUserInteractions(userID int64, entityIDs []int64, entityType models.EntityType) (map[int64]models.InteractionType, error)
+	GetInteractionSummaries(viewerID int64, entityIDs []int64, entityType models.EntityType) (map[int64]InteractionSummary, error)
+	GetTopUsersByLikes(window time.Duration, limit int) ([]LeaderboardEntry, error)
+	GetUserInteractionSummary(userID int64) (likesGiven, dislikesGiven, likesReceived int, err error)
+	GetReceivedInteractions(userID int64, page, limit int) ([]InteractionDetail, int, error)
 }
 
+// LeaderboardEntry is a user's rank by likes received on their posts
+type LeaderboardEntry struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Likes    int    `json:"likes"`
+}
+
+// InteractionSummary is the reaction counts on an entity together with the
+// viewer's own reaction, which is zero when they have not reacted
+type InteractionSummary struct {
+	Likes          int
+	Dislikes       int
+	ViewerReaction models.InteractionType
+}
+
+// InteractionDetail is a reaction someone else left on a user's post or comment
+type InteractionDetail struct {
+	ActorID       int64                  `json:"actor_id"`
+	ActorUsername string                 `json:"actor_username"`
+	EntityID      int64                  `json:"entity_id"`
+	EntityType    models.EntityType      `json:"entity_type"`
+	Type          models.InteractionType `json:"type"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+var (
+	// ErrInteractionNotFound is returned when removing a reaction the user never made
+	ErrInteractionNotFound = errors.New("interaction not found")
+	// ErrEntityNotFound is returned when reacting to a post or comment that
+	// does not exist or has been deleted
+	ErrEntityNotFound = errors.New("entity not found")
+)
+
 type InteractionRepository struct {
-	conn *sql.DB
+	conn   *sql.DB
+	reader *sql.DB
 }
 
 func NewInteractionRepository(conn *sql.DB) *InteractionRepository {
-	return &InteractionRepository{conn: conn}
+	return &InteractionRepository{conn: conn, reader: conn}
+}
+
+// WithReplica sends the repository's queries to a read-only replica; writes
+// stay on the primary. A nil replica keeps reads on the primary
+func (r *InteractionRepository) WithReplica(replica *sql.DB) *InteractionRepository {
+	if replica != nil {
+		r.reader = replica
+	}
+	return r
 }
 
 // Ensure InteractionRepository implements the interface
 var _ InteractionRepositoryInterface = &InteractionRepository{}
 
-// AddInteraction adds a like or dislike to a post or comment
-func (r *InteractionRepository) AddInteraction(userID, entityID int64, entityType string, interactionType models.InteractionType) error {
+// AddInteraction adds a like or dislike to a post or comment. The reaction and
+// the post counters change together or, on any error, not at all
+func (r *InteractionRepository) AddInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) error {
 	// Validate input
 	if userID <= 0 || entityID <= 0 || entityType == "" {
 		return errors.New("invalid interaction parameters")
 	}
 
-	// Start a transaction
-	tx, err := r.conn.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			return
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		if err := checkEntityExists(tx, entityID, entityType); err != nil {
+			return err
 		}
-		tx.Commit()
-	}()
 
-	// Check if the entity exists (basic validation)
-	var exists int
-	var checkQuery string
-	switch entityType {
-	case "post":
-		checkQuery = "SELECT COUNT(*) FROM posts WHERE id = ?"
-	case "comment":
-		checkQuery = "SELECT COUNT(*) FROM comments WHERE id = ?"
-	default:
-		return errors.New("invalid entity type")
-	}
+		// Check if user has already interacted with this entity
+		var currentInteraction models.InteractionType
+		err := tx.QueryRow(`
+			SELECT type FROM interactions 
+			WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+		`, userID, entityID, entityType).Scan(&currentInteraction)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
 
-	err = tx.QueryRow(checkQuery, entityID).Scan(&exists)
-	if err != nil {
-		return err
-	}
-	if exists == 0 {
-		return errors.New("entity not found")
-	}
+		// If interaction exists, update it
+		if err == nil {
+			_, err = tx.Exec(`
+				UPDATE interactions 
+				SET type = ?, created_at = ? 
+				WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+			`, interactionType, time.Now(), userID, entityID, entityType)
+			if err != nil || currentInteraction == interactionType || entityType != models.EntityTypePost {
+				return err
+			}
 
-	// Check if user has already interacted with this entity
-	var currentInteraction models.InteractionType
-	err = tx.QueryRow(`
-		SELECT type FROM interactions 
-		WHERE user_id = ? AND entity_id = ? AND entity_type = ?
-	`, userID, entityID, entityType).Scan(&currentInteraction)
+			// Move the user's vote from the old counter to the new one
+			if err := adjustPostCount(tx, entityID, currentInteraction, -1); err != nil {
+				return err
+			}
+			return adjustPostCount(tx, entityID, interactionType, 1)
+		}
 
-	if err != nil && err != sql.ErrNoRows {
-		return err
+		// Insert new interaction
+		_, err = tx.Exec(`
+			INSERT INTO interactions (user_id, entity_id, entity_type, type, created_at) 
+			VALUES (?, ?, ?, ?, ?)
+		`, userID, entityID, entityType, interactionType, time.Now())
+		if err != nil || entityType != models.EntityTypePost {
+			return err
+		}
+		return adjustPostCount(tx, entityID, interactionType, 1)
+	})
+}
+
+// ToggleInteraction applies a like or dislike with toggle semantics: repeating
+// the current reaction removes it, the opposite one replaces it, and otherwise
+// it is added. It returns the resulting reaction, or 0 when none remains
+func (r *InteractionRepository) ToggleInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) (models.InteractionType, error) {
+	// Validate input
+	if userID <= 0 || entityID <= 0 || entityType == "" {
+		return 0, errors.New("invalid interaction parameters")
+	}
+	if interactionType != models.Like && interactionType != models.Dislike {
+		return 0, errors.New("invalid interaction type")
 	}
 
-	// If interaction exists, update it
-	if err == nil {
-		_, err = tx.Exec(`
-			UPDATE interactions 
-			SET type = ?, created_at = ? 
+	var result models.InteractionType
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		if err := checkEntityExists(tx, entityID, entityType); err != nil {
+			return err
+		}
+
+		var currentInteraction models.InteractionType
+		err := tx.QueryRow(`
+			SELECT type FROM interactions
 			WHERE user_id = ? AND entity_id = ? AND entity_type = ?
-		`, interactionType, time.Now(), userID, entityID, entityType)
-		return err
-	}
+		`, userID, entityID, entityType).Scan(&currentInteraction)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
 
-	// Insert new interaction
-	_, err = tx.Exec(`
-		INSERT INTO interactions (user_id, entity_id, entity_type, type, created_at) 
-		VALUES (?, ?, ?, ?, ?)
-	`, userID, entityID, entityType, interactionType, time.Now())
-	return err
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec(`
+				INSERT INTO interactions (user_id, entity_id, entity_type, type, created_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, userID, entityID, entityType, interactionType, time.Now())
+			result = interactionType
+		case currentInteraction == interactionType:
+			_, err = tx.Exec(`
+				DELETE FROM interactions
+				WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+			`, userID, entityID, entityType)
+		default:
+			_, err = tx.Exec(`
+				UPDATE interactions
+				SET type = ?, created_at = ?
+				WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+			`, interactionType, time.Now(), userID, entityID, entityType)
+			result = interactionType
+		}
+		if err != nil || entityType != models.EntityTypePost {
+			return err
+		}
+
+		// Keep the denormalized post counters in step with the change
+		if currentInteraction != 0 {
+			if err := adjustPostCount(tx, entityID, currentInteraction, -1); err != nil {
+				return err
+			}
+		}
+		if result != 0 {
+			return adjustPostCount(tx, entityID, result, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
 }
 
 // GetInteractionCounts retrieves like and dislike counts for a specific entity
-func (r *InteractionRepository) GetInteractionCounts(entityID int64, entityType string) (likes, dislikes int, err error) {
+func (r *InteractionRepository) GetInteractionCounts(entityID int64, entityType models.EntityType) (likes, dislikes int, err error) {
 	// Validate input
 	if entityID <= 0 || entityType == "" {
 		return 0, 0, errors.New("invalid input parameters")
@@ -111,12 +214,12 @@ func (r *InteractionRepository) GetInteractionCounts(entityID int64, entityType
 
 	query := `
 		SELECT 
-			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as likes,
-			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as dislikes
+			COALESCE(SUM(CASE WHEN type = ? THEN 1 ELSE 0 END), 0) as likes,
+			COALESCE(SUM(CASE WHEN type = ? THEN 1 ELSE 0 END), 0) as dislikes
 		FROM interactions 
 		WHERE entity_id = ? AND entity_type = ?
 	`
-	err = r.conn.QueryRow(query, models.Like, models.Dislike, entityID, entityType).Scan(&likes, &dislikes)
+	err = r.reader.QueryRow(query, models.Like, models.Dislike, entityID, entityType).Scan(&likes, &dislikes)
 	if err == sql.ErrNoRows {
 		return 0, 0, nil
 	}
@@ -126,17 +229,325 @@ func (r *InteractionRepository) GetInteractionCounts(entityID int64, entityType
 	return
 }
 
-// RemoveInteraction removes a user's interaction with an entity
-func (r *InteractionRepository) RemoveInteraction(userID, entityID int64, entityType string) error {
+// RemoveInteraction removes a user's interaction with an entity, returning
+// ErrInteractionNotFound when there was none
+func (r *InteractionRepository) RemoveInteraction(userID, entityID int64, entityType models.EntityType) error {
 	// Validate input
 	if userID <= 0 || entityID <= 0 || entityType == "" {
 		return errors.New("invalid input parameters")
 	}
 
-	query := `
-		DELETE FROM interactions 
-		WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		var currentInteraction models.InteractionType
+		err := tx.QueryRow(`
+			SELECT type FROM interactions
+			WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+		`, userID, entityID, entityType).Scan(&currentInteraction)
+		if err == sql.ErrNoRows {
+			return ErrInteractionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		query := `
+			DELETE FROM interactions 
+			WHERE user_id = ? AND entity_id = ? AND entity_type = ?
+		`
+		if _, err := tx.Exec(query, userID, entityID, entityType); err != nil {
+			return err
+		}
+
+		if entityType == models.EntityTypePost {
+			return adjustPostCount(tx, entityID, currentInteraction, -1)
+		}
+		return nil
+	})
+}
+
+// reconcileCountsQuery recomputes the denormalized like and dislike counts
+// from the interactions table for the posts matched by the appended condition
+const reconcileCountsQuery = `
+	UPDATE posts SET
+		like_count = (SELECT COUNT(*) FROM interactions
+			WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?),
+		dislike_count = (SELECT COUNT(*) FROM interactions
+			WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?)
+`
+
+// ReconcilePostCounts recomputes a post's denormalized like and dislike
+// counts from the interactions table, repairing any drift
+func (r *InteractionRepository) ReconcilePostCounts(postID int64) error {
+	_, err := r.conn.Exec(reconcileCountsQuery+` WHERE id = ?`, models.Like, models.Dislike, postID)
+	return err
+}
+
+// ReconcileInteractionCounts recomputes the denormalized counts for every post
+// and returns how many posts had drifted
+func (r *InteractionRepository) ReconcileInteractionCounts() (int64, error) {
+	query := reconcileCountsQuery + `
+	WHERE like_count != (SELECT COUNT(*) FROM interactions
+			WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?)
+		OR dislike_count != (SELECT COUNT(*) FROM interactions
+			WHERE entity_type = 'post' AND entity_id = posts.id AND type = ?)
 	`
-	_, err := r.conn.Exec(query, userID, entityID, entityType)
+	result, err := r.conn.Exec(query, models.Like, models.Dislike, models.Like, models.Dislike)
+	if err != nil {
+		return 0, fmt.Errorf("error reconciling interaction counts: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// checkEntityExists returns ErrEntityNotFound unless the post or comment can
+// be reacted to; deleted posts are removed outright while deleted comments
+// keep their row, so those are excluded here
+func checkEntityExists(tx *sql.Tx, entityID int64, entityType models.EntityType) error {
+	var checkQuery string
+	switch entityType {
+	case models.EntityTypePost:
+		checkQuery = "SELECT COUNT(*) FROM posts WHERE id = ?"
+	case models.EntityTypeComment:
+		checkQuery = "SELECT COUNT(*) FROM comments WHERE id = ? AND deleted_at IS NULL"
+	default:
+		return errors.New("invalid entity type")
+	}
+
+	var exists int
+	if err := tx.QueryRow(checkQuery, entityID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrEntityNotFound
+	}
+	return nil
+}
+
+// adjustPostCount applies delta to the denormalized counter matching the
+// interaction type within the caller's transaction
+func adjustPostCount(tx *sql.Tx, postID int64, interactionType models.InteractionType, delta int) error {
+	var column string
+	switch interactionType {
+	case models.Like:
+		column = "like_count"
+	case models.Dislike:
+		column = "dislike_count"
+	default:
+		return errors.New("invalid interaction type")
+	}
+
+	_, err := tx.Exec(`UPDATE posts SET `+column+` = `+column+` + ? WHERE id = ?`, delta, postID)
 	return err
 }
+
+// GetUserInteractions returns the user's reaction to each of the given entities
+// in a single query; entities the user has not reacted to are absent from the map
+func (r *InteractionRepository) GetUserInteractions(userID int64, entityIDs []int64, entityType models.EntityType) (map[int64]models.InteractionType, error) {
+	// Validate input
+	if userID <= 0 || entityType == "" {
+		return nil, errors.New("invalid input parameters")
+	}
+
+	interactions := make(map[int64]models.InteractionType, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return interactions, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(entityIDs)), ",")
+	args := []interface{}{userID, entityType}
+	for _, id := range entityIDs {
+		args = append(args, id)
+	}
+
+	query := `
+		SELECT entity_id, type FROM interactions
+		WHERE user_id = ? AND entity_type = ? AND entity_id IN (` + placeholders + `)
+	`
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entityID int64
+		var interactionType models.InteractionType
+		if err := rows.Scan(&entityID, &interactionType); err != nil {
+			return nil, err
+		}
+		interactions[entityID] = interactionType
+	}
+
+	return interactions, rows.Err()
+}
+
+// GetInteractionSummaries counts the likes and dislikes on each entity and
+// looks up the viewer's reaction to it in a single query. Entities nobody has
+// reacted to are left out of the result
+func (r *InteractionRepository) GetInteractionSummaries(viewerID int64, entityIDs []int64, entityType models.EntityType) (map[int64]InteractionSummary, error) {
+	if viewerID <= 0 || entityType == "" {
+		return nil, errors.New("invalid input parameters")
+	}
+
+	summaries := make(map[int64]InteractionSummary, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return summaries, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(entityIDs)), ",")
+	args := []interface{}{models.Like, models.Dislike, viewerID, entityType}
+	for _, id := range entityIDs {
+		args = append(args, id)
+	}
+
+	query := `
+		SELECT entity_id,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END),
+			COALESCE(MAX(CASE WHEN user_id = ? THEN type END), 0)
+		FROM interactions
+		WHERE entity_type = ? AND entity_id IN (` + placeholders + `)
+		GROUP BY entity_id
+	`
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entityID int64
+		var summary InteractionSummary
+		if err := rows.Scan(&entityID, &summary.Likes, &summary.Dislikes, &summary.ViewerReaction); err != nil {
+			return nil, err
+		}
+		summaries[entityID] = summary
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetTopUsersByLikes ranks post authors by the likes their posts received
+// within the trailing window; ties are broken by user ID
+func (r *InteractionRepository) GetTopUsersByLikes(window time.Duration, limit int) ([]LeaderboardEntry, error) {
+	if window <= 0 || limit <= 0 {
+		return nil, errors.New("invalid input parameters")
+	}
+
+	query := `
+		SELECT p.user_id, u.username, COUNT(*) AS likes
+		FROM interactions i
+		JOIN posts p ON p.id = i.entity_id
+		JOIN users u ON u.id = p.user_id
+		WHERE i.entity_type = 'post' AND i.type = ? AND i.created_at >= ?
+		GROUP BY p.user_id, u.username
+		ORDER BY likes DESC, p.user_id ASC
+		LIMIT ?
+	`
+	rows, err := r.reader.Query(query, models.Like, time.Now().Add(-window), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []LeaderboardEntry
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.Likes); err != nil {
+			return nil, fmt.Errorf("error scanning leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetUserInteractionSummary counts the likes and dislikes a user has given
+// and the likes received on their posts and comments
+func (r *InteractionRepository) GetUserInteractionSummary(userID int64) (likesGiven, dislikesGiven, likesReceived int, err error) {
+	if userID <= 0 {
+		return 0, 0, 0, errors.New("invalid input parameters")
+	}
+
+	err = r.reader.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN type = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = ? THEN 1 ELSE 0 END), 0)
+		FROM interactions
+		WHERE user_id = ?
+	`, models.Like, models.Dislike, userID).Scan(&likesGiven, &dislikesGiven)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error counting given interactions: %w", err)
+	}
+
+	err = r.reader.QueryRow(`
+		SELECT COUNT(*) FROM interactions i
+		WHERE i.type = ? AND (
+			(i.entity_type = 'post' AND i.entity_id IN (SELECT id FROM posts WHERE user_id = ?))
+			OR (i.entity_type = 'comment' AND i.entity_id IN (SELECT id FROM comments WHERE user_id = ?))
+		)
+	`, models.Like, userID, userID).Scan(&likesReceived)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error counting received likes: %w", err)
+	}
+
+	return likesGiven, dislikesGiven, likesReceived, nil
+}
+
+// receivedInteractionsFilter matches reactions from other users on the posts
+// and comments written by the user bound to the last three parameters
+const receivedInteractionsFilter = `
+	WHERE i.user_id != ? AND (
+		(i.entity_type = 'post' AND i.entity_id IN (SELECT id FROM posts WHERE user_id = ?))
+		OR (i.entity_type = 'comment' AND i.entity_id IN (SELECT id FROM comments WHERE user_id = ?))
+	)
+`
+
+// GetReceivedInteractions pages through the reactions other users left on the
+// user's posts and comments, newest first, along with the total count
+func (r *InteractionRepository) GetReceivedInteractions(userID int64, page, limit int) ([]InteractionDetail, int, error) {
+	if userID <= 0 || page <= 0 || limit <= 0 {
+		return nil, 0, errors.New("invalid input parameters")
+	}
+	offset := (page - 1) * limit
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM interactions i` + receivedInteractionsFilter
+	if err := r.reader.QueryRow(countQuery, userID, userID, userID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("error counting received interactions: %w", err)
+	}
+
+	query := `
+		SELECT i.user_id, u.username, i.entity_id, i.entity_type, i.type, i.created_at
+		FROM interactions i
+		JOIN users u ON u.id = i.user_id` + receivedInteractionsFilter + `
+		ORDER BY i.created_at DESC, i.id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.reader.Query(query, userID, userID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying received interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var details []InteractionDetail
+	for rows.Next() {
+		var detail InteractionDetail
+		err := rows.Scan(
+			&detail.ActorID,
+			&detail.ActorUsername,
+			&detail.EntityID,
+			&detail.EntityType,
+			&detail.Type,
+			&detail.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning received interaction: %w", err)
+		}
+		details = append(details, detail)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return details, totalCount, nil
+}
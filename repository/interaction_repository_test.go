@@ -1,10 +1,608 @@
 package repository
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
+
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInteractionRepository(t *testing.T) {
 	// Placeholder test
 	t.Log("Interaction repository tests")
-}
\ No newline at end of file
+}
+
+func TestInteractionRepository_GetUserInteractions(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	viewerID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+
+	var postIDs []int64
+	for i := 0; i < 4; i++ {
+		post := &models.Post{UserID: viewerID, Title: "Post", Content: "Feed item"}
+		require.NoError(t, postRepo.Create(post, nil))
+		postIDs = append(postIDs, post.ID)
+	}
+
+	repo := NewInteractionRepository(db)
+	require.NoError(t, repo.AddInteraction(viewerID, postIDs[0], models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(viewerID, postIDs[1], models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.AddInteraction(viewerID, postIDs[3], models.EntityTypePost, models.Like))
+
+	t.Run("Mixed Reactions", func(t *testing.T) {
+		reactions, err := repo.GetUserInteractions(viewerID, postIDs[:3], models.EntityTypePost)
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]models.InteractionType{
+			postIDs[0]: models.Like,
+			postIDs[1]: models.Dislike,
+		}, reactions)
+	})
+
+	t.Run("Other Entity Type", func(t *testing.T) {
+		reactions, err := repo.GetUserInteractions(viewerID, postIDs, models.EntityTypeComment)
+		require.NoError(t, err)
+		assert.Empty(t, reactions)
+	})
+
+	t.Run("No Entities", func(t *testing.T) {
+		reactions, err := repo.GetUserInteractions(viewerID, nil, models.EntityTypePost)
+		require.NoError(t, err)
+		assert.Empty(t, reactions)
+	})
+}
+
+func TestInteractionRepository_GetInteractionSummaries(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	viewerID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "other", "other@example.com", "hashedpassword")
+	require.NoError(t, err)
+	otherID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	postRepo := NewPostRepository(db)
+	var postIDs []int64
+	for i := 0; i < 3; i++ {
+		post := &models.Post{UserID: otherID, Title: "Post", Content: "Feed item"}
+		require.NoError(t, postRepo.Create(post, nil))
+		postIDs = append(postIDs, post.ID)
+	}
+
+	repo := NewInteractionRepository(db)
+	require.NoError(t, repo.AddInteraction(viewerID, postIDs[0], models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(otherID, postIDs[0], models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(otherID, postIDs[1], models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(viewerID, postIDs[1], models.EntityTypePost, models.Dislike))
+
+	summaries, err := repo.GetInteractionSummaries(viewerID, postIDs, models.EntityTypePost)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]InteractionSummary{
+		postIDs[0]: {Likes: 2, ViewerReaction: models.Like},
+		postIDs[1]: {Likes: 1, Dislikes: 1, ViewerReaction: models.Dislike},
+	}, summaries)
+
+	summaries, err = repo.GetInteractionSummaries(otherID, postIDs[1:], models.EntityTypePost)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]InteractionSummary{
+		postIDs[1]: {Likes: 1, Dislikes: 1, ViewerReaction: models.Like},
+	}, summaries)
+}
+
+func TestInteractionRepository_DenormalizedCounts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	authorID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	post := &models.Post{UserID: authorID, Title: "Hot", Content: "Take"}
+	require.NoError(t, postRepo.Create(post, nil))
+
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "voter", "voter@example.com", "hashedpassword")
+	require.NoError(t, err)
+	voterID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	repo := NewInteractionRepository(db)
+
+	// A post nobody has reacted to yet counts zero of each
+	likes, dislikes, err := repo.GetInteractionCounts(post.ID, models.EntityTypePost)
+	require.NoError(t, err)
+	assert.Zero(t, likes)
+	assert.Zero(t, dislikes)
+
+	assertCounts := func(t *testing.T, likes, dislikes int) {
+		t.Helper()
+		stored, err := postRepo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
+		require.NoError(t, err)
+		assert.Equal(t, likes, stored.LikeCount, "like_count")
+		assert.Equal(t, dislikes, stored.DislikeCount, "dislike_count")
+	}
+
+	require.NoError(t, repo.AddInteraction(authorID, post.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(voterID, post.ID, models.EntityTypePost, models.Like))
+	assertCounts(t, 2, 0)
+
+	// Repeating the same reaction must not count twice
+	require.NoError(t, repo.AddInteraction(voterID, post.ID, models.EntityTypePost, models.Like))
+	assertCounts(t, 2, 0)
+
+	// Toggling from like to dislike moves the vote between counters
+	require.NoError(t, repo.AddInteraction(voterID, post.ID, models.EntityTypePost, models.Dislike))
+	assertCounts(t, 1, 1)
+
+	require.NoError(t, repo.RemoveInteraction(voterID, post.ID, models.EntityTypePost))
+	assertCounts(t, 1, 0)
+
+	// Removing a reaction that does not exist leaves the counts alone
+	assert.ErrorIs(t, repo.RemoveInteraction(voterID, post.ID, models.EntityTypePost), ErrInteractionNotFound)
+	assertCounts(t, 1, 0)
+
+	t.Run("Reconcile Repairs Drift", func(t *testing.T) {
+		_, err := db.Exec("UPDATE posts SET like_count = 40, dislike_count = 3 WHERE id = ?", post.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.ReconcilePostCounts(post.ID))
+		assertCounts(t, 1, 0)
+	})
+}
+
+func TestInteractionRepository_AddInteractionRollsBack(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	post := &models.Post{UserID: userID, Title: "Fragile", Content: "Counters"}
+	require.NoError(t, postRepo.Create(post, nil))
+
+	repo := NewInteractionRepository(db)
+	countInteractions := func(t *testing.T) int {
+		t.Helper()
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM interactions WHERE entity_id = ? AND entity_type = 'post'`, post.ID).Scan(&count))
+		return count
+	}
+
+	t.Run("Invalid Type After Insert", func(t *testing.T) {
+		// The row is inserted before the counter update rejects the type
+		assert.Error(t, repo.AddInteraction(userID, post.ID, models.EntityTypePost, models.InteractionType(7)))
+		assert.Equal(t, 0, countInteractions(t))
+	})
+
+	t.Run("Counter Update Fails", func(t *testing.T) {
+		_, err := db.Exec(`CREATE TRIGGER fail_like_count BEFORE UPDATE OF like_count ON posts
+						   BEGIN SELECT RAISE(ABORT, 'counter update failed'); END`)
+		require.NoError(t, err)
+		defer db.Exec(`DROP TRIGGER fail_like_count`)
+
+		assert.Error(t, repo.AddInteraction(userID, post.ID, models.EntityTypePost, models.Like))
+		assert.Equal(t, 0, countInteractions(t))
+
+		stored, err := postRepo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
+		require.NoError(t, err)
+		assert.Zero(t, stored.LikeCount)
+	})
+
+	t.Run("Succeeds Once Fixed", func(t *testing.T) {
+		require.NoError(t, repo.AddInteraction(userID, post.ID, models.EntityTypePost, models.Like))
+		assert.Equal(t, 1, countInteractions(t))
+	})
+}
+
+func TestInteractionRepository_ReconcileInteractionCounts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	repo := NewInteractionRepository(db)
+
+	liked := &models.Post{UserID: userID, Title: "Liked", Content: "Content"}
+	require.NoError(t, postRepo.Create(liked, nil))
+	disliked := &models.Post{UserID: userID, Title: "Disliked", Content: "Content"}
+	require.NoError(t, postRepo.Create(disliked, nil))
+
+	require.NoError(t, repo.AddInteraction(userID, liked.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(userID, disliked.ID, models.EntityTypePost, models.Dislike))
+
+	// Corrupt one post's counts as a crash between writes might
+	_, err := db.Exec("UPDATE posts SET like_count = 0, dislike_count = 9 WHERE id = ?", liked.ID)
+	require.NoError(t, err)
+
+	repaired, err := repo.ReconcileInteractionCounts()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), repaired)
+
+	for _, tc := range []struct {
+		post     *models.Post
+		likes    int
+		dislikes int
+	}{
+		{post: liked, likes: 1, dislikes: 0},
+		{post: disliked, likes: 0, dislikes: 1},
+	} {
+		stored, err := postRepo.GetByID(strconv.FormatInt(tc.post.ID, 10), AnonymousViewer)
+		require.NoError(t, err)
+		assert.Equal(t, tc.likes, stored.LikeCount, tc.post.Title)
+		assert.Equal(t, tc.dislikes, stored.DislikeCount, tc.post.Title)
+	}
+
+	// A second run finds nothing left to fix
+	repaired, err = repo.ReconcileInteractionCounts()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), repaired)
+}
+
+func TestInteractionRepository_GetTopUsersByLikes(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	var userIDs []int64
+	for _, name := range []string{"alice", "bob", "carol"} {
+		result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", name, name+"@example.com", "hashedpassword")
+		require.NoError(t, err)
+		userID, err := result.LastInsertId()
+		require.NoError(t, err)
+		userIDs = append(userIDs, userID)
+	}
+	alice, bob, carol := userIDs[0], userIDs[1], userIDs[2]
+
+	postRepo := NewPostRepository(db)
+	newPost := func(authorID int64) int64 {
+		post := &models.Post{UserID: authorID, Title: "Post", Content: "Content"}
+		require.NoError(t, postRepo.Create(post, nil))
+		return post.ID
+	}
+	alicePost, bobPost, bobOther, carolPost := newPost(alice), newPost(bob), newPost(bob), newPost(carol)
+
+	repo := NewInteractionRepository(db)
+	// Bob collects three likes across two posts, alice one, carol only a dislike
+	require.NoError(t, repo.AddInteraction(alice, bobPost, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(carol, bobPost, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(alice, bobOther, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(bob, alicePost, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(alice, carolPost, models.EntityTypePost, models.Dislike))
+
+	// A like from outside the window does not count
+	_, err := db.Exec("UPDATE interactions SET created_at = ? WHERE user_id = ? AND entity_id = ?",
+		time.Now().Add(-48*time.Hour), bob, alicePost)
+	require.NoError(t, err)
+
+	t.Run("Within Window", func(t *testing.T) {
+		entries, err := repo.GetTopUsersByLikes(24*time.Hour, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []LeaderboardEntry{
+			{UserID: bob, Username: "bob", Likes: 3},
+		}, entries)
+	})
+
+	t.Run("Wider Window", func(t *testing.T) {
+		entries, err := repo.GetTopUsersByLikes(72*time.Hour, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []LeaderboardEntry{
+			{UserID: bob, Username: "bob", Likes: 3},
+			{UserID: alice, Username: "alice", Likes: 1},
+		}, entries)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		entries, err := repo.GetTopUsersByLikes(72*time.Hour, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, bob, entries[0].UserID)
+	})
+}
+
+func TestInteractionRepository_GetUserInteractionSummary(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	aliceID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "bob", "bob@example.com", "hashedpassword")
+	require.NoError(t, err)
+	bobID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	postRepo := NewPostRepository(db)
+	commentRepo := NewCommentRepository(db)
+	repo := NewInteractionRepository(db)
+
+	alicePost := &models.Post{UserID: aliceID, Title: "Alice", Content: "Content"}
+	require.NoError(t, postRepo.Create(alicePost, nil))
+	bobPost := &models.Post{UserID: bobID, Title: "Bob", Content: "Content"}
+	require.NoError(t, postRepo.Create(bobPost, nil))
+	otherBobPost := &models.Post{UserID: bobID, Title: "Bob again", Content: "Content"}
+	require.NoError(t, postRepo.Create(otherBobPost, nil))
+	aliceComment := &Comment{PostID: bobPost.ID, UserID: aliceID, Content: "Nice"}
+	require.NoError(t, commentRepo.Create(aliceComment))
+
+	// Alice gives two likes and a dislike; bob likes her post and comment
+	require.NoError(t, repo.AddInteraction(aliceID, bobPost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(aliceID, alicePost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(aliceID, otherBobPost.ID, models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.AddInteraction(bobID, alicePost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(bobID, aliceComment.ID, models.EntityTypeComment, models.Like))
+
+	likesGiven, dislikesGiven, likesReceived, err := repo.GetUserInteractionSummary(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, likesGiven)
+	assert.Equal(t, 1, dislikesGiven)
+	assert.Equal(t, 3, likesReceived)
+
+	likesGiven, dislikesGiven, likesReceived, err = repo.GetUserInteractionSummary(bobID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, likesGiven)
+	assert.Equal(t, 0, dislikesGiven)
+	assert.Equal(t, 1, likesReceived)
+}
+
+func TestInteractionRepository_RejectsDeletedEntities(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	post := &models.Post{UserID: userID, Title: "Thread", Content: "Content"}
+	require.NoError(t, postRepo.Create(post, nil))
+
+	commentRepo := NewCommentRepository(db)
+	comment := &Comment{PostID: post.ID, UserID: userID, Content: "Soon gone"}
+	require.NoError(t, commentRepo.Create(comment))
+	require.NoError(t, commentRepo.DeleteComment(comment.ID, userID))
+
+	repo := NewInteractionRepository(db)
+
+	t.Run("Soft Deleted Comment", func(t *testing.T) {
+		err := repo.AddInteraction(userID, comment.ID, models.EntityTypeComment, models.Like)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+
+		var stored int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM interactions WHERE entity_type = 'comment'`).Scan(&stored))
+		assert.Zero(t, stored)
+	})
+
+	t.Run("Deleted Post", func(t *testing.T) {
+		require.NoError(t, postRepo.DeletePost(strconv.FormatInt(post.ID, 10), userID))
+
+		err := repo.AddInteraction(userID, post.ID, models.EntityTypePost, models.Like)
+		assert.ErrorIs(t, err, ErrEntityNotFound)
+	})
+}
+
+func TestInteractionRepository_ToggleInteraction(t *testing.T) {
+	testCases := []struct {
+		name             string
+		existing         models.InteractionType
+		toggle           models.InteractionType
+		expected         models.InteractionType
+		expectedLikes    int
+		expectedDislikes int
+	}{
+		{
+			name:     "Like To None",
+			existing: models.Like,
+			toggle:   models.Like,
+			expected: 0,
+		},
+		{
+			name:             "Like To Dislike",
+			existing:         models.Like,
+			toggle:           models.Dislike,
+			expected:         models.Dislike,
+			expectedDislikes: 1,
+		},
+		{
+			name:          "None To Like",
+			toggle:        models.Like,
+			expected:      models.Like,
+			expectedLikes: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			userID := setupTestUser(t, db)
+			postRepo := NewPostRepository(db)
+			post := &models.Post{UserID: userID, Title: "Toggle", Content: "Content"}
+			require.NoError(t, postRepo.Create(post, nil))
+
+			repo := NewInteractionRepository(db)
+			if tc.existing != 0 {
+				require.NoError(t, repo.AddInteraction(userID, post.ID, models.EntityTypePost, tc.existing))
+			}
+
+			result, err := repo.ToggleInteraction(userID, post.ID, models.EntityTypePost, tc.toggle)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+
+			interactions, err := repo.GetUserInteractions(userID, []int64{post.ID}, models.EntityTypePost)
+			require.NoError(t, err)
+			if tc.expected == 0 {
+				assert.Empty(t, interactions)
+			} else {
+				assert.Equal(t, tc.expected, interactions[post.ID])
+			}
+
+			stored, err := postRepo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedLikes, stored.LikeCount, "like_count")
+			assert.Equal(t, tc.expectedDislikes, stored.DislikeCount, "dislike_count")
+		})
+	}
+}
+
+func TestInteractionRepository_ToggleUpdatesRowInPlace(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	post := &models.Post{UserID: userID, Title: "Toggle", Content: "Content"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+	comment := &Comment{PostID: post.ID, UserID: userID, Content: "React to me"}
+	require.NoError(t, NewCommentRepository(db).Create(comment))
+
+	repo := NewInteractionRepository(db)
+
+	stored := func() (id int64, interactionType models.InteractionType, count int) {
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM interactions WHERE user_id = ? AND entity_id = ? AND entity_type = 'comment'`,
+			userID, comment.ID).Scan(&count))
+		if count == 1 {
+			require.NoError(t, db.QueryRow(`SELECT id, type FROM interactions WHERE user_id = ? AND entity_id = ? AND entity_type = 'comment'`,
+				userID, comment.ID).Scan(&id, &interactionType))
+		}
+		return id, interactionType, count
+	}
+
+	result, err := repo.ToggleInteraction(userID, comment.ID, models.EntityTypeComment, models.Like)
+	require.NoError(t, err)
+	assert.Equal(t, models.Like, result)
+	likeID, interactionType, count := stored()
+	require.Equal(t, 1, count)
+	assert.Equal(t, models.Like, interactionType)
+
+	// Switching to a dislike rewrites the existing row rather than adding one
+	result, err = repo.ToggleInteraction(userID, comment.ID, models.EntityTypeComment, models.Dislike)
+	require.NoError(t, err)
+	assert.Equal(t, models.Dislike, result)
+	dislikeID, interactionType, count := stored()
+	require.Equal(t, 1, count)
+	assert.Equal(t, likeID, dislikeID)
+	assert.Equal(t, models.Dislike, interactionType)
+
+	result, err = repo.ToggleInteraction(userID, comment.ID, models.EntityTypeComment, models.Dislike)
+	require.NoError(t, err)
+	assert.Equal(t, models.InteractionType(0), result)
+	_, _, count = stored()
+	assert.Equal(t, 0, count)
+}
+
+func TestInteractionRepository_GetReceivedInteractions(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	aliceID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "bob", "bob@example.com", "hashedpassword")
+	require.NoError(t, err)
+	bobID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	postRepo := NewPostRepository(db)
+	commentRepo := NewCommentRepository(db)
+	repo := NewInteractionRepository(db)
+
+	alicePost := &models.Post{UserID: aliceID, Title: "Alice", Content: "Content"}
+	require.NoError(t, postRepo.Create(alicePost, nil))
+	bobPost := &models.Post{UserID: bobID, Title: "Bob", Content: "Content"}
+	require.NoError(t, postRepo.Create(bobPost, nil))
+	aliceComment := &Comment{PostID: bobPost.ID, UserID: aliceID, Content: "Nice"}
+	require.NoError(t, commentRepo.Create(aliceComment))
+
+	// Only bob's reactions to alice's content are hers to see; her own like
+	// and bob's reaction to his own post are left out
+	require.NoError(t, repo.AddInteraction(bobID, alicePost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(bobID, aliceComment.ID, models.EntityTypeComment, models.Dislike))
+	require.NoError(t, repo.AddInteraction(aliceID, alicePost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(aliceID, bobPost.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(bobID, bobPost.ID, models.EntityTypePost, models.Like))
+
+	details, total, err := repo.GetReceivedInteractions(aliceID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, details, 2)
+	for _, detail := range details {
+		assert.Equal(t, bobID, detail.ActorID)
+		assert.Equal(t, "bob", detail.ActorUsername)
+	}
+	assert.ElementsMatch(t,
+		[]models.EntityType{models.EntityTypePost, models.EntityTypeComment},
+		[]models.EntityType{details[0].EntityType, details[1].EntityType},
+	)
+
+	t.Run("Pagination", func(t *testing.T) {
+		details, total, err := repo.GetReceivedInteractions(aliceID, 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Len(t, details, 1)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		details, total, err := repo.GetReceivedInteractions(bobID, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, details, 1)
+		assert.Equal(t, aliceID, details[0].ActorID)
+		assert.Equal(t, bobPost.ID, details[0].EntityID)
+	})
+}
+
+func TestInteractionRepository_GetUserInteractionSummaryDislikes(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	authorID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "critic", "critic@example.com", "hashedpassword")
+	require.NoError(t, err)
+	criticID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	postRepo := NewPostRepository(db)
+	commentRepo := NewCommentRepository(db)
+	repo := NewInteractionRepository(db)
+
+	var postIDs []int64
+	for i := 0; i < 4; i++ {
+		post := &models.Post{UserID: authorID, Title: fmt.Sprintf("Take %d", i), Content: "Content"}
+		require.NoError(t, postRepo.Create(post, nil))
+		postIDs = append(postIDs, post.ID)
+	}
+	comment := &Comment{PostID: postIDs[0], UserID: authorID, Content: "Hot take"}
+	require.NoError(t, commentRepo.Create(comment))
+
+	// Dislikes on two posts and a comment, a like switched to a dislike,
+	// and a dislike that was taken back
+	require.NoError(t, repo.AddInteraction(criticID, postIDs[0], models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.AddInteraction(criticID, postIDs[1], models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.AddInteraction(criticID, comment.ID, models.EntityTypeComment, models.Dislike))
+	require.NoError(t, repo.AddInteraction(criticID, postIDs[2], models.EntityTypePost, models.Like))
+	require.NoError(t, repo.AddInteraction(criticID, postIDs[2], models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.AddInteraction(criticID, postIDs[3], models.EntityTypePost, models.Dislike))
+	require.NoError(t, repo.RemoveInteraction(criticID, postIDs[3], models.EntityTypePost))
+
+	likesGiven, dislikesGiven, _, err := repo.GetUserInteractionSummary(criticID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, likesGiven)
+	assert.Equal(t, 4, dislikesGiven)
+
+	// Dislikes received do not count as given by the author
+	_, dislikesGiven, _, err = repo.GetUserInteractionSummary(authorID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dislikesGiven)
+}
+
+func TestInteractionRepository_EntityTypeConstraint(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	insert := `INSERT INTO interactions (user_id, entity_id, entity_type, type) VALUES (?, ?, ?, ?)`
+
+	for _, entityType := range []models.EntityType{models.EntityTypePost, models.EntityTypeComment} {
+		_, err := db.Exec(insert, userID, 1, entityType, models.Like)
+		assert.NoError(t, err, entityType)
+	}
+
+	// Writes that skip the handler checks are still rejected by the table
+	_, err := db.Exec(insert, userID, 2, "banana", models.Like)
+	assert.ErrorContains(t, err, "CHECK constraint failed")
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
 	"forum/models"
 )
 
@@ -10,93 +9,90 @@ import (
 type LikeRepositoryInterface interface {
 	CreateLike(like *models.Interaction) error
 	DeleteLike(userID, postID int) error
-	GetLikesByPostID(postID int) ([]models.Interaction, error)
+	GetLikesByPostID(postID, page, limit int) ([]models.Interaction, int, error)
 	UserHasLikedPost(userID, postID int) (bool, error)
-	AddInteraction(userID, entityID int64, entityType string, interactionType models.InteractionType) error
-	GetInteractionCounts(entityID int64, entityType string) (likes, dislikes int, err error)
-	RemoveInteraction(userID, entityID int64, entityType string) error
+	AddInteraction(userID, entityID int64, entityType models.EntityType, interactionType models.InteractionType) error
+	GetInteractionCounts(entityID int64, entityType models.EntityType) (likes, dislikes int, err error)
+	RemoveInteraction(userID, entityID int64, entityType models.EntityType) error
 }
 
-// LikeRepository implements the LikeRepositoryInterface
+// LikeRepository implements the LikeRepositoryInterface as a post-like view
+// of the interactions table, so likes made through either repository agree
+// and keep the post counters in step
 type LikeRepository struct {
-	db *sql.DB
+	*InteractionRepository
 }
 
 // NewLikeRepository creates a new instance of LikeRepository
 func NewLikeRepository(db *sql.DB) LikeRepositoryInterface {
-	return &LikeRepository{db: db}
+	return &LikeRepository{InteractionRepository: NewInteractionRepository(db)}
 }
 
-// CreateLike adds a new like to the database
+// CreateLike likes a post for the user, replacing a dislike if there was one
 func (r *LikeRepository) CreateLike(like *models.Interaction) error {
-	query := `INSERT INTO likes (user_id, post_id) VALUES (?, ?)`
-	_, err := r.db.Exec(query, like.UserID, like.EntityID)
-	return err
+	return r.AddInteraction(like.UserID, like.EntityID, models.EntityTypePost, models.Like)
 }
 
-// DeleteLike removes a like from the database
+// DeleteLike removes the user's like from a post; a dislike is left in place
 func (r *LikeRepository) DeleteLike(userID, postID int) error {
-	query := `DELETE FROM likes WHERE user_id = ? AND post_id = ?`
-	_, err := r.db.Exec(query, userID, postID)
-	return err
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM interactions
+								WHERE user_id = ? AND entity_id = ? AND entity_type = 'post' AND type = ?`,
+			userID, postID, models.Like)
+		if err != nil {
+			return err
+		}
+		if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+			return err
+		}
+		return adjustPostCount(tx, int64(postID), models.Like, -1)
+	})
 }
 
-// GetLikesByPostID retrieves all likes for a specific post
-func (r *LikeRepository) GetLikesByPostID(postID int) ([]models.Interaction, error) {
-	query := `SELECT id, user_id, post_id FROM likes WHERE post_id = ?`
-	rows, err := r.db.Query(query, postID)
+// GetLikesByPostID retrieves one page of likes for a specific post along with
+// the total number of likes
+func (r *LikeRepository) GetLikesByPostID(postID, page, limit int) ([]models.Interaction, int, error) {
+	offset := (page - 1) * limit
+
+	// First, get total count of likes
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM interactions WHERE entity_type = 'post' AND entity_id = ? AND type = ?`
+	err := r.reader.QueryRow(countQuery, postID, models.Like).Scan(&totalCount)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// Then, get paginated likes
+	query := `SELECT id, user_id, entity_id, created_at FROM interactions
+			  WHERE entity_type = 'post' AND entity_id = ? AND type = ?
+			  ORDER BY id
+			  LIMIT ? OFFSET ?`
+	rows, err := r.reader.Query(query, postID, models.Like, limit, offset)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var likes []models.Interaction
 	for rows.Next() {
-		var like models.Interaction
-		err := rows.Scan(&like.ID, &like.UserID, &like.EntityID)
+		like := models.Interaction{EntityType: models.EntityTypePost, Type: models.Like}
+		err := rows.Scan(&like.ID, &like.UserID, &like.EntityID, &like.CreatedAt)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		likes = append(likes, like)
 	}
-	return likes, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return likes, totalCount, nil
 }
 
 // UserHasLikedPost checks if a user has already liked a post
 func (r *LikeRepository) UserHasLikedPost(userID, postID int) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM likes WHERE user_id = ? AND post_id = ?)`
+	query := `SELECT EXISTS(SELECT 1 FROM interactions
+			  WHERE user_id = ? AND entity_type = 'post' AND entity_id = ? AND type = ?)`
 	var exists bool
-	err := r.db.QueryRow(query, userID, postID).Scan(&exists)
+	err := r.reader.QueryRow(query, userID, postID, models.Like).Scan(&exists)
 	return exists, err
 }
-
-// AddInteraction adds a new interaction to the database
-func (r *LikeRepository) AddInteraction(userID, entityID int64, entityType string, interactionType models.InteractionType) error {
-	query := `
-		INSERT INTO interactions (user_id, entity_id, entity_type, type)
-		VALUES (?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE type = ?
-	`
-	_, err := r.db.Exec(query, userID, entityID, entityType, interactionType, interactionType)
-	return err
-}
-
-// GetInteractionCounts retrieves the interaction counts for a specific entity
-func (r *LikeRepository) GetInteractionCounts(entityID int64, entityType string) (likes, dislikes int, err error) {
-	query := `SELECT COUNT(*) FROM likes WHERE post_id = ?`
-	err = r.db.QueryRow(query, entityID).Scan(&likes)
-	if err != nil {
-		return 0, 0, fmt.Errorf("error getting interaction counts: %v", err)
-	}
-	return likes, 0, nil
-}
-
-// RemoveInteraction removes an interaction from the database
-func (r *LikeRepository) RemoveInteraction(userID, entityID int64, entityType string) error {
-	query := `DELETE FROM likes WHERE user_id = ? AND post_id = ?`
-	_, err := r.db.Exec(query, userID, entityID)
-	if err != nil {
-		return fmt.Errorf("error removing interaction: %v", err)
-	}
-	return nil
-}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLikeRepository_GetLikesByPostID(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	authorID := setupTestUser(t, db)
+	post := &models.Post{UserID: authorID, Title: "Viral", Content: "Everyone likes this"}
+	require.NoError(t, NewPostRepository(db).Create(post, nil))
+
+	repo := NewLikeRepository(db)
+
+	// Five distinct users like the post
+	var likerIDs []int64
+	for i := 0; i < 5; i++ {
+		result, err := db.Exec(
+			"INSERT INTO users (username, email, password) VALUES (?, ?, ?)",
+			fmt.Sprintf("liker%d", i), fmt.Sprintf("liker%d@example.com", i), "hashedpassword",
+		)
+		require.NoError(t, err)
+		likerID, err := result.LastInsertId()
+		require.NoError(t, err)
+		likerIDs = append(likerIDs, likerID)
+
+		require.NoError(t, repo.CreateLike(&models.Interaction{UserID: likerID, EntityID: post.ID}))
+	}
+
+	testCases := []struct {
+		name          string
+		page          int
+		limit         int
+		expectedUsers []int64
+	}{
+		{name: "First Page", page: 1, limit: 2, expectedUsers: likerIDs[0:2]},
+		{name: "Second Page", page: 2, limit: 2, expectedUsers: likerIDs[2:4]},
+		{name: "Partial Last Page", page: 3, limit: 2, expectedUsers: likerIDs[4:5]},
+		{name: "Past The End", page: 4, limit: 2, expectedUsers: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			likes, total, err := repo.GetLikesByPostID(int(post.ID), tc.page, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, 5, total)
+
+			var userIDs []int64
+			for _, like := range likes {
+				assert.Equal(t, post.ID, like.EntityID)
+				userIDs = append(userIDs, like.UserID)
+			}
+			assert.Equal(t, tc.expectedUsers, userIDs)
+		})
+	}
+}
+
+func TestLikeRepository_SharesInteractions(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	postRepo := NewPostRepository(db)
+	var posts []*models.Post
+	for _, title := range []string{"Liked here", "Liked there", "Disliked", "Ignored"} {
+		post := &models.Post{UserID: userID, Title: title, Content: "Content"}
+		require.NoError(t, postRepo.Create(post, nil))
+		posts = append(posts, post)
+	}
+
+	likes := NewLikeRepository(db)
+	interactions := NewInteractionRepository(db)
+	require.NoError(t, likes.CreateLike(&models.Interaction{UserID: userID, EntityID: posts[0].ID}))
+	require.NoError(t, interactions.AddInteraction(userID, posts[1].ID, models.EntityTypePost, models.Like))
+	require.NoError(t, interactions.AddInteraction(userID, posts[2].ID, models.EntityTypePost, models.Dislike))
+
+	likedIDs := func(t *testing.T) []int64 {
+		t.Helper()
+		liked, err := postRepo.GetLikedPosts(userID)
+		require.NoError(t, err)
+		var ids []int64
+		for _, post := range liked {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	t.Run("Both Writers Feed Liked Posts", func(t *testing.T) {
+		assert.ElementsMatch(t, []int64{posts[0].ID, posts[1].ID}, likedIDs(t))
+
+		hasLiked, err := likes.UserHasLikedPost(int(userID), int(posts[1].ID))
+		require.NoError(t, err)
+		assert.True(t, hasLiked)
+		hasLiked, err = likes.UserHasLikedPost(int(userID), int(posts[2].ID))
+		require.NoError(t, err)
+		assert.False(t, hasLiked)
+	})
+
+	t.Run("Delete Like Keeps Counters", func(t *testing.T) {
+		require.NoError(t, likes.DeleteLike(int(userID), int(posts[0].ID)))
+		assert.Equal(t, []int64{posts[1].ID}, likedIDs(t))
+
+		stored, err := postRepo.GetByID(fmt.Sprint(posts[0].ID), AnonymousViewer)
+		require.NoError(t, err)
+		assert.Zero(t, stored.LikeCount)
+	})
+
+	t.Run("Delete Like Leaves Dislike", func(t *testing.T) {
+		require.NoError(t, likes.DeleteLike(int(userID), int(posts[2].ID)))
+
+		likeCount, dislikeCount, err := interactions.GetInteractionCounts(posts[2].ID, models.EntityTypePost)
+		require.NoError(t, err)
+		assert.Equal(t, 0, likeCount)
+		assert.Equal(t, 1, dislikeCount)
+	})
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"forum/models"
+)
+
+// NotificationTypeComment is used when someone comments on a thread the
+// recipient takes part in
+const NotificationTypeComment = "comment"
+
+// NotificationTypeNewPost is used when a post is created in a category the
+// recipient follows
+const NotificationTypeNewPost = "new_post"
+
+// NotificationTypes lists every notification type a user can mute
+var NotificationTypes = []string{NotificationTypeComment, NotificationTypeNewPost}
+
+// ErrUnknownNotificationType is returned when a preference names a type that
+// is not in NotificationTypes
+var ErrUnknownNotificationType = errors.New("unknown notification type")
+
+// notificationBatchSize bounds the rows per INSERT so that a batch stays well
+// under SQLite's bound parameter limit
+const notificationBatchSize = 100
+
+type Notification struct {
+	ID         int64
+	UserID     int64 // recipient
+	ActorID    int64
+	Type       string
+	EntityType models.EntityType
+	EntityID   int64
+	Read       bool
+	RequestID  string // request that triggered the notification, for tracing
+	CreatedAt  time.Time
+}
+
+// NotificationRepositoryInterface defines the methods that a notification repository must implement
+type NotificationRepositoryInterface interface {
+	Create(notification *Notification) error
+	CreateBatch(notifications []Notification) error
+	GetByUserID(userID int64, limit int) ([]Notification, error)
+	GetPreferences(userID int64) (map[string]bool, error)
+	UpdatePreferences(userID int64, preferences map[string]bool) error
+}
+
+type NotificationRepository struct {
+	conn *sql.DB
+}
+
+func NewNotificationRepository(conn *sql.DB) *NotificationRepository {
+	return &NotificationRepository{conn: conn}
+}
+
+// Create stores a notification unless the recipient has muted its type, in
+// which case nothing is written and the ID is left at zero
+func (r *NotificationRepository) Create(notification *Notification) error {
+	var muted bool
+	err := r.conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM notification_preferences
+			  WHERE user_id = ? AND type = ? AND enabled = 0)`, notification.UserID, notification.Type).Scan(&muted)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return nil
+	}
+
+	query := `INSERT INTO notifications (user_id, actor_id, type, entity_type, entity_id, request_id, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	notification.CreatedAt = time.Now()
+	result, err := r.conn.Exec(query,
+		notification.UserID,
+		notification.ActorID,
+		notification.Type,
+		notification.EntityType,
+		notification.EntityID,
+		notification.RequestID,
+		notification.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	notification.ID = id
+	return nil
+}
+
+// CreateBatch inserts the notifications with multi-row INSERT statements inside
+// a single transaction, so either every notification is written or none are.
+// Notifications whose recipient has muted their type are skipped
+func (r *NotificationRepository) CreateBatch(notifications []Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		for start := 0; start < len(notifications); start += notificationBatchSize {
+			end := start + notificationBatchSize
+			if end > len(notifications) {
+				end = len(notifications)
+			}
+			chunk, err := filterMuted(tx, notifications[start:end])
+			if err != nil {
+				return err
+			}
+			if len(chunk) == 0 {
+				continue
+			}
+
+			placeholders := make([]string, len(chunk))
+			args := make([]interface{}, 0, len(chunk)*7)
+			for i, n := range chunk {
+				placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+				args = append(args, n.UserID, n.ActorID, n.Type, n.EntityType, n.EntityID, n.RequestID, now)
+			}
+
+			query := `INSERT INTO notifications (user_id, actor_id, type, entity_type, entity_id, request_id, created_at)
+					  VALUES ` + strings.Join(placeholders, ", ")
+			if _, err := tx.Exec(query, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// filterMuted drops the notifications whose recipient has muted their type
+func filterMuted(tx *sql.Tx, notifications []Notification) ([]Notification, error) {
+	placeholders := make([]string, len(notifications))
+	args := make([]interface{}, len(notifications))
+	for i, n := range notifications {
+		placeholders[i] = "?"
+		args[i] = n.UserID
+	}
+
+	rows, err := tx.Query(`SELECT user_id, type FROM notification_preferences
+			  WHERE enabled = 0 AND user_id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type mutedKey struct {
+		userID int64
+		kind   string
+	}
+	muted := make(map[mutedKey]bool)
+	for rows.Next() {
+		var key mutedKey
+		if err := rows.Scan(&key.userID, &key.kind); err != nil {
+			return nil, err
+		}
+		muted[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(muted) == 0 {
+		return notifications, nil
+	}
+
+	kept := make([]Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if !muted[mutedKey{n.UserID, n.Type}] {
+			kept = append(kept, n)
+		}
+	}
+	return kept, nil
+}
+
+// GetByUserID retrieves the most recent notifications for a recipient
+func (r *NotificationRepository) GetByUserID(userID int64, limit int) ([]Notification, error) {
+	query := `SELECT id, user_id, actor_id, type, entity_type, entity_id, is_read, request_id, created_at
+			  FROM notifications WHERE user_id = ?
+			  ORDER BY created_at DESC, id DESC
+			  LIMIT ?`
+
+	rows, err := r.conn.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		var n Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.ActorID,
+			&n.Type,
+			&n.EntityType,
+			&n.EntityID,
+			&n.Read,
+			&n.RequestID,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// GetPreferences reports whether each notification type is enabled for the
+// user; types without a stored preference default to enabled
+func (r *NotificationRepository) GetPreferences(userID int64) (map[string]bool, error) {
+	preferences := make(map[string]bool, len(NotificationTypes))
+	for _, kind := range NotificationTypes {
+		preferences[kind] = true
+	}
+
+	rows, err := r.conn.Query(`SELECT type, enabled FROM notification_preferences WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		if _, known := preferences[kind]; known {
+			preferences[kind] = enabled
+		}
+	}
+
+	return preferences, rows.Err()
+}
+
+// UpdatePreferences stores the given types' settings, leaving any type not
+// in the map unchanged
+func (r *NotificationRepository) UpdatePreferences(userID int64, preferences map[string]bool) error {
+	for kind := range preferences {
+		if !isNotificationType(kind) {
+			return ErrUnknownNotificationType
+		}
+	}
+
+	query := `INSERT INTO notification_preferences (user_id, type, enabled) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id, type) DO UPDATE SET enabled = excluded.enabled`
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		for kind, enabled := range preferences {
+			if _, err := tx.Exec(query, userID, kind, enabled); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func isNotificationType(kind string) bool {
+	for _, known := range NotificationTypes {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Ensure NotificationRepository implements the interface
+var _ NotificationRepositoryInterface = &NotificationRepository{}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"testing"
+
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRepository_CreateBatch(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	// Pin a single connection so changes() reports on the batch's statement
+	db.SetMaxOpenConns(1)
+
+	repo := NewNotificationRepository(db)
+
+	notifications := make([]Notification, 25)
+	for i := range notifications {
+		notifications[i] = Notification{
+			UserID:     int64(i + 1),
+			ActorID:    99,
+			Type:       NotificationTypeComment,
+			EntityType: models.EntityTypeComment,
+			EntityID:   7,
+			RequestID:  "host/abc-000001",
+		}
+	}
+
+	require.NoError(t, repo.CreateBatch(notifications))
+
+	// changes() counts rows modified by the most recent statement only
+	var lastStatementRows int
+	require.NoError(t, db.QueryRow(`SELECT changes()`).Scan(&lastStatementRows))
+	assert.Equal(t, len(notifications), lastStatementRows)
+
+	var total int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM notifications`).Scan(&total))
+	assert.Equal(t, len(notifications), total)
+
+	received, err := repo.GetByUserID(3, 10)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, int64(99), received[0].ActorID)
+	assert.Equal(t, int64(7), received[0].EntityID)
+	assert.False(t, received[0].Read)
+	assert.Equal(t, "host/abc-000001", received[0].RequestID)
+
+	t.Run("Empty Batch", func(t *testing.T) {
+		assert.NoError(t, repo.CreateBatch(nil))
+	})
+}
+
+func TestNotificationRepository_Preferences(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	repo := NewNotificationRepository(db)
+	const mutedUser, enabledUser = int64(1), int64(2)
+
+	t.Run("Defaults To All On", func(t *testing.T) {
+		preferences, err := repo.GetPreferences(mutedUser)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{NotificationTypeComment: true, NotificationTypeNewPost: true}, preferences)
+	})
+
+	require.NoError(t, repo.UpdatePreferences(mutedUser, map[string]bool{NotificationTypeComment: false}))
+
+	t.Run("Unknown Type", func(t *testing.T) {
+		err := repo.UpdatePreferences(mutedUser, map[string]bool{"follow": false})
+		assert.ErrorIs(t, err, ErrUnknownNotificationType)
+	})
+
+	t.Run("Muted Type Is Skipped", func(t *testing.T) {
+		require.NoError(t, repo.CreateBatch([]Notification{
+			{UserID: mutedUser, ActorID: 9, Type: NotificationTypeComment, EntityType: models.EntityTypeComment, EntityID: 1},
+			{UserID: mutedUser, ActorID: 9, Type: NotificationTypeNewPost, EntityType: models.EntityTypePost, EntityID: 2},
+			{UserID: enabledUser, ActorID: 9, Type: NotificationTypeComment, EntityType: models.EntityTypeComment, EntityID: 1},
+		}))
+
+		single := &Notification{UserID: mutedUser, ActorID: 9, Type: NotificationTypeComment, EntityType: models.EntityTypeComment, EntityID: 3}
+		require.NoError(t, repo.Create(single))
+		assert.Zero(t, single.ID)
+
+		received, err := repo.GetByUserID(mutedUser, 10)
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		assert.Equal(t, NotificationTypeNewPost, received[0].Type)
+
+		received, err = repo.GetByUserID(enabledUser, 10)
+		require.NoError(t, err)
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("Re-enable", func(t *testing.T) {
+		require.NoError(t, repo.UpdatePreferences(mutedUser, map[string]bool{NotificationTypeComment: true}))
+
+		preferences, err := repo.GetPreferences(mutedUser)
+		require.NoError(t, err)
+		assert.True(t, preferences[NotificationTypeComment])
+
+		single := &Notification{UserID: mutedUser, ActorID: 9, Type: NotificationTypeComment, EntityType: models.EntityTypeComment, EntityID: 4}
+		require.NoError(t, repo.Create(single))
+		assert.NotZero(t, single.ID)
+	})
+}
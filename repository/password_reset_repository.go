@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetTokenTTL is how long a reset token stays usable after it is issued
+const PasswordResetTokenTTL = time.Hour
+
+// ErrInvalidResetToken is returned for reset tokens that are unknown, already
+// used or expired
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// PasswordResetRepositoryInterface defines the methods for single-use password reset tokens
+type PasswordResetRepositoryInterface interface {
+	CreateResetToken(userID int) (string, error)
+	ConsumeResetToken(token string) (int, error)
+}
+
+type PasswordResetRepository struct {
+	conn *sql.DB
+}
+
+var _ PasswordResetRepositoryInterface = &PasswordResetRepository{}
+
+func NewPasswordResetRepository(conn *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{conn: conn}
+}
+
+// CreateResetToken issues a random token for the user that expires after
+// PasswordResetTokenTTL
+func (r *PasswordResetRepository) CreateResetToken(userID int) (string, error) {
+	token := uuid.New().String()
+	query := `INSERT INTO password_reset_tokens (token, user_id, expires_at) VALUES (?, ?, ?)`
+	if _, err := r.conn.Exec(query, token, userID, time.Now().Add(PasswordResetTokenTTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeResetToken returns the user a token was issued to and deletes it, so
+// each token works at most once. Expired tokens are deleted too, but rejected
+// with ErrInvalidResetToken
+func (r *PasswordResetRepository) ConsumeResetToken(token string) (int, error) {
+	var userID int
+	var expiresAt time.Time
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`SELECT user_id, expires_at FROM password_reset_tokens WHERE token = ?`, token).Scan(&userID, &expiresAt)
+		if err == sql.ErrNoRows {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		// A concurrent reset may have consumed the token since it was read
+		result, err := tx.Exec(`DELETE FROM password_reset_tokens WHERE token = ?`, token)
+		if err != nil {
+			return err
+		}
+		if deleted, err := result.RowsAffected(); err != nil {
+			return err
+		} else if deleted == 0 {
+			return ErrInvalidResetToken
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Checked after the commit so expired tokens are deleted as well
+	if !time.Now().Before(expiresAt) {
+		return 0, ErrInvalidResetToken
+	}
+	return userID, nil
+}
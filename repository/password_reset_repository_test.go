@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetRepository(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := int(setupTestUser(t, db))
+	repo := NewPasswordResetRepository(db)
+
+	t.Run("Consume Once", func(t *testing.T) {
+		token, err := repo.CreateResetToken(userID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+
+		consumedBy, err := repo.ConsumeResetToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, consumedBy)
+
+		_, err = repo.ConsumeResetToken(token)
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		_, err := db.Exec(`INSERT INTO password_reset_tokens (token, user_id, expires_at) VALUES (?, ?, ?)`,
+			"stale-token", userID, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		_, err = repo.ConsumeResetToken("stale-token")
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+
+		// The expired token is cleared rather than left behind
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM password_reset_tokens WHERE token = ?`, "stale-token").Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := repo.ConsumeResetToken("never-issued")
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("Issued With One Hour Expiry", func(t *testing.T) {
+		token, err := repo.CreateResetToken(userID)
+		require.NoError(t, err)
+
+		var expiresAt time.Time
+		require.NoError(t, db.QueryRow(`SELECT expires_at FROM password_reset_tokens WHERE token = ?`, token).Scan(&expiresAt))
+		assert.WithinDuration(t, time.Now().Add(PasswordResetTokenTTL), expiresAt, time.Minute)
+	})
+}
@@ -2,103 +2,224 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"forum/models"
+	"forum/pkg/slug"
 )
 
+// ErrPostNotFound is returned when a post lookup matches no rows
+var ErrPostNotFound = errors.New("post not found")
+
+// PostSort selects the ordering used when listing posts
+type PostSort string
+
+const (
+	PostSortNewest   PostSort = "newest"
+	PostSortOldest   PostSort = "oldest"
+	PostSortTrending PostSort = "trending"
+)
+
+// postSortOrder maps each supported PostSort to its ORDER BY clause
+var postSortOrder = map[PostSort]string{
+	PostSortNewest:   "p.created_at DESC",
+	PostSortOldest:   "p.created_at ASC",
+	PostSortTrending: "p.like_count DESC, p.created_at DESC",
+}
+
+// ParsePostSort validates a user or config supplied sort name
+func ParsePostSort(value string) (PostSort, error) {
+	sort := PostSort(value)
+	if _, ok := postSortOrder[sort]; !ok {
+		return "", fmt.Errorf("unknown post sort %q", value)
+	}
+	return sort, nil
+}
+
 type PostRepositoryInterface interface {
 	Create(post *models.Post, categoryIDs []int64) error
-	GetByID(postID string) (*models.Post, error)
-	ListPosts(page, limit int) ([]models.Post, int, error)
+	GetByID(postID string, viewer PostViewer) (*models.Post, error)
+	GetBySlug(slug string, viewer PostViewer) (*models.Post, error)
+	ListPosts(page, limit int, sort PostSort, excludeUserID *int64) ([]models.Post, int, error)
 	GetPostsByCategory(categoryID int64) ([]models.Post, error)
 	GetUserPosts(userID int64) ([]models.Post, error)
+	ListUserPosts(userID int64, includePending bool, page, limit int) ([]models.Post, int, error)
+	CountUserPosts(userID int64) (int, error)
+	GetPostCounts(postID int64, viewer PostViewer) (likes, dislikes, comments int, err error)
 	GetLikedPosts(userID int64) ([]models.Post, error)
+	GetCommentedPosts(userID int64, page, limit int) ([]models.Post, int, error)
+	GetPostsWithoutEngagement(page, limit int) ([]models.Post, int, error)
+	SearchPosts(query string, page, limit int) ([]models.Post, int, error)
+	FindByTitle(title string) ([]models.Post, error)
+	GetLatestPerCategory(perCategory int) (map[int64][]models.Post, error)
+	GetCategoryIDsForPosts(postIDs []int64) (map[int64][]int64, error)
+	ApproveBatch(ids []int64) ([]ApprovalResult, error)
+	GetPostsUpdatedSince(since time.Time, viewer PostViewer, page, limit int) ([]PostChange, int, error)
 	UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error
 	DeletePost(postID string, userID int64) error
 	FilterPosts(filters struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
-	}) ([]models.Post, error)
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
+	}, viewer PostViewer) ([]models.Post, error)
 }
 
 type PostRepository struct {
-	conn *sql.DB
+	conn       *sql.DB
+	reader     *sql.DB
+	totalCount *countCache
 }
 
 func NewPostRepository(conn *sql.DB) *PostRepository {
-	return &PostRepository{conn: conn}
+	return &PostRepository{
+		conn:       conn,
+		reader:     conn,
+		totalCount: &countCache{ttl: DefaultPostCountTTL},
+	}
 }
 
-func (r *PostRepository) Create(post *models.Post, categoryIDs []int64) error {
-	// Start a transaction
-	tx, err := r.conn.Begin()
-	if err != nil {
-		return err
+// WithReplica sends the repository's queries to a read-only replica; writes
+// stay on the primary. A nil replica keeps reads on the primary
+func (r *PostRepository) WithReplica(replica *sql.DB) *PostRepository {
+	if replica != nil {
+		r.reader = replica
 	}
+	return r
+}
+
+// WithCountTTL sets how long the total post count used by ListPosts is
+// cached; zero disables caching
+func (r *PostRepository) WithCountTTL(ttl time.Duration) *PostRepository {
+	r.totalCount = &countCache{ttl: ttl}
+	return r
+}
 
+func (r *PostRepository) Create(post *models.Post, categoryIDs []int64) error {
 	// Insert post
-	query := `INSERT INTO posts (user_id, title, content, created_at) 
-			  VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO posts (user_id, title, content, excerpt, status, slug, created_at, updated_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := tx.Exec(query, post.UserID, post.Title, post.Content, time.Now())
-	if err != nil {
-		tx.Rollback()
-		return err
+	if post.Status == "" {
+		post.Status = PostStatusApproved
 	}
+	now := time.Now()
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		postSlug, err := slug.Unique(tx, slug.Make(post.Title))
+		if err != nil {
+			return err
+		}
+		post.Slug = postSlug
 
-	// Get the ID of the newly inserted post
-	postID, err := result.LastInsertId()
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	post.ID = postID
+		result, err := tx.Exec(query, post.UserID, post.Title, post.Content, nullableExcerpt(post.Excerpt), post.Status, post.Slug, now, now)
+		if err != nil {
+			return err
+		}
 
-	// Insert post categories
-	categoryQuery := `INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)`
-	for _, categoryID := range categoryIDs {
-		_, err = tx.Exec(categoryQuery, postID, categoryID)
+		// Get the ID of the newly inserted post
+		postID, err := result.LastInsertId()
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
+		post.ID = postID
+
+		// Insert post categories
+		categoryQuery := `INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)`
+		for _, categoryID := range categoryIDs {
+			if _, err := tx.Exec(categoryQuery, postID, categoryID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.totalCount.invalidate()
+	return nil
+}
+
+// storedTime converts t to the zone post times are written in. SQLite
+// compares the stored timestamps as text, so a bound time in another zone,
+// such as the offset a client sent, would match the wrong posts
+func storedTime(t time.Time) time.Time {
+	return t.In(time.Local)
+}
+
+// updatedOrCreated returns when a post was last edited, treating posts with no
+// recorded edit time as unchanged since creation
+func updatedOrCreated(updatedAt sql.NullTime, createdAt time.Time) time.Time {
+	if updatedAt.Valid {
+		return updatedAt.Time
 	}
+	return createdAt
+}
 
-	// Commit the transaction
-	return tx.Commit()
+// nullableExcerpt stores an empty excerpt as NULL so listings fall back to
+// truncated content
+func nullableExcerpt(excerpt string) sql.NullString {
+	return sql.NullString{String: excerpt, Valid: excerpt != ""}
 }
 
-func (r *PostRepository) GetByID(postID string) (*models.Post, error) {
+// GetByID retrieves a post by ID, returning ErrPostNotFound when the viewer
+// may not see it
+func (r *PostRepository) GetByID(postID string, viewer PostViewer) (*models.Post, error) {
 	// Convert string ID to int64
 	id, err := strconv.ParseInt(postID, 10, 64)
 	if err != nil {
 		return nil, fmt.Errorf("invalid post ID: %v", err)
 	}
 
-	// Prepare SQL query
-	query := `SELECT id, user_id, title, content, created_at 
-			  FROM posts WHERE id = ?`
+	return r.getPost("p.id = ?", id, viewer)
+}
+
+// GetBySlug retrieves a post by the slug it was given at creation, returning
+// ErrPostNotFound when the viewer may not see it
+func (r *PostRepository) GetBySlug(slug string, viewer PostViewer) (*models.Post, error) {
+	return r.getPost("p.slug = ?", slug, viewer)
+}
+
+// getPost retrieves the post matching condition with its author and
+// categories, provided the viewer may see it
+func (r *PostRepository) getPost(condition string, arg interface{}, viewer PostViewer) (*models.Post, error) {
+	visible, visibleArgs := viewer.visibility()
+	query := `SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.excerpt, ''), COALESCE(p.slug, ''),
+				 p.created_at, p.updated_at, p.like_count, p.dislike_count, COALESCE(u.username, '')
+			  FROM posts p
+			  LEFT JOIN users u ON p.user_id = u.id
+			  WHERE ` + condition + ` AND ` + visible
 
 	post := &models.Post{}
-	err = r.conn.QueryRow(query, id).Scan(
+	var updatedAt sql.NullTime
+	err := r.reader.QueryRow(query, append([]interface{}{arg}, visibleArgs...)...).Scan(
 		&post.ID,
 		&post.UserID,
 		&post.Title,
 		&post.Content,
+		&post.Excerpt,
+		&post.Slug,
 		&post.CreatedAt,
+		&updatedAt,
+		&post.LikeCount,
+		&post.DislikeCount,
+		&post.Username,
 	)
+	if err == sql.ErrNoRows {
+		return nil, ErrPostNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	post.UpdatedAt = updatedOrCreated(updatedAt, post.CreatedAt)
 
 	// Fetch associated categories
 	categoryQuery := `SELECT category_id FROM post_categories WHERE post_id = ?`
-	rows, err := r.conn.Query(categoryQuery, id)
+	rows, err := r.reader.Query(categoryQuery, post.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,91 +238,174 @@ func (r *PostRepository) GetByID(postID string) (*models.Post, error) {
 	return post, nil
 }
 
-func (r *PostRepository) ListPosts(page, limit int) ([]models.Post, int, error) {
+// ListPosts retrieves one page of approved posts in the requested order,
+// falling back to newest first for an unknown sort. A non-nil excludeUserID
+// leaves out that user's own posts
+func (r *PostRepository) ListPosts(page, limit int, sort PostSort, excludeUserID *int64) ([]models.Post, int, error) {
 	offset := (page - 1) * limit
 
-	// First, get total count of posts
+	orderBy, ok := postSortOrder[sort]
+	if !ok {
+		orderBy = postSortOrder[PostSortNewest]
+	}
+
+	// Posts waiting in the moderation queue stay out of the feed
+	whereClause := " WHERE p.status = ?"
+	args := []interface{}{PostStatusApproved}
+	if excludeUserID != nil {
+		whereClause += " AND p.user_id != ?"
+		args = append(args, *excludeUserID)
+	}
+
+	// First, get total count of posts; the unfiltered total is cached
+	countPosts := func() (int, error) {
+		var count int
+		err := r.reader.QueryRow(`SELECT COUNT(*) FROM posts p`+whereClause, args...).Scan(&count)
+		return count, err
+	}
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM posts`
-	err := r.conn.QueryRow(countQuery).Scan(&totalCount)
+	var err error
+	if excludeUserID == nil {
+		totalCount, err = r.totalCount.get(countPosts)
+	} else {
+		totalCount, err = countPosts()
+	}
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Then, get paginated posts
-	query := `SELECT id, user_id, title, content, created_at 
-			  FROM posts 
-			  ORDER BY created_at DESC 
+	query := postPageQuery + whereClause + `
+			  ORDER BY ` + orderBy + `
+			  LIMIT ? OFFSET ?`
+
+	posts, err := r.queryPostPage(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, totalCount, nil
+}
+
+// ListUserPosts retrieves one page of a user's posts, newest first, along
+// with the total count. Posts still in the moderation queue are only included
+// when includePending is set, as for the author's own listing
+func (r *PostRepository) ListUserPosts(userID int64, includePending bool, page, limit int) ([]models.Post, int, error) {
+	offset := (page - 1) * limit
+
+	whereClause := " WHERE p.user_id = ?"
+	args := []interface{}{userID}
+	if !includePending {
+		whereClause += " AND p.status = ?"
+		args = append(args, PostStatusApproved)
+	}
+
+	var totalCount int
+	if err := r.reader.QueryRow(`SELECT COUNT(*) FROM posts p`+whereClause, args...).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	query := postPageQuery + whereClause + `
+			  ORDER BY ` + postSortOrder[PostSortNewest] + `
 			  LIMIT ? OFFSET ?`
 
-	rows, err := r.conn.Query(query, limit, offset)
+	posts, err := r.queryPostPage(query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
+	return posts, totalCount, nil
+}
+
+// postPageQuery selects the columns queryPostPage scans; callers append the
+// WHERE, ORDER BY and LIMIT clauses
+const postPageQuery = `SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.excerpt, ''), p.created_at,
+			  p.updated_at, p.like_count, p.dislike_count
+			  FROM posts p`
+
+// queryPostPage runs a postPageQuery and loads the categories of the posts
+// it returns in one further query
+func (r *PostRepository) queryPostPage(query string, args ...interface{}) ([]models.Post, error) {
+	rows, err := r.reader.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
+		var updatedAt sql.NullTime
 		err := rows.Scan(
 			&post.ID,
 			&post.UserID,
 			&post.Title,
 			&post.Content,
+			&post.Excerpt,
 			&post.CreatedAt,
+			&updatedAt,
+			&post.LikeCount,
+			&post.DislikeCount,
 		)
 		if err != nil {
-			return nil, 0, err
-		}
-
-		// Fetch categories for each post
-		categoryQuery := `SELECT category_id FROM post_categories WHERE post_id = ?`
-		categoryRows, err := r.conn.Query(categoryQuery, post.ID)
-		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		defer categoryRows.Close()
+		post.UpdatedAt = updatedOrCreated(updatedAt, post.CreatedAt)
 
-		var categoryIDs []int64
-		for categoryRows.Next() {
-			var categoryID int64
-			if err := categoryRows.Scan(&categoryID); err != nil {
-				return nil, 0, err
-			}
-			categoryIDs = append(categoryIDs, categoryID)
-		}
-		post.Categories = categoryIDs
+		// Listings add a preview, leaving the stored excerpt as it is
+		post.Preview = post.PreviewText()
 
 		posts = append(posts, post)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return posts, totalCount, nil
+	// Fetch the categories of the whole page in one query
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	categoryIDs, err := r.GetCategoryIDsForPosts(postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Categories = categoryIDs[posts[i].ID]
+	}
+
+	return posts, nil
 }
 
+// FilterPosts lists the posts matching every set filter that the viewer may
+// see, newest first
 func (r *PostRepository) FilterPosts(filters struct {
-	CategoryID  *int64
-	UserID      *int64
-	LikedByUser *int64
-},
+	CategoryID    *int64
+	UserID        *int64
+	LikedByUser   *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}, viewer PostViewer,
 ) ([]models.Post, error) {
-	// nolint:SA4006 // Conditions slice is used later in the function
-	var conditions []string
-	var args []interface{}
+	// Posts waiting for moderation are only listed for those allowed to see them
+	visible, visibleArgs := viewer.visibility()
+	conditions := []string{visible}
+	args := visibleArgs
 
-	// Base query with a more specific initial condition
+	// Base query with a more specific initial condition; a post in several
+	// categories is returned once, with their names joined
 	query := `
 		SELECT p.id, p.title, p.content, p.user_id, p.created_at, 
-		       u.username, c.name as category_name
+		       u.username, COALESCE(GROUP_CONCAT(c.name, ', '), '') as category_name
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		JOIN post_categories pc ON p.id = pc.post_id
-		JOIN categories c ON pc.category_id = c.id
+		LEFT JOIN post_categories pc ON p.id = pc.post_id
+		LEFT JOIN categories c ON pc.category_id = c.id
 		WHERE 1=1
 	`
 
 	// Add conditions based on filter parameters
 	if filters.CategoryID != nil {
-		conditions = append(conditions, "pc.category_id = ?")
+		// Matched apart from the join so the post keeps all its category names
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM post_categories fc WHERE fc.post_id = p.id AND fc.category_id = ?)")
 		args = append(args, *filters.CategoryID)
 	}
 
@@ -211,9 +415,20 @@ func (r *PostRepository) FilterPosts(filters struct {
 	}
 
 	if filters.LikedByUser != nil {
-		query += ` JOIN likes l ON p.id = l.post_id`
-		conditions = append(conditions, "l.user_id = ?")
-		args = append(args, *filters.LikedByUser)
+		// Likes are recorded as interactions, not in the legacy likes table
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM interactions i
+			WHERE i.entity_type = 'post' AND i.entity_id = p.id AND i.user_id = ? AND i.type = ?)`)
+		args = append(args, *filters.LikedByUser, models.Like)
+	}
+
+	if filters.CreatedAfter != nil {
+		conditions = append(conditions, "p.created_at >= ?")
+		args = append(args, storedTime(*filters.CreatedAfter))
+	}
+
+	if filters.CreatedBefore != nil {
+		conditions = append(conditions, "p.created_at <= ?")
+		args = append(args, storedTime(*filters.CreatedBefore))
 	}
 
 	// Modify query with conditions if any exist
@@ -223,10 +438,10 @@ func (r *PostRepository) FilterPosts(filters struct {
 	}
 
 	// Add ORDER BY to ensure consistent results
-	query += " ORDER BY p.created_at DESC"
+	query += " GROUP BY p.id ORDER BY p.created_at DESC"
 
 	// Execute query
-	rows, err := r.conn.Query(query, args...)
+	rows, err := r.reader.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -256,136 +471,647 @@ func (r *PostRepository) FilterPosts(filters struct {
 
 func (r *PostRepository) GetPostsByCategory(categoryID int64) ([]models.Post, error) {
 	return r.FilterPosts(struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
 	}{
 		CategoryID: &categoryID,
-	})
+	}, AnonymousViewer)
 }
 
+// GetUserPosts returns a user's approved posts, newest first
 func (r *PostRepository) GetUserPosts(userID int64) ([]models.Post, error) {
 	return r.FilterPosts(struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
 	}{
 		UserID: &userID,
-	})
+	}, AnonymousViewer)
+}
+
+// CountUserPosts counts a user's approved posts, leaving out those still
+// waiting for moderation
+func (r *PostRepository) CountUserPosts(userID int64) (int, error) {
+	var count int
+	err := r.reader.QueryRow(`SELECT COUNT(*) FROM posts WHERE user_id = ? AND status = ?`, userID, PostStatusApproved).Scan(&count)
+	return count, err
 }
 
+// GetPostCounts returns a post's like, dislike and comment counts in one
+// query, reading the denormalized reaction counters and skipping deleted
+// comments. It returns ErrPostNotFound for unknown posts and for posts the
+// viewer may not see
+func (r *PostRepository) GetPostCounts(postID int64, viewer PostViewer) (likes, dislikes, comments int, err error) {
+	visible, visibleArgs := viewer.visibility()
+	query := `SELECT p.like_count, p.dislike_count,
+			  (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL)
+			  FROM posts p WHERE p.id = ? AND ` + visible
+	err = r.reader.QueryRow(query, append([]interface{}{postID}, visibleArgs...)...).Scan(&likes, &dislikes, &comments)
+	if err == sql.ErrNoRows {
+		return 0, 0, 0, ErrPostNotFound
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return likes, dislikes, comments, nil
+}
+
+// GetLikedPosts returns the posts the user has liked, newest first. Besides
+// approved posts these can include the user's own posts awaiting moderation
 func (r *PostRepository) GetLikedPosts(userID int64) ([]models.Post, error) {
 	return r.FilterPosts(struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
 	}{
 		LikedByUser: &userID,
-	})
+	}, PostViewer{UserID: userID})
 }
 
-func (r *PostRepository) UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error {
-	// Start a transaction
-	tx, err := r.conn.Begin()
+// GetCommentedPosts retrieves one page of the distinct posts the user has
+// commented on, most recently commented first, along with the total count
+func (r *PostRepository) GetCommentedPosts(userID int64, page, limit int) ([]models.Post, int, error) {
+	offset := (page - 1) * limit
+
+	// First, get total count of distinct posts
+	var totalCount int
+	countQuery := `SELECT COUNT(DISTINCT post_id) FROM comments
+				   WHERE user_id = ? AND deleted_at IS NULL`
+	err := r.reader.QueryRow(countQuery, userID).Scan(&totalCount)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	// Update post query
-	query := `UPDATE posts 
-			  SET title = ?, content = ?, updated_at = ?
-			  WHERE id = ? AND user_id = ?`
+	// Then, get paginated posts ordered by the user's latest comment on each
+	query := `SELECT p.id, p.user_id, p.title, p.content, p.created_at
+			  FROM posts p
+			  JOIN (
+				  SELECT post_id, MAX(created_at) AS last_commented_at
+				  FROM comments
+				  WHERE user_id = ? AND deleted_at IS NULL
+				  GROUP BY post_id
+			  ) c ON c.post_id = p.id
+			  ORDER BY c.last_commented_at DESC
+			  LIMIT ? OFFSET ?`
 
-	// Execute update
-	result, err := tx.Exec(query,
-		post.Title,
-		post.Content,
-		post.UpdatedAt,
-		post.ID,
-		userID,
-	)
+	rows, err := r.reader.Query(query, userID, limit, offset)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, 0, err
 	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, totalCount, rows.Err()
+}
+
+// unengagedCondition matches posts with no reactions and no live comments
+const unengagedCondition = `
+	NOT EXISTS (SELECT 1 FROM interactions i
+		WHERE i.entity_type = 'post' AND i.entity_id = p.id)
+	AND NOT EXISTS (SELECT 1 FROM comments c
+		WHERE c.post_id = p.id AND c.deleted_at IS NULL)`
+
+// GetPostsWithoutEngagement lists posts nobody has liked, disliked or
+// commented on, newest first
+func (r *PostRepository) GetPostsWithoutEngagement(page, limit int) ([]models.Post, int, error) {
+	offset := (page - 1) * limit
 
-	rowsAffected, err := result.RowsAffected()
+	// First, get total count of unengaged posts
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM posts p WHERE` + unengagedCondition
+	err := r.reader.QueryRow(countQuery).Scan(&totalCount)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, 0, err
+	}
+
+	// Then, get paginated posts
+	query := `SELECT p.id, p.user_id, p.title, p.content, p.created_at
+			  FROM posts p
+			  WHERE` + unengagedCondition + `
+			  ORDER BY p.created_at DESC, p.id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := r.reader.Query(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("no rows updated: post not found or unauthorized")
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		posts = append(posts, post)
 	}
 
-	// Delete existing post categories
-	_, err = tx.Exec(`DELETE FROM post_categories WHERE post_id = ?`, post.ID)
+	return posts, totalCount, rows.Err()
+}
+
+// likePatternEscaper escapes LIKE wildcards so user input matches literally
+// when paired with ESCAPE '\'
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchCondition matches approved posts whose title or content contains the
+// pattern bound twice after it
+const searchCondition = ` p.status = 'approved'
+	AND (p.title LIKE ? ESCAPE '\' OR p.content LIKE ? ESCAPE '\')`
+
+// SearchPosts finds approved posts whose title or content contains query,
+// case-insensitively for ASCII, newest first along with the total count
+func (r *PostRepository) SearchPosts(query string, page, limit int) ([]models.Post, int, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, errors.New("search query cannot be empty")
+	}
+	offset := (page - 1) * limit
+	pattern := "%" + likePatternEscaper.Replace(query) + "%"
+
+	// First, get total count of matching posts
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM posts p WHERE` + searchCondition
+	err := r.reader.QueryRow(countQuery, pattern, pattern).Scan(&totalCount)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, 0, err
+	}
+
+	// Then, get paginated posts
+	selectQuery := `SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.excerpt, ''), p.created_at,
+					p.like_count, p.dislike_count
+					FROM posts p
+					WHERE` + searchCondition + `
+					ORDER BY p.created_at DESC, p.id DESC
+					LIMIT ? OFFSET ?`
+
+	rows, err := r.reader.Query(selectQuery, pattern, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.Excerpt,
+			&post.CreatedAt,
+			&post.LikeCount,
+			&post.DislikeCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		post.Preview = post.PreviewText()
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return posts, totalCount, nil
+}
+
+// maxTitleMatches caps how many posts FindByTitle returns
+const maxTitleMatches = 20
+
+// FindByTitle returns the newest approved posts whose title equals the given
+// one, ignoring case and surrounding whitespace, so authors can be warned
+// about duplicates before posting
+func (r *PostRepository) FindByTitle(title string) ([]models.Post, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+
+	query := `SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.excerpt, ''), p.created_at,
+				 p.like_count, p.dislike_count, COALESCE(u.username, '')
+			  FROM posts p
+			  LEFT JOIN users u ON p.user_id = u.id
+			  WHERE p.status = ? AND TRIM(p.title) = ? COLLATE NOCASE
+			  ORDER BY p.created_at DESC, p.id DESC
+			  LIMIT ?`
+
+	rows, err := r.reader.Query(query, PostStatusApproved, title, maxTitleMatches)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Insert new post categories
-	categoryQuery := `INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)`
-	for _, categoryID := range categoryIDs {
-		_, err = tx.Exec(categoryQuery, post.ID, categoryID)
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.Excerpt,
+			&post.CreatedAt,
+			&post.LikeCount,
+			&post.DislikeCount,
+			&post.Username,
+		)
 		if err != nil {
-			tx.Rollback()
-			return err
+			return nil, err
 		}
+		post.Preview = post.PreviewText()
+		posts = append(posts, post)
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return err
+	return posts, rows.Err()
+}
+
+// GetLatestPerCategory returns up to perCategory of the newest approved posts
+// in each category, keyed by category ID, ranking posts with a window function
+// so the whole homepage is served by one query
+func (r *PostRepository) GetLatestPerCategory(perCategory int) (map[int64][]models.Post, error) {
+	if perCategory <= 0 {
+		return nil, errors.New("perCategory must be positive")
 	}
 
-	return nil
+	query := `SELECT category_id, id, user_id, title, content, created_at
+			  FROM (
+				  SELECT pc.category_id, p.id, p.user_id, p.title, p.content, p.created_at,
+					  ROW_NUMBER() OVER (
+						  PARTITION BY pc.category_id
+						  ORDER BY p.created_at DESC, p.id DESC
+					  ) AS position
+				  FROM posts p
+				  JOIN post_categories pc ON pc.post_id = p.id
+				  WHERE p.status = ?
+			  )
+			  WHERE position <= ?
+			  ORDER BY category_id, position`
+
+	rows, err := r.reader.Query(query, PostStatusApproved, perCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	postsByCategory := make(map[int64][]models.Post)
+	for rows.Next() {
+		var categoryID int64
+		var post models.Post
+		err := rows.Scan(
+			&categoryID,
+			&post.ID,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		postsByCategory[categoryID] = append(postsByCategory[categoryID], post)
+	}
+
+	return postsByCategory, rows.Err()
 }
 
-func (r *PostRepository) DeletePost(postID string, userID int64) error {
-	// Convert string ID to int64
-	id, err := strconv.ParseInt(postID, 10, 64)
+// GetCategoryIDsForPosts loads the category IDs of many posts in one query,
+// keyed by post ID; posts without categories are absent from the map
+func (r *PostRepository) GetCategoryIDsForPosts(postIDs []int64) (map[int64][]int64, error) {
+	categoryIDs := make(map[int64][]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return categoryIDs, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(postIDs)), ",")
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+
+	query := `SELECT post_id, category_id FROM post_categories
+			  WHERE post_id IN (` + placeholders + `)
+			  ORDER BY post_id, category_id`
+
+	rows, err := r.reader.Query(query, args...)
 	if err != nil {
-		return fmt.Errorf("invalid post ID: %v", err)
+		return nil, err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID, categoryID int64
+		if err := rows.Scan(&postID, &categoryID); err != nil {
+			return nil, err
+		}
+		categoryIDs[postID] = append(categoryIDs[postID], categoryID)
+	}
+
+	return categoryIDs, rows.Err()
+}
+
+// Moderation states stored in posts.status
+const (
+	PostStatusPending  = "pending"
+	PostStatusApproved = "approved"
+)
+
+// PostViewer is who posts are read for. Posts waiting for moderation are
+// only shown to their author and to moderators
+type PostViewer struct {
+	UserID    int64 // zero for readers who are not logged in
+	Moderator bool
+}
 
-	// Start a transaction to ensure atomicity
-	tx, err := r.conn.Begin()
+// AnonymousViewer reads as a visitor who is not logged in, seeing approved
+// posts only
+var AnonymousViewer = PostViewer{}
+
+// visibility returns the condition limiting posts aliased p to those the
+// viewer may see, with its arguments
+func (v PostViewer) visibility() (string, []interface{}) {
+	if v.Moderator {
+		return "1=1", nil
+	}
+	return "(p.status = ? OR p.user_id = ?)", []interface{}{PostStatusApproved, v.UserID}
+}
+
+// Per-post outcomes reported by ApproveBatch
+const (
+	ApprovalApproved        = "approved"
+	ApprovalAlreadyApproved = "already_approved"
+	ApprovalNotFound        = "not_found"
+)
+
+// ApprovalResult is the outcome of approving one post in a batch
+type ApprovalResult struct {
+	PostID int64  `json:"post_id"`
+	Result string `json:"result"`
+}
+
+// ApproveBatch approves the given pending posts in one transaction and
+// reports what happened to each ID, in request order
+func (r *PostRepository) ApproveBatch(ids []int64) ([]ApprovalResult, error) {
+	results := make([]ApprovalResult, 0, len(ids))
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			var status string
+			err := tx.QueryRow(`SELECT status FROM posts WHERE id = ?`, id).Scan(&status)
+			switch {
+			case err == sql.ErrNoRows:
+				results = append(results, ApprovalResult{PostID: id, Result: ApprovalNotFound})
+				continue
+			case err != nil:
+				return fmt.Errorf("error loading post %d: %w", id, err)
+			case status == PostStatusApproved:
+				results = append(results, ApprovalResult{PostID: id, Result: ApprovalAlreadyApproved})
+				continue
+			}
+
+			// Moving updated_at puts the post into the sync feed, which
+			// left it out while it was pending
+			if _, err := tx.Exec(`UPDATE posts SET status = ?, updated_at = ? WHERE id = ?`, PostStatusApproved, time.Now(), id); err != nil {
+				return fmt.Errorf("error approving post %d: %w", id, err)
+			}
+			results = append(results, ApprovalResult{PostID: id, Result: ApprovalApproved})
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// Approved posts join the feed, so its cached total is stale
+	r.totalCount.invalidate()
+	return results, nil
+}
+
+// PostChange is one entry in the sync feed: a created or edited post, or a
+// tombstone for a deleted one
+type PostChange struct {
+	PostID    int64        `json:"post_id"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Deleted   bool         `json:"deleted"`
+	Post      *models.Post `json:"post,omitempty"`
+}
+
+// GetPostsUpdatedSince returns one page of the posts the viewer may see that
+// were created, edited or deleted after since, oldest change first, so sync
+// clients can resume from the last updated_at they saw
+func (r *PostRepository) GetPostsUpdatedSince(since time.Time, viewer PostViewer, page, limit int) ([]PostChange, int, error) {
+	offset := (page - 1) * limit
+	since = storedTime(since)
+	visible, visibleArgs := viewer.visibility()
+
+	var totalCount int
+	countQuery := `SELECT (SELECT COUNT(*) FROM posts p WHERE p.updated_at > ? AND ` + visible + `)
+					    + (SELECT COUNT(*) FROM post_tombstones WHERE deleted_at > ?)`
+	countArgs := append(append([]interface{}{since}, visibleArgs...), since)
+	if err := r.reader.QueryRow(countQuery, countArgs...).Scan(&totalCount); err != nil {
+		return nil, 0, err
 	}
 
-	// First, delete associated post categories
-	_, err = tx.Exec(`DELETE FROM post_categories WHERE post_id = ?`, id)
+	// The first SELECT fixes the column types, so tombstones reuse deleted_at
+	// for the time columns
+	query := `SELECT p.id, p.updated_at, 0, p.user_id, p.title, p.content, COALESCE(p.excerpt, ''),
+				     p.created_at, p.like_count, p.dislike_count
+			  FROM posts p WHERE p.updated_at > ? AND ` + visible + `
+			  UNION ALL
+			  SELECT post_id, deleted_at, 1, 0, '', '', '', deleted_at, 0, 0
+			  FROM post_tombstones WHERE deleted_at > ?
+			  ORDER BY 2, 1
+			  LIMIT ? OFFSET ?`
+	rows, err := r.reader.Query(query, append(countArgs, limit, offset)...)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var changes []PostChange
+	for rows.Next() {
+		var change PostChange
+		var post models.Post
+		err := rows.Scan(
+			&change.PostID,
+			&change.UpdatedAt,
+			&change.Deleted,
+			&post.UserID,
+			&post.Title,
+			&post.Content,
+			&post.Excerpt,
+			&post.CreatedAt,
+			&post.LikeCount,
+			&post.DislikeCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !change.Deleted {
+			post.ID = change.PostID
+			post.UpdatedAt = change.UpdatedAt
+			change.Post = &post
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return changes, totalCount, nil
+}
+
+func (r *PostRepository) UpdatePost(post *models.Post, categoryIDs []int64, userID int64) error {
+	// Update post query; updated_at only moves when the title or content
+	// actually changed, since SET expressions see the row's old values
+	query := `UPDATE posts 
+			  SET title = ?, content = ?, excerpt = ?,
+			      updated_at = CASE WHEN title IS NOT ? OR content IS NOT ? THEN ? ELSE updated_at END
+			  WHERE id = ? AND user_id = ?`
+
+	updatedAt := post.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
 	}
 
-	// Delete the post, but only if it belongs to the specified user
-	result, err := tx.Exec(`DELETE FROM posts WHERE id = ? AND user_id = ?`, id, userID)
+	return withTx(r.conn, func(tx *sql.Tx) error {
+		// Execute update
+		result, err := tx.Exec(query,
+			post.Title,
+			post.Content,
+			nullableExcerpt(post.Excerpt),
+			post.Title,
+			post.Content,
+			updatedAt,
+			post.ID,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("no rows updated: post not found or unauthorized")
+		}
+
+		// Delete existing post categories
+		if _, err := tx.Exec(`DELETE FROM post_categories WHERE post_id = ?`, post.ID); err != nil {
+			return err
+		}
+
+		// Insert new post categories
+		categoryQuery := `INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)`
+		for _, categoryID := range categoryIDs {
+			if _, err := tx.Exec(categoryQuery, post.ID, categoryID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PostRepository) DeletePost(postID string, userID int64) error {
+	// Convert string ID to int64
+	id, err := strconv.ParseInt(postID, 10, 64)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return fmt.Errorf("invalid post ID: %v", err)
 	}
 
-	// Check if any rows were actually deleted
-	rowsAffected, err := result.RowsAffected()
+	// Use a transaction to ensure atomicity
+	err = withTx(r.conn, func(tx *sql.Tx) error {
+		// First, delete associated post categories
+		if _, err := tx.Exec(`DELETE FROM post_categories WHERE post_id = ?`, id); err != nil {
+			return err
+		}
+
+		// Delete the post, but only if it belongs to the specified user
+		result, err := tx.Exec(`DELETE FROM posts WHERE id = ? AND user_id = ?`, id, userID)
+		if err != nil {
+			return err
+		}
+
+		// Check if any rows were actually deleted
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("no post found or unauthorized to delete")
+		}
+
+		// Leave a tombstone so sync clients learn about the deletion
+		_, err = tx.Exec(`INSERT OR REPLACE INTO post_tombstones (post_id, deleted_at) VALUES (?, ?)`, id, time.Now())
+		return err
+	})
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	r.totalCount.invalidate()
+	return nil
+}
 
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("no post found or unauthorized to delete")
-	}
+// PurgeDeleted drops what remains of posts deleted before olderThan: their
+// tombstones, and any category links and likes or dislikes left behind.
+// Posts are deleted outright, so only the tombstone lets sync clients learn
+// of a deletion; clients that last synced before olderThan will not. It
+// returns the number of tombstones removed
+func (r *PostRepository) PurgeDeleted(olderThan time.Time) (int64, error) {
+	const expired = `SELECT post_id FROM post_tombstones WHERE deleted_at < ?`
 
-	// Commit the transaction
-	return tx.Commit()
+	var purged int64
+	err := withTx(r.conn, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM post_categories WHERE post_id IN (`+expired+`)`, olderThan); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM interactions WHERE entity_type = ? AND entity_id IN (`+expired+`)`,
+			models.EntityTypePost, olderThan); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`DELETE FROM post_tombstones WHERE deleted_at < ?`, olderThan)
+		if err != nil {
+			return err
+		}
+		purged, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
 }
 
 var _ PostRepositoryInterface = &PostRepository{}
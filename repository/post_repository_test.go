@@ -2,19 +2,23 @@ package repository
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"forum/models"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	// Import the sqlite3 driver
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-func setupTestUser(t *testing.T, db *sql.DB) int64 {
+func setupTestUser(t testing.TB, db *sql.DB) int64 {
 	// Insert a test user
 	result, err := db.Exec(
 		"INSERT INTO users (username, email, password) VALUES (?, ?, ?)",
@@ -28,7 +32,7 @@ func setupTestUser(t *testing.T, db *sql.DB) int64 {
 	return userID
 }
 
-func setupTestCategories(t *testing.T, db *sql.DB) []int64 {
+func setupTestCategories(t testing.TB, db *sql.DB) []int64 {
 	// Insert test categories
 	categories := []string{"Technology", "Sports", "Music"}
 	var categoryIDs []int64
@@ -65,7 +69,7 @@ func TestPostRepository_Create(t *testing.T) {
 	assert.NotZero(t, post.ID)
 
 	// Verify post was created
-	createdPost, err := repo.GetByID(strconv.FormatInt(post.ID, 10))
+	createdPost, err := repo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
 	assert.NoError(t, err)
 	assert.Equal(t, post.Title, createdPost.Title)
 	assert.Equal(t, post.Content, createdPost.Content)
@@ -92,11 +96,84 @@ func TestPostRepository_GetByID(t *testing.T) {
 	postIDStr := strconv.FormatInt(post.ID, 10)
 
 	// Retrieve the post
-	retrievedPost, err := repo.GetByID(postIDStr)
+	retrievedPost, err := repo.GetByID(postIDStr, AnonymousViewer)
 	assert.NoError(t, err)
 	assert.NotNil(t, retrievedPost)
 	assert.Equal(t, post.Title, retrievedPost.Title)
 	assert.Equal(t, post.Content, retrievedPost.Content)
+
+	// The author's username comes along with the post
+	userID := setupTestUser(t, db)
+	authored := &models.Post{UserID: userID, Title: "Authored", Content: "By a real user"}
+	require.NoError(t, repo.Create(authored, nil))
+	retrievedPost, err = repo.GetByID(strconv.FormatInt(authored.ID, 10), AnonymousViewer)
+	require.NoError(t, err)
+	assert.Equal(t, "testuser", retrievedPost.Username)
+
+	_, err = repo.GetByID("999999", AnonymousViewer)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
+
+func TestPostRepository_PendingVisibility(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	authorID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "reader", "reader@example.com", "hashedpassword")
+	require.NoError(t, err)
+	readerID, err := result.LastInsertId()
+	require.NoError(t, err)
+	categoryIDs := setupTestCategories(t, db)
+
+	repo := NewPostRepository(db)
+	pending := &models.Post{UserID: authorID, Title: "Awaiting Review", Content: "Content", Status: PostStatusPending}
+	require.NoError(t, repo.Create(pending, categoryIDs[:1]))
+	postID := strconv.FormatInt(pending.ID, 10)
+
+	viewers := map[string]struct {
+		viewer  PostViewer
+		visible bool
+	}{
+		"Anonymous":  {AnonymousViewer, false},
+		"Other User": {PostViewer{UserID: readerID}, false},
+		"Author":     {PostViewer{UserID: authorID}, true},
+		"Moderator":  {PostViewer{UserID: readerID, Moderator: true}, true},
+	}
+	for name, tc := range viewers {
+		t.Run(name, func(t *testing.T) {
+			_, err := repo.GetByID(postID, tc.viewer)
+			_, slugErr := repo.GetBySlug(pending.Slug, tc.viewer)
+			if tc.visible {
+				assert.NoError(t, err)
+				assert.NoError(t, slugErr)
+			} else {
+				assert.ErrorIs(t, err, ErrPostNotFound)
+				assert.ErrorIs(t, slugErr, ErrPostNotFound)
+			}
+		})
+	}
+
+	t.Run("Listings", func(t *testing.T) {
+		byCategory, err := repo.GetPostsByCategory(categoryIDs[0])
+		require.NoError(t, err)
+		assert.Empty(t, byCategory)
+
+		byUser, err := repo.GetUserPosts(authorID)
+		require.NoError(t, err)
+		assert.Empty(t, byUser)
+
+		// Liking a post needs seeing it, so only the author's own likes can
+		// reach a pending post
+		_, err = db.Exec(`INSERT INTO interactions (user_id, entity_id, entity_type, type) VALUES (?, ?, 'post', ?), (?, ?, 'post', ?)`,
+			authorID, pending.ID, models.Like, readerID, pending.ID, models.Like)
+		require.NoError(t, err)
+		liked, err := repo.GetLikedPosts(authorID)
+		require.NoError(t, err)
+		assert.Len(t, liked, 1)
+		liked, err = repo.GetLikedPosts(readerID)
+		require.NoError(t, err)
+		assert.Empty(t, liked)
+	})
 }
 
 func TestPostRepository_ListPosts(t *testing.T) {
@@ -131,9 +208,12 @@ func TestPostRepository_ListPosts(t *testing.T) {
 	}
 
 	// List posts
-	listedPosts, _, err := repo.ListPosts(1, 10)
+	listedPosts, _, err := repo.ListPosts(1, 10, PostSortNewest, nil)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, len(listedPosts), 2)
+	for _, post := range listedPosts {
+		assert.Equal(t, []int64{categoryIDs[0]}, post.Categories)
+	}
 }
 
 func TestPostRepository_UpdatePost(t *testing.T) {
@@ -165,13 +245,56 @@ func TestPostRepository_UpdatePost(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Retrieve and verify the updated post
-	retrievedPost, err := repo.GetByID(strconv.FormatInt(post.ID, 10))
+	retrievedPost, err := repo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", retrievedPost.Title)
 	assert.Equal(t, "Updated Content", retrievedPost.Content)
 	assert.Equal(t, 1, len(retrievedPost.Categories))
 }
 
+func TestPostRepository_UpdatePostTimestamps(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	post := &models.Post{UserID: userID, Title: "Original Title", Content: "Original Content"}
+	require.NoError(t, repo.Create(post, nil))
+
+	// Pretend the post was written an hour ago
+	createdAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	_, err := db.Exec(`UPDATE posts SET created_at = ?, updated_at = ? WHERE id = ?`, createdAt, createdAt, post.ID)
+	require.NoError(t, err)
+
+	postID := strconv.FormatInt(post.ID, 10)
+	update := func(title, content, excerpt string) *models.Post {
+		edited := &models.Post{ID: post.ID, Title: title, Content: content, Excerpt: excerpt, UpdatedAt: time.Now()}
+		require.NoError(t, repo.UpdatePost(edited, nil, userID))
+		stored, err := repo.GetByID(postID, AnonymousViewer)
+		require.NoError(t, err)
+		return stored
+	}
+
+	t.Run("Unchanged Text Keeps Timestamp", func(t *testing.T) {
+		stored := update("Original Title", "Original Content", "Just an excerpt")
+		assert.True(t, stored.CreatedAt.Equal(createdAt))
+		assert.True(t, stored.UpdatedAt.Equal(createdAt), "updated_at moved to %v", stored.UpdatedAt)
+	})
+
+	t.Run("Edit Sets UpdatedAt", func(t *testing.T) {
+		stored := update("Edited Title", "Original Content", "Just an excerpt")
+		assert.True(t, stored.CreatedAt.Equal(createdAt))
+		assert.True(t, stored.UpdatedAt.After(createdAt))
+
+		posts, _, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.True(t, posts[0].CreatedAt.Equal(createdAt))
+		assert.True(t, posts[0].UpdatedAt.Equal(stored.UpdatedAt))
+	})
+}
+
 func TestPostRepository_DeletePost(t *testing.T) {
 	// Setup test database
 	db, cleanup := SetupTestDB(t)
@@ -194,8 +317,8 @@ func TestPostRepository_DeletePost(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Try to retrieve the deleted post
-	_, err = repo.GetByID(strconv.FormatInt(post.ID, 10))
-	assert.Error(t, err)
+	_, err = repo.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
+	assert.ErrorIs(t, err, ErrPostNotFound)
 }
 
 func TestPostRepository_GetUserPosts(t *testing.T) {
@@ -233,6 +356,82 @@ func TestPostRepository_GetUserPosts(t *testing.T) {
 	assert.Equal(t, 2, len(userPosts))
 }
 
+func TestPostRepository_ListUserPosts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "other", "other@example.com", "hashedpassword")
+	require.NoError(t, err)
+	otherID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	repo := NewPostRepository(db)
+	var approvedIDs []int64
+	for i := 0; i < 3; i++ {
+		post := &models.Post{UserID: userID, Title: fmt.Sprintf("Post %d", i), Content: "Content"}
+		require.NoError(t, repo.Create(post, nil))
+		approvedIDs = append(approvedIDs, post.ID)
+	}
+	pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+	require.NoError(t, repo.Create(pending, nil))
+	require.NoError(t, repo.Create(&models.Post{UserID: otherID, Title: "Someone else", Content: "Content"}, nil))
+
+	ids := func(posts []models.Post) []int64 {
+		var ids []int64
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	t.Run("Approved Pages", func(t *testing.T) {
+		posts, total, err := repo.ListUserPosts(userID, false, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []int64{approvedIDs[2], approvedIDs[1]}, ids(posts))
+
+		posts, _, err = repo.ListUserPosts(userID, false, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{approvedIDs[0]}, ids(posts))
+	})
+
+	t.Run("Including Pending", func(t *testing.T) {
+		posts, total, err := repo.ListUserPosts(userID, true, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 4, total)
+		assert.Equal(t, pending.ID, posts[0].ID)
+	})
+
+	t.Run("No Posts", func(t *testing.T) {
+		posts, total, err := repo.ListUserPosts(otherID+1, false, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, posts)
+	})
+}
+
+func TestPostRepository_CountUserPosts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, repo.Create(&models.Post{UserID: userID, Title: fmt.Sprintf("Post %d", i), Content: "Content"}, nil))
+	}
+	// Posts waiting for moderation are not counted
+	require.NoError(t, repo.Create(&models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}, nil))
+
+	count, err := repo.CountUserPosts(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = repo.CountUserPosts(userID + 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
 func TestPostRepository_FilterPosts(t *testing.T) {
 	// Setup test database
 	db, cleanup := SetupTestDB(t)
@@ -266,13 +465,886 @@ func TestPostRepository_FilterPosts(t *testing.T) {
 	// Filter posts by category
 	categoryID, _ := strconv.ParseInt(strconv.FormatInt(categoryIDs[0], 10), 10, 64)
 	filteredPosts, err := repo.FilterPosts(struct {
-		CategoryID  *int64
-		UserID      *int64
-		LikedByUser *int64
+		CategoryID    *int64
+		UserID        *int64
+		LikedByUser   *int64
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
 	}{
 		CategoryID: &categoryID,
-	})
+	}, AnonymousViewer)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(filteredPosts))
 	assert.Equal(t, "Tech Post", filteredPosts[0].Title)
 }
+
+func TestPostRepository_GetPostCounts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	authorID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "reader", "reader@example.com", "hashedpassword")
+	require.NoError(t, err)
+	readerID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	repo := NewPostRepository(db)
+	post := &models.Post{UserID: authorID, Title: "Counted", Content: "Content"}
+	require.NoError(t, repo.Create(post, nil))
+	other := &models.Post{UserID: authorID, Title: "Other", Content: "Content"}
+	require.NoError(t, repo.Create(other, nil))
+
+	interactionRepo := NewInteractionRepository(db)
+	require.NoError(t, interactionRepo.AddInteraction(authorID, post.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, interactionRepo.AddInteraction(readerID, post.ID, models.EntityTypePost, models.Dislike))
+	require.NoError(t, interactionRepo.AddInteraction(readerID, other.ID, models.EntityTypePost, models.Like))
+
+	commentRepo := NewCommentRepository(db)
+	for _, postID := range []int64{post.ID, post.ID, post.ID, other.ID} {
+		require.NoError(t, commentRepo.Create(&Comment{PostID: postID, UserID: readerID, Content: "Nice"}))
+	}
+	deleted := &Comment{PostID: post.ID, UserID: readerID, Content: "Oops"}
+	require.NoError(t, commentRepo.Create(deleted))
+	require.NoError(t, commentRepo.DeleteComment(deleted.ID, readerID))
+
+	likes, dislikes, comments, err := repo.GetPostCounts(post.ID, AnonymousViewer)
+	require.NoError(t, err)
+	assert.Equal(t, 1, likes)
+	assert.Equal(t, 1, dislikes)
+	assert.Equal(t, 3, comments)
+
+	t.Run("Unknown Post", func(t *testing.T) {
+		_, _, _, err := repo.GetPostCounts(other.ID+100, AnonymousViewer)
+		assert.ErrorIs(t, err, ErrPostNotFound)
+	})
+
+	t.Run("Pending Post", func(t *testing.T) {
+		pending := &models.Post{UserID: authorID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+		require.NoError(t, repo.Create(pending, nil))
+
+		for _, viewer := range []PostViewer{AnonymousViewer, {UserID: readerID}} {
+			_, _, _, err := repo.GetPostCounts(pending.ID, viewer)
+			assert.ErrorIs(t, err, ErrPostNotFound)
+		}
+		for _, viewer := range []PostViewer{{UserID: authorID}, {Moderator: true}} {
+			_, _, _, err := repo.GetPostCounts(pending.ID, viewer)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestPostRepository_ListPostsSort(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	older := &models.Post{UserID: userID, Title: "Older", Content: "Liked"}
+	require.NoError(t, repo.Create(older, nil))
+	newer := &models.Post{UserID: userID, Title: "Newer", Content: "Ignored"}
+	require.NoError(t, repo.Create(newer, nil))
+
+	require.NoError(t, NewInteractionRepository(db).AddInteraction(userID, older.ID, models.EntityTypePost, models.Like))
+
+	testCases := []struct {
+		sort        PostSort
+		expectedIDs []int64
+	}{
+		{sort: PostSortNewest, expectedIDs: []int64{newer.ID, older.ID}},
+		{sort: PostSortOldest, expectedIDs: []int64{older.ID, newer.ID}},
+		{sort: PostSortTrending, expectedIDs: []int64{older.ID, newer.ID}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.sort), func(t *testing.T) {
+			posts, total, err := repo.ListPosts(1, 10, tc.sort, nil)
+			require.NoError(t, err)
+			assert.Equal(t, 2, total)
+
+			var ids []int64
+			for _, post := range posts {
+				ids = append(ids, post.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestPostRepository_GetCommentedPosts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+	commentRepo := NewCommentRepository(db)
+
+	first := &models.Post{UserID: userID, Title: "First", Content: "Content"}
+	require.NoError(t, repo.Create(first, nil))
+	second := &models.Post{UserID: userID, Title: "Second", Content: "Content"}
+	require.NoError(t, repo.Create(second, nil))
+	untouched := &models.Post{UserID: userID, Title: "Untouched", Content: "Content"}
+	require.NoError(t, repo.Create(untouched, nil))
+
+	// Two comments on the first post, then one on the second
+	for _, postID := range []int64{first.ID, first.ID, second.ID} {
+		require.NoError(t, commentRepo.Create(&Comment{PostID: postID, UserID: userID, Content: "Me too"}))
+	}
+
+	posts, total, err := repo.GetCommentedPosts(userID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	var ids []int64
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	assert.Equal(t, []int64{second.ID, first.ID}, ids)
+}
+
+func TestPostRepository_ListPostsExcludeUser(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	viewerID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "author", "author@example.com", "hashedpassword")
+	require.NoError(t, err)
+	authorID, err := result.LastInsertId()
+	require.NoError(t, err)
+
+	repo := NewPostRepository(db)
+	for _, userID := range []int64{viewerID, authorID, viewerID, authorID} {
+		require.NoError(t, repo.Create(&models.Post{UserID: userID, Title: "Post", Content: "Content"}, nil))
+	}
+
+	posts, total, err := repo.ListPosts(1, 10, PostSortNewest, &viewerID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, posts, 2)
+	for _, post := range posts {
+		assert.Equal(t, authorID, post.UserID)
+	}
+
+	// Without the exclusion every post is listed
+	_, total, err = repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+}
+
+func TestPostRepository_GetPostsWithoutEngagement(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+	commentRepo := NewCommentRepository(db)
+	interactionRepo := NewInteractionRepository(db)
+
+	newPost := func(title string) *models.Post {
+		post := &models.Post{UserID: userID, Title: title, Content: "Content"}
+		require.NoError(t, repo.Create(post, nil))
+		return post
+	}
+	quiet := newPost("Quiet")
+	liked := newPost("Liked")
+	disliked := newPost("Disliked")
+	commented := newPost("Commented")
+	deletedComment := newPost("Deleted comment")
+	alsoQuiet := newPost("Also quiet")
+
+	require.NoError(t, interactionRepo.AddInteraction(userID, liked.ID, models.EntityTypePost, models.Like))
+	require.NoError(t, interactionRepo.AddInteraction(userID, disliked.ID, models.EntityTypePost, models.Dislike))
+	require.NoError(t, commentRepo.Create(&Comment{PostID: commented.ID, UserID: userID, Content: "Nice"}))
+
+	// A comment that was later deleted no longer counts as engagement
+	removed := &Comment{PostID: deletedComment.ID, UserID: userID, Content: "Oops"}
+	require.NoError(t, commentRepo.Create(removed))
+	require.NoError(t, commentRepo.DeleteComment(removed.ID, userID))
+
+	posts, total, err := repo.GetPostsWithoutEngagement(1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	var ids []int64
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	assert.Equal(t, []int64{alsoQuiet.ID, deletedComment.ID, quiet.ID}, ids)
+}
+
+func TestPostRepository_GetLatestPerCategory(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	tech, sports, music := categoryIDs[0], categoryIDs[1], categoryIDs[2]
+
+	repo := NewPostRepository(db)
+	var techPosts []int64
+	for i := 0; i < 4; i++ {
+		post := &models.Post{UserID: userID, Title: "Tech " + strconv.Itoa(i), Content: "Content"}
+		require.NoError(t, repo.Create(post, []int64{tech}))
+		techPosts = append(techPosts, post.ID)
+	}
+	crossover := &models.Post{UserID: userID, Title: "Crossover", Content: "Content"}
+	require.NoError(t, repo.Create(crossover, []int64{tech, sports}))
+
+	postsByCategory, err := repo.GetLatestPerCategory(2)
+	require.NoError(t, err)
+
+	ids := func(posts []models.Post) []int64 {
+		var ids []int64
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{crossover.ID, techPosts[3]}, ids(postsByCategory[tech]))
+	assert.Equal(t, []int64{crossover.ID}, ids(postsByCategory[sports]))
+	assert.NotContains(t, postsByCategory, music)
+
+	t.Run("Pending Posts", func(t *testing.T) {
+		approved := &models.Post{UserID: userID, Title: "Approved", Content: "Content"}
+		require.NoError(t, repo.Create(approved, []int64{music}))
+		pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+		require.NoError(t, repo.Create(pending, []int64{music}))
+
+		postsByCategory, err := repo.GetLatestPerCategory(2)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{approved.ID}, ids(postsByCategory[music]))
+	})
+}
+
+func TestPostRepository_GetCategoryIDsForPosts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	repo := NewPostRepository(db)
+
+	newPost := func(categories []int64) int64 {
+		post := &models.Post{UserID: userID, Title: "Post", Content: "Content"}
+		require.NoError(t, repo.Create(post, categories))
+		return post.ID
+	}
+	single := newPost([]int64{categoryIDs[1]})
+	multiple := newPost([]int64{categoryIDs[2], categoryIDs[0]})
+	uncategorized := newPost(nil)
+	notRequested := newPost([]int64{categoryIDs[0]})
+
+	grouped, err := repo.GetCategoryIDsForPosts([]int64{single, multiple, uncategorized})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64][]int64{
+		single:   {categoryIDs[1]},
+		multiple: {categoryIDs[0], categoryIDs[2]},
+	}, grouped)
+	assert.NotContains(t, grouped, notRequested)
+
+	t.Run("No Posts", func(t *testing.T) {
+		grouped, err := repo.GetCategoryIDsForPosts(nil)
+		require.NoError(t, err)
+		assert.Empty(t, grouped)
+	})
+}
+
+func TestPostRepository_ApproveBatch(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	var pending []int64
+	for i := 0; i < 3; i++ {
+		post := &models.Post{UserID: userID, Title: "Pending", Content: "Content"}
+		require.NoError(t, repo.Create(post, nil))
+		_, err := db.Exec(`UPDATE posts SET status = ? WHERE id = ?`, PostStatusPending, post.ID)
+		require.NoError(t, err)
+		pending = append(pending, post.ID)
+	}
+	approved := &models.Post{UserID: userID, Title: "Approved", Content: "Content"}
+	require.NoError(t, repo.Create(approved, nil))
+	missing := approved.ID + 100
+
+	results, err := repo.ApproveBatch([]int64{pending[0], approved.ID, pending[1], missing, pending[2]})
+	require.NoError(t, err)
+	assert.Equal(t, []ApprovalResult{
+		{PostID: pending[0], Result: ApprovalApproved},
+		{PostID: approved.ID, Result: ApprovalAlreadyApproved},
+		{PostID: pending[1], Result: ApprovalApproved},
+		{PostID: missing, Result: ApprovalNotFound},
+		{PostID: pending[2], Result: ApprovalApproved},
+	}, results)
+
+	var stillPending int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM posts WHERE status = ?`, PostStatusPending).Scan(&stillPending))
+	assert.Zero(t, stillPending)
+}
+
+func TestPostRepository_ListPostsExcerpt(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	longContent := strings.Repeat("word ", models.PreviewLength)
+	withExcerpt := &models.Post{UserID: userID, Title: "Excerpted", Content: longContent, Excerpt: "A hand-written summary"}
+	require.NoError(t, repo.Create(withExcerpt, nil))
+	withoutExcerpt := &models.Post{UserID: userID, Title: "Plain", Content: longContent}
+	require.NoError(t, repo.Create(withoutExcerpt, nil))
+
+	posts, _, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+
+	previews, excerpts := map[int64]string{}, map[int64]string{}
+	for _, post := range posts {
+		previews[post.ID] = post.Preview
+		excerpts[post.ID] = post.Excerpt
+	}
+	assert.Equal(t, "A hand-written summary", previews[withExcerpt.ID])
+	assert.Equal(t, longContent[:models.PreviewLength]+"...", previews[withoutExcerpt.ID])
+	// The excerpt is reported as stored, so clients can tell the two apart
+	assert.Equal(t, "A hand-written summary", excerpts[withExcerpt.ID])
+	assert.Empty(t, excerpts[withoutExcerpt.ID])
+
+	// Single post reads return only what the author wrote
+	stored, err := repo.GetByID(strconv.FormatInt(withoutExcerpt.ID, 10), AnonymousViewer)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Excerpt)
+	assert.Empty(t, stored.Preview)
+}
+
+func TestPostRepository_GetPostsUpdatedSince(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	unchanged := &models.Post{UserID: userID, Title: "Unchanged", Content: "Content"}
+	require.NoError(t, repo.Create(unchanged, nil))
+	edited := &models.Post{UserID: userID, Title: "Edited", Content: "Content"}
+	require.NoError(t, repo.Create(edited, nil))
+	deleted := &models.Post{UserID: userID, Title: "Deleted", Content: "Content"}
+	require.NoError(t, repo.Create(deleted, nil))
+
+	// Backdate the existing posts so they predate the sync point
+	longAgo := time.Now().Add(-time.Hour)
+	_, err := db.Exec(`UPDATE posts SET created_at = ?, updated_at = ?`, longAgo, longAgo)
+	require.NoError(t, err)
+	since := time.Now().Add(-time.Minute)
+
+	edited.Title = "Edited again"
+	edited.UpdatedAt = time.Now()
+	require.NoError(t, repo.UpdatePost(edited, nil, userID))
+	require.NoError(t, repo.DeletePost(strconv.FormatInt(deleted.ID, 10), userID))
+	created := &models.Post{UserID: userID, Title: "Created", Content: "Content"}
+	require.NoError(t, repo.Create(created, nil))
+
+	changes, total, err := repo.GetPostsUpdatedSince(since, AnonymousViewer, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, changes, 3)
+
+	// Oldest change first
+	assert.Equal(t, edited.ID, changes[0].PostID)
+	assert.False(t, changes[0].Deleted)
+	require.NotNil(t, changes[0].Post)
+	assert.Equal(t, "Edited again", changes[0].Post.Title)
+
+	assert.Equal(t, deleted.ID, changes[1].PostID)
+	assert.True(t, changes[1].Deleted)
+	assert.Nil(t, changes[1].Post)
+
+	assert.Equal(t, created.ID, changes[2].PostID)
+	assert.False(t, changes[2].Deleted)
+
+	t.Run("Pagination", func(t *testing.T) {
+		page, total, err := repo.GetPostsUpdatedSince(since, AnonymousViewer, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, page, 1)
+		assert.Equal(t, created.ID, page[0].PostID)
+	})
+
+	t.Run("Nothing Newer", func(t *testing.T) {
+		page, total, err := repo.GetPostsUpdatedSince(time.Now().Add(time.Minute), AnonymousViewer, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, page)
+	})
+
+	t.Run("Since In Another Zone", func(t *testing.T) {
+		for _, offset := range []int{2, -5} {
+			zone := time.FixedZone("", offset*60*60)
+			page, total, err := repo.GetPostsUpdatedSince(since.In(zone), AnonymousViewer, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 3, total, "since %s", since.In(zone))
+			assert.Len(t, page, 3)
+		}
+	})
+
+	t.Run("Pending Posts", func(t *testing.T) {
+		pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+		require.NoError(t, repo.Create(pending, nil))
+		ids := func(changes []PostChange) []int64 {
+			var out []int64
+			for _, change := range changes {
+				out = append(out, change.PostID)
+			}
+			return out
+		}
+
+		page, total, err := repo.GetPostsUpdatedSince(since, AnonymousViewer, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.NotContains(t, ids(page), pending.ID)
+
+		otherUser := PostViewer{UserID: userID + 1}
+		page, _, err = repo.GetPostsUpdatedSince(since, otherUser, 1, 10)
+		require.NoError(t, err)
+		assert.NotContains(t, ids(page), pending.ID)
+
+		for _, viewer := range []PostViewer{{UserID: userID}, {Moderator: true}} {
+			page, total, err = repo.GetPostsUpdatedSince(since, viewer, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 4, total)
+			assert.Contains(t, ids(page), pending.ID)
+		}
+
+		// Approval moves the post into the feed after the latest sync point
+		afterCreate := time.Now()
+		_, err = repo.ApproveBatch([]int64{pending.ID})
+		require.NoError(t, err)
+		page, _, err = repo.GetPostsUpdatedSince(afterCreate, AnonymousViewer, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{pending.ID}, ids(page))
+	})
+}
+
+func TestPostRepository_ListPostsCountCache(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db).WithCountTTL(time.Hour)
+
+	first := &models.Post{UserID: userID, Title: "First", Content: "Content"}
+	require.NoError(t, repo.Create(first, nil))
+
+	_, total, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// A row written behind the repository's back is not counted until the
+	// cache is invalidated
+	_, err = db.Exec(`INSERT INTO posts (user_id, title, content, created_at) VALUES (?, 'Direct', 'Content', ?)`, userID, time.Now())
+	require.NoError(t, err)
+	_, total, err = repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "total should be served from cache")
+
+	second := &models.Post{UserID: userID, Title: "Second", Content: "Content"}
+	require.NoError(t, repo.Create(second, nil))
+	_, total, err = repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	require.NoError(t, repo.DeletePost(strconv.FormatInt(first.ID, 10), userID))
+	_, total, err = repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	t.Run("Filtered Totals Are Not Cached", func(t *testing.T) {
+		otherUser := userID + 1
+		_, total, err := repo.ListPosts(1, 10, PostSortNewest, &otherUser)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+
+		_, total, err = repo.ListPosts(1, 10, PostSortNewest, &userID)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+}
+
+func TestPostRepository_ListPostsHidesPending(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	approved := &models.Post{UserID: userID, Title: "Approved", Content: "Content"}
+	require.NoError(t, repo.Create(approved, nil))
+	pending := &models.Post{UserID: userID, Title: "Pending", Content: "Content", Status: PostStatusPending}
+	require.NoError(t, repo.Create(pending, nil))
+
+	assert.Equal(t, PostStatusApproved, approved.Status)
+
+	posts, total, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, posts, 1)
+	assert.Equal(t, approved.ID, posts[0].ID)
+
+	results, err := repo.ApproveBatch([]int64{pending.ID})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	posts, total, err = repo.ListPosts(1, 10, PostSortNewest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, posts, 2)
+}
+
+// countingDriver wraps the sqlite3 driver and counts the statements prepared
+// through it; its connections hide the driver's direct query interfaces, so
+// database/sql prepares every query and exec
+type countingDriver struct {
+	sqlite3.SQLiteDriver
+	queries int64
+}
+
+func (d *countingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, driver: d}, nil
+}
+
+type countingConn struct {
+	driver.Conn
+	driver *countingDriver
+}
+
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	atomic.AddInt64(&c.driver.queries, 1)
+	return c.Conn.Prepare(query)
+}
+
+var queryCounter = &countingDriver{}
+
+func init() {
+	sql.Register("sqlite3_counting", queryCounter)
+}
+
+func BenchmarkPostRepository_ListPosts(b *testing.B) {
+	db, cleanup := SetupTestDB(b)
+	defer cleanup()
+
+	userID := setupTestUser(b, db)
+	categoryIDs := setupTestCategories(b, db)
+	seedRepo := NewPostRepository(db)
+	for i := 0; i < 100; i++ {
+		post := &models.Post{UserID: userID, Title: fmt.Sprintf("Post %d", i), Content: "Content"}
+		require.NoError(b, seedRepo.Create(post, categoryIDs[:2]))
+	}
+
+	// A second handle on the same shared in-memory database counts the
+	// statements ListPosts issues
+	counted, err := sql.Open("sqlite3_counting", fmt.Sprintf("file:%s?mode=memory&cache=shared", b.Name()))
+	require.NoError(b, err)
+	defer counted.Close()
+	repo := NewPostRepository(counted)
+
+	atomic.StoreInt64(&queryCounter.queries, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		posts, _, err := repo.ListPosts(1, 100, PostSortNewest, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(posts) != 100 || len(posts[0].Categories) != 2 {
+			b.Fatalf("unexpected page: %d posts", len(posts))
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(atomic.LoadInt64(&queryCounter.queries))/float64(b.N), "queries/op")
+}
+
+func TestPostRepository_FindByTitle(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	original := &models.Post{UserID: userID, Title: "Best Go Books", Content: "Share your favourites"}
+	require.NoError(t, repo.Create(original, nil))
+	for _, post := range []*models.Post{
+		{UserID: userID, Title: "Best Go Books of 2024", Content: "Only a prefix match"},
+		{UserID: userID, Title: "best go books", Content: "Awaiting moderation", Status: PostStatusPending},
+	} {
+		require.NoError(t, repo.Create(post, nil))
+	}
+
+	for _, title := range []string{"Best Go Books", "BEST GO BOOKS", "  best go books  "} {
+		posts, err := repo.FindByTitle(title)
+		require.NoError(t, err)
+		require.Len(t, posts, 1, title)
+		assert.Equal(t, original.ID, posts[0].ID)
+		assert.Equal(t, "testuser", posts[0].Username)
+	}
+
+	posts, err := repo.FindByTitle("Best Go")
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	_, err = repo.FindByTitle("   ")
+	assert.Error(t, err)
+}
+
+func TestPostRepository_SearchPosts(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	seed := []*models.Post{
+		{UserID: userID, Title: "Learning Gophers", Content: "A beginner's diary"},
+		{UserID: userID, Title: "Weekend plans", Content: "Hiking with other gophers"},
+		{UserID: userID, Title: "Gophers everywhere", Content: "Why gophers took over"},
+		{UserID: userID, Title: "Discounts", Content: "Everything is 50% off"},
+		{UserID: userID, Title: "Unrelated", Content: "Nothing to see here"},
+		{UserID: userID, Title: "Gophers in review", Content: "Awaiting moderation", Status: PostStatusPending},
+	}
+	for _, post := range seed {
+		require.NoError(t, repo.Create(post, nil))
+	}
+
+	titles := func(posts []models.Post) []string {
+		var out []string
+		for _, post := range posts {
+			out = append(out, post.Title)
+		}
+		return out
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedTitles []string
+	}{
+		{name: "Title Only", query: "learning", expectedTitles: []string{"Learning Gophers"}},
+		{name: "Content Only", query: "hiking", expectedTitles: []string{"Weekend plans"}},
+		{
+			name:           "Title Or Content",
+			query:          "gophers",
+			expectedTitles: []string{"Gophers everywhere", "Weekend plans", "Learning Gophers"},
+		},
+		{name: "Percent Is Literal", query: "50%", expectedTitles: []string{"Discounts"}},
+		{name: "Underscore Is Literal", query: "_", expectedTitles: nil},
+		{name: "Surrounding Space Trimmed", query: "  hiking ", expectedTitles: []string{"Weekend plans"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			posts, total, err := repo.SearchPosts(tc.query, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.expectedTitles), total)
+			assert.Equal(t, tc.expectedTitles, titles(posts))
+		})
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		posts, total, err := repo.SearchPosts("gophers", 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []string{"Learning Gophers"}, titles(posts))
+	})
+
+	t.Run("Empty Query", func(t *testing.T) {
+		_, _, err := repo.SearchPosts("   ", 1, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestPostRepository_WithReplica(t *testing.T) {
+	primary, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	primaryUserID := setupTestUser(t, primary)
+	require.NoError(t, NewPostRepository(primary).Create(&models.Post{UserID: primaryUserID, Title: "On primary", Content: "Content"}, nil))
+
+	t.Run("No Replica", func(t *testing.T) {
+		repo := NewPostRepository(primary).WithReplica(nil)
+		posts, _, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "On primary", posts[0].Title)
+	})
+
+	t.Run("Replica", func(t *testing.T) {
+		replica, cleanupReplica := SetupTestDB(t)
+		defer cleanupReplica()
+
+		replicaUserID := setupTestUser(t, replica)
+		require.NoError(t, NewPostRepository(replica).Create(&models.Post{UserID: replicaUserID, Title: "On replica", Content: "Content"}, nil))
+
+		repo := NewPostRepository(primary).WithReplica(replica).WithCountTTL(0)
+
+		// Reads are served by the replica
+		posts, total, err := repo.ListPosts(1, 10, PostSortNewest, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "On replica", posts[0].Title)
+
+		// Writes land on the primary only
+		written := &models.Post{UserID: primaryUserID, Title: "Written", Content: "Content"}
+		require.NoError(t, repo.Create(written, nil))
+
+		var count int
+		require.NoError(t, primary.QueryRow(`SELECT COUNT(*) FROM posts WHERE title = 'Written'`).Scan(&count))
+		assert.Equal(t, 1, count)
+		require.NoError(t, replica.QueryRow(`SELECT COUNT(*) FROM posts WHERE title = 'Written'`).Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+
+}
+
+func TestPostRepository_FilterPostsByDate(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+
+	base := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := map[string]time.Time{
+		"Before":     base.Add(-time.Second),
+		"Lower Edge": base,
+		"Inside":     base.Add(24 * time.Hour),
+		"Upper Edge": base.Add(48 * time.Hour),
+		"After":      base.Add(48*time.Hour + time.Second),
+	}
+	for title, createdAt := range created {
+		post := &models.Post{UserID: userID, Title: title, Content: "Content"}
+		require.NoError(t, repo.Create(post, nil))
+		// Stored in the server's zone, as Create does
+		_, err := db.Exec(`UPDATE posts SET created_at = ? WHERE id = ?`, createdAt.Local(), post.ID)
+		require.NoError(t, err)
+	}
+
+	filter := func(after, before *time.Time) []string {
+		posts, err := repo.FilterPosts(struct {
+			CategoryID    *int64
+			UserID        *int64
+			LikedByUser   *int64
+			CreatedAfter  *time.Time
+			CreatedBefore *time.Time
+		}{
+			CreatedAfter:  after,
+			CreatedBefore: before,
+		}, AnonymousViewer)
+		require.NoError(t, err)
+		var titles []string
+		for _, post := range posts {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	lower, upper := base, base.Add(48*time.Hour)
+	assert.Equal(t, []string{"Upper Edge", "Inside", "Lower Edge"}, filter(&lower, &upper))
+	assert.Equal(t, []string{"After", "Upper Edge", "Inside", "Lower Edge"}, filter(&lower, nil))
+	assert.Equal(t, []string{"Upper Edge", "Inside", "Lower Edge", "Before"}, filter(nil, &upper))
+
+	// The same instants written with a client's offset match the same posts
+	zone := time.FixedZone("", 2*60*60)
+	lower, upper = base.In(zone), base.Add(48*time.Hour).In(zone)
+	assert.Equal(t, []string{"Upper Edge", "Inside", "Lower Edge"}, filter(&lower, &upper))
+}
+
+func TestPostRepository_Slugs(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	repo := NewPostRepository(db)
+	create := func(title string) *models.Post {
+		post := &models.Post{UserID: userID, Title: title, Content: "Content"}
+		require.NoError(t, repo.Create(post, nil))
+		return post
+	}
+
+	first := create("Hello, World!")
+	second := create("Hello World")
+	third := create("hello   world")
+	assert.Equal(t, "hello-world", first.Slug)
+	assert.Equal(t, "hello-world-2", second.Slug)
+	assert.Equal(t, "hello-world-3", third.Slug)
+	assert.Equal(t, "post", create("???").Slug)
+
+	t.Run("Lookup", func(t *testing.T) {
+		found, err := repo.GetBySlug("hello-world-2", AnonymousViewer)
+		require.NoError(t, err)
+		assert.Equal(t, second.ID, found.ID)
+		assert.Equal(t, "hello-world-2", found.Slug)
+
+		_, err = repo.GetBySlug("goodbye-world", AnonymousViewer)
+		assert.ErrorIs(t, err, ErrPostNotFound)
+	})
+
+	t.Run("Stable Across Title Edits", func(t *testing.T) {
+		edited := &models.Post{ID: first.ID, Title: "Goodbye World", Content: "Content"}
+		require.NoError(t, repo.UpdatePost(edited, nil, userID))
+
+		found, err := repo.GetBySlug("hello-world", AnonymousViewer)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, found.ID)
+		assert.Equal(t, "Goodbye World", found.Title)
+	})
+}
+
+func TestPostRepository_PurgeDeleted(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	categoryIDs := setupTestCategories(t, db)
+	repo := NewPostRepository(db)
+	interactions := NewInteractionRepository(db)
+
+	now := time.Now()
+	deletePost := func(title string, deletedAt time.Time) int64 {
+		post := &models.Post{UserID: userID, Title: title, Content: "Content"}
+		require.NoError(t, repo.Create(post, categoryIDs[:1]))
+		require.NoError(t, interactions.AddInteraction(userID, post.ID, models.EntityTypePost, models.Like))
+		require.NoError(t, repo.DeletePost(strconv.FormatInt(post.ID, 10), userID))
+		_, err := db.Exec(`UPDATE post_tombstones SET deleted_at = ? WHERE post_id = ?`, deletedAt, post.ID)
+		require.NoError(t, err)
+		return post.ID
+	}
+	remains := func(query string, postID int64) bool {
+		var count int
+		require.NoError(t, db.QueryRow(query, postID).Scan(&count))
+		return count > 0
+	}
+	const tombstone = `SELECT COUNT(*) FROM post_tombstones WHERE post_id = ?`
+	const interaction = `SELECT COUNT(*) FROM interactions WHERE entity_type = 'post' AND entity_id = ?`
+
+	old := deletePost("Long gone", now.Add(-48*time.Hour))
+	recent := deletePost("Just deleted", now.Add(-time.Hour))
+
+	purged, err := repo.PurgeDeleted(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	assert.False(t, remains(tombstone, old))
+	assert.False(t, remains(interaction, old))
+	assert.True(t, remains(tombstone, recent))
+	assert.True(t, remains(interaction, recent))
+
+	// Nothing left to purge at the same cutoff
+	purged, err = repo.PurgeDeleted(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
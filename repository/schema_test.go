@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"forum/db"
+	"forum/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigratedSchema runs every repository against the production migration
+// rather than the SetupTestDB schema, so the two cannot drift apart unnoticed
+func TestMigratedSchema(t *testing.T) {
+	database, err := db.NewDatabase(":memory:")
+	require.NoError(t, err)
+	defer database.Close()
+
+	// Every pooled connection would otherwise open its own empty database
+	database.Conn.SetMaxOpenConns(1)
+	require.NoError(t, database.Migrate(filepath.Join("..", "db", "migrations", "001_create_tables.sql")))
+	conn := database.Conn
+
+	users := NewUserRepository(conn)
+	author, err := users.Create(&models.User{Username: "author", Email: "author@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	reader, err := users.Create(&models.User{Username: "reader", Email: "reader@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	authorID, readerID := int64(author.ID), int64(reader.ID)
+
+	t.Run("Users", func(t *testing.T) {
+		author.Username = "author2"
+		require.NoError(t, users.Update(author))
+		found, err := users.FindByID(author.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "author2", found.Username)
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		sessions := NewSessionRepository(conn)
+		require.NoError(t, sessions.CreateSession(reader, 0))
+		found, err := sessions.ValidateSession(reader.SessionToken)
+		require.NoError(t, err)
+		assert.Equal(t, reader.ID, found.ID)
+
+		require.NoError(t, sessions.InvalidateSession(reader.ID))
+		_, err = sessions.ValidateSession(reader.SessionToken)
+		assert.Error(t, err)
+	})
+
+	categories := NewCategoryRepository(conn)
+	category := &models.Category{Name: "Migrations", Description: "Schema talk"}
+	require.NoError(t, categories.Create(category))
+
+	t.Run("Categories", func(t *testing.T) {
+		category.Description = "Schema changes"
+		require.NoError(t, categories.Update(category))
+		found, err := categories.GetByID(category.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Schema changes", found.Description)
+
+		scratch := &models.Category{Name: "Scratch"}
+		require.NoError(t, categories.Create(scratch))
+		require.NoError(t, categories.Delete(scratch.ID))
+		_, err = categories.GetByID(scratch.ID)
+		assert.Error(t, err)
+	})
+
+	posts := NewPostRepository(conn)
+	post := &models.Post{UserID: authorID, Title: "Migrated", Content: "Works on the real schema"}
+	require.NoError(t, posts.Create(post, []int64{category.ID}))
+
+	t.Run("Posts", func(t *testing.T) {
+		post.Title = "Migrated twice"
+		post.UpdatedAt = time.Now()
+		require.NoError(t, posts.UpdatePost(post, []int64{category.ID}, authorID))
+
+		listed, total, err := posts.ListPosts(1, 10, PostSortNewest, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, listed, 1)
+		assert.Equal(t, "Migrated twice", listed[0].Title)
+		assert.Equal(t, []int64{category.ID}, listed[0].Categories)
+	})
+
+	comments := NewCommentRepository(conn)
+
+	t.Run("Comments", func(t *testing.T) {
+		comment := &Comment{PostID: post.ID, UserID: readerID, Content: "Nice"}
+		require.NoError(t, comments.Create(comment))
+		require.NoError(t, comments.UpdateComment(comment.ID, readerID, "Very nice"))
+
+		thread, total, err := comments.GetByPostIDPaginated(post.ID, 1, 10, CommentSortNew, false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, thread, 1)
+		assert.Equal(t, "Very nice", thread[0].Content)
+		assert.Equal(t, "reader", thread[0].Username)
+
+		require.NoError(t, comments.DeleteComment(comment.ID, readerID))
+	})
+
+	t.Run("Interactions", func(t *testing.T) {
+		interactions := NewInteractionRepository(conn)
+		require.NoError(t, interactions.AddInteraction(readerID, post.ID, models.EntityTypePost, models.Like))
+		result, err := interactions.ToggleInteraction(readerID, post.ID, models.EntityTypePost, models.Dislike)
+		require.NoError(t, err)
+		assert.Equal(t, models.Dislike, result)
+
+		likes, dislikes, err := interactions.GetInteractionCounts(post.ID, models.EntityTypePost)
+		require.NoError(t, err)
+		assert.Equal(t, 0, likes)
+		assert.Equal(t, 1, dislikes)
+
+		require.NoError(t, interactions.RemoveInteraction(readerID, post.ID, models.EntityTypePost))
+	})
+
+	t.Run("Likes", func(t *testing.T) {
+		likes := NewLikeRepository(conn)
+		require.NoError(t, likes.CreateLike(&models.Interaction{UserID: readerID, EntityID: post.ID}))
+		liked, err := likes.UserHasLikedPost(int(readerID), int(post.ID))
+		require.NoError(t, err)
+		assert.True(t, liked)
+
+		page, total, err := likes.GetLikesByPostID(int(post.ID), 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, page, 1)
+		assert.Equal(t, readerID, page[0].UserID)
+
+		require.NoError(t, likes.DeleteLike(int(readerID), int(post.ID)))
+		liked, err = likes.UserHasLikedPost(int(readerID), int(post.ID))
+		require.NoError(t, err)
+		assert.False(t, liked)
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		notifications := NewNotificationRepository(conn)
+		require.NoError(t, notifications.Create(&Notification{
+			UserID: authorID, ActorID: readerID, Type: NotificationTypeComment, EntityType: models.EntityTypePost, EntityID: post.ID,
+		}))
+		received, err := notifications.GetByUserID(authorID, 10)
+		require.NoError(t, err)
+		assert.Len(t, received, 1)
+
+		require.NoError(t, notifications.UpdatePreferences(authorID, map[string]bool{NotificationTypeComment: false}))
+		preferences, err := notifications.GetPreferences(authorID)
+		require.NoError(t, err)
+		assert.False(t, preferences[NotificationTypeComment])
+	})
+
+	t.Run("Subscriptions", func(t *testing.T) {
+		subscriptions := NewSubscriptionRepository(conn)
+		require.NoError(t, subscriptions.Subscribe(readerID, category.ID))
+		subscribers, err := subscriptions.ListSubscribers([]int64{category.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{readerID}, subscribers)
+		require.NoError(t, subscriptions.Unsubscribe(readerID, category.ID))
+	})
+
+	t.Run("Password Resets", func(t *testing.T) {
+		resets := NewPasswordResetRepository(conn)
+		token, err := resets.CreateResetToken(reader.ID)
+		require.NoError(t, err)
+		userID, err := resets.ConsumeResetToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, reader.ID, userID)
+	})
+
+	t.Run("Delete Post", func(t *testing.T) {
+		require.NoError(t, posts.DeletePost(strconv.FormatInt(post.ID, 10), authorID))
+		_, err := posts.GetByID(strconv.FormatInt(post.ID, 10), AnonymousViewer)
+		assert.Error(t, err)
+	})
+
+	t.Run("Delete User", func(t *testing.T) {
+		require.NoError(t, users.Delete(reader.ID))
+		_, err := users.FindByID(reader.ID)
+		assert.Error(t, err)
+	})
+}
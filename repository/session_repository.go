@@ -9,7 +9,7 @@ import (
 )
 
 type SessionRepositoryInterface interface {
-	CreateSession(user *models.User) error
+	CreateSession(user *models.User, duration time.Duration) error
 	ValidateSession(sessionToken string) (*models.User, error)
 	InvalidateSession(userID int) error
 	Validate(token string) (int64, error)
@@ -23,10 +23,11 @@ func NewSessionRepository(db *sql.DB) *SessionRepository {
 	return &SessionRepository{db: db}
 }
 
-// CreateSession stores a new session for a user
-func (r *SessionRepository) CreateSession(user *models.User) error {
+// CreateSession stores a new session for a user that lasts for duration, or
+// for models.DefaultSessionDuration when duration is zero
+func (r *SessionRepository) CreateSession(user *models.User, duration time.Duration) error {
 	// Generate a new session token
-	user.GenerateSessionToken()
+	user.GenerateSessionToken(duration)
 
 	// Prepare SQL to insert or update session
 	query := `
@@ -89,6 +90,16 @@ func (r *SessionRepository) InvalidateSession(userID int) error {
 	return err
 }
 
+// DeleteExpiredSessions removes every session past its expiry and returns how
+// many were deleted
+func (r *SessionRepository) DeleteExpiredSessions() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // Validate checks if a session token is valid and returns the user ID
 func (r *SessionRepository) Validate(token string) (int64, error) {
 	// Implement the Validate method to match the interface
@@ -57,7 +57,7 @@ func TestCreateSession(t *testing.T) {
 	}
 
 	sessionRepo := NewSessionRepository(db)
-	err = sessionRepo.CreateSession(user)
+	err = sessionRepo.CreateSession(user, 0)
 	assert.NoError(t, err)
 
 	// Verify session was created
@@ -74,6 +74,40 @@ func TestCreateSession(t *testing.T) {
 	assert.False(t, expiresAt.IsZero())
 }
 
+func TestCreateSessionLifetime(t *testing.T) {
+	testCases := []struct {
+		name     string
+		duration time.Duration
+		expected time.Duration
+	}{
+		{name: "Default", duration: 0, expected: models.DefaultSessionDuration},
+		{name: "Remember Me", duration: models.RememberSessionDuration, expected: 30 * 24 * time.Hour},
+		{name: "Custom", duration: 2 * time.Hour, expected: 2 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupSessionTestDB(t)
+			defer db.Close()
+
+			_, err := db.Exec(`
+				INSERT INTO users (username, email, password, created_at)
+				VALUES (?, ?, ?, ?)
+			`, "testuser", "test@example.com", "hashedpassword", time.Now())
+			assert.NoError(t, err)
+
+			user := &models.User{ID: 1, Username: "testuser"}
+			before := time.Now()
+			assert.NoError(t, NewSessionRepository(db).CreateSession(user, tc.duration))
+
+			var expiresAt time.Time
+			err = db.QueryRow(`SELECT expires_at FROM sessions WHERE user_id = ?`, user.ID).Scan(&expiresAt)
+			assert.NoError(t, err)
+			assert.WithinDuration(t, before.Add(tc.expected), expiresAt, time.Minute)
+		})
+	}
+}
+
 func TestValidateSession(t *testing.T) {
 	db := setupSessionTestDB(t)
 	defer db.Close()
@@ -91,10 +125,10 @@ func TestValidateSession(t *testing.T) {
 		Username: "testuser",
 		Email:    "test@example.com",
 	}
-	user.GenerateSessionToken()
+	user.GenerateSessionToken(0)
 
 	sessionRepo := NewSessionRepository(db)
-	err = sessionRepo.CreateSession(user)
+	err = sessionRepo.CreateSession(user, 0)
 	assert.NoError(t, err)
 
 	// Test valid session validation
@@ -129,3 +163,38 @@ func TestInvalidateSession(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, count)
 }
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	db := setupSessionTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		INSERT INTO users (username, email, password, created_at)
+		VALUES (?, ?, ?, ?), (?, ?, ?, ?)
+	`, "expired", "expired@example.com", "hashedpassword", time.Now(),
+		"valid", "valid@example.com", "hashedpassword", time.Now())
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`
+		INSERT INTO sessions (user_id, session_token, created_at, expires_at)
+		VALUES (?, ?, ?, ?), (?, ?, ?, ?)
+	`, 1, "expired-token", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour),
+		2, "valid-token", time.Now(), time.Now().Add(24*time.Hour))
+	assert.NoError(t, err)
+
+	sessionRepo := NewSessionRepository(db)
+	deleted, err := sessionRepo.DeleteExpiredSessions()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var tokens []string
+	rows, err := db.Query(`SELECT session_token FROM sessions`)
+	assert.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var token string
+		assert.NoError(t, rows.Scan(&token))
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, []string{"valid-token"}, tokens)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// SubscriptionRepositoryInterface defines the methods for following categories
+type SubscriptionRepositoryInterface interface {
+	Subscribe(userID, categoryID int64) error
+	Unsubscribe(userID, categoryID int64) error
+	ListSubscribers(categoryIDs []int64) ([]int64, error)
+}
+
+type SubscriptionRepository struct {
+	conn *sql.DB
+}
+
+var _ SubscriptionRepositoryInterface = &SubscriptionRepository{}
+
+func NewSubscriptionRepository(conn *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{conn: conn}
+}
+
+// Subscribe follows a category for the user; subscribing again is a no-op
+func (r *SubscriptionRepository) Subscribe(userID, categoryID int64) error {
+	var exists bool
+	err := r.conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = ?)`, categoryID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	query := `INSERT OR IGNORE INTO category_subscriptions (user_id, category_id) VALUES (?, ?)`
+	_, err = r.conn.Exec(query, userID, categoryID)
+	return err
+}
+
+// Unsubscribe stops following a category; it is a no-op if not subscribed
+func (r *SubscriptionRepository) Unsubscribe(userID, categoryID int64) error {
+	query := `DELETE FROM category_subscriptions WHERE user_id = ? AND category_id = ?`
+	_, err := r.conn.Exec(query, userID, categoryID)
+	return err
+}
+
+// ListSubscribers returns the distinct users following any of the categories
+func (r *SubscriptionRepository) ListSubscribers(categoryIDs []int64) ([]int64, error) {
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(categoryIDs))
+	args := make([]interface{}, len(categoryIDs))
+	for i, id := range categoryIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `SELECT DISTINCT user_id FROM category_subscriptions
+			  WHERE category_id IN (` + strings.Join(placeholders, ", ") + `)
+			  ORDER BY user_id`
+	rows, err := r.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionRepository(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	userID := setupTestUser(t, db)
+	result, err := db.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)", "other", "other@example.com", "hashedpassword")
+	require.NoError(t, err)
+	otherID, err := result.LastInsertId()
+	require.NoError(t, err)
+	categoryIDs := setupTestCategories(t, db)
+
+	repo := NewSubscriptionRepository(db)
+
+	require.NoError(t, repo.Subscribe(userID, categoryIDs[0]))
+	require.NoError(t, repo.Subscribe(otherID, categoryIDs[1]))
+
+	t.Run("Duplicate Is Ignored", func(t *testing.T) {
+		require.NoError(t, repo.Subscribe(userID, categoryIDs[0]))
+
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM category_subscriptions WHERE user_id = ?`, userID).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Unknown Category", func(t *testing.T) {
+		assert.ErrorIs(t, repo.Subscribe(userID, 9999), ErrCategoryNotFound)
+	})
+
+	t.Run("List Subscribers", func(t *testing.T) {
+		subscribers, err := repo.ListSubscribers([]int64{categoryIDs[0]})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{userID}, subscribers)
+
+		// A user following several of the categories is listed once
+		require.NoError(t, repo.Subscribe(userID, categoryIDs[1]))
+		subscribers, err = repo.ListSubscribers(categoryIDs)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{userID, otherID}, subscribers)
+
+		subscribers, err = repo.ListSubscribers(nil)
+		require.NoError(t, err)
+		assert.Empty(t, subscribers)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		require.NoError(t, repo.Unsubscribe(otherID, categoryIDs[1]))
+		require.NoError(t, repo.Unsubscribe(otherID, categoryIDs[1]))
+
+		subscribers, err := repo.ListSubscribers([]int64{categoryIDs[1]})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{userID}, subscribers)
+	})
+}
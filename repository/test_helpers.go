@@ -2,16 +2,20 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
-// SetupTestDB creates an in-memory SQLite database for testing
-func SetupTestDB(t *testing.T) (*sql.DB, func()) {
-	// Open an in-memory SQLite database for testing
-	db, err := sql.Open("sqlite3", ":memory:")
+// SetupTestDB creates an in-memory SQLite database for testing; its tables
+// mirror db/migrations/001_create_tables.sql, which is the source of truth
+// and is exercised directly by TestMigratedSchema
+func SetupTestDB(t testing.TB) (*sql.DB, func()) {
+	// Open an in-memory SQLite database for testing; the named shared cache
+	// lets every pooled connection of this test see the same tables
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
 	require.NoError(t, err)
 
 	// Create necessary tables
@@ -22,12 +26,21 @@ func SetupTestDB(t *testing.T) (*sql.DB, func()) {
 			email TEXT UNIQUE NOT NULL,
 			password TEXT NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			last_login DATETIME
+		);
+
+		CREATE TABLE sessions (
+			user_id INTEGER PRIMARY KEY,
+			session_token TEXT NOT NULL UNIQUE,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id)
 		);
 
 		CREATE TABLE categories (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL
+			name TEXT UNIQUE NOT NULL,
+			description TEXT
 		);
 
 		CREATE TABLE posts (
@@ -35,11 +48,23 @@ func SetupTestDB(t *testing.T) (*sql.DB, func()) {
 			user_id INTEGER NOT NULL,
 			title TEXT NOT NULL,
 			content TEXT NOT NULL,
+			excerpt TEXT,
 			created_at DATETIME NOT NULL,
 			updated_at DATETIME,
+			like_count INTEGER NOT NULL DEFAULT 0,
+			dislike_count INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL DEFAULT 'approved',
+			slug TEXT,
 			FOREIGN KEY(user_id) REFERENCES users(id)
 		);
 
+		CREATE UNIQUE INDEX idx_posts_slug ON posts(slug);
+
+		CREATE TABLE post_tombstones (
+			post_id INTEGER PRIMARY KEY,
+			deleted_at DATETIME NOT NULL
+		);
+
 		CREATE TABLE post_categories (
 			post_id INTEGER NOT NULL,
 			category_id INTEGER NOT NULL,
@@ -48,12 +73,65 @@ func SetupTestDB(t *testing.T) (*sql.DB, func()) {
 			FOREIGN KEY(category_id) REFERENCES categories(id)
 		);
 
-		CREATE TABLE likes (
-			user_id INTEGER NOT NULL,
+		CREATE TABLE comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			post_id INTEGER NOT NULL,
-			PRIMARY KEY (user_id, post_id),
+			parent_id INTEGER,
+			user_id INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			deleted_at DATETIME,
+			FOREIGN KEY(post_id) REFERENCES posts(id),
+			FOREIGN KEY(parent_id) REFERENCES comments(id),
+			FOREIGN KEY(user_id) REFERENCES users(id)
+		);
+
+		CREATE TABLE interactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			entity_id INTEGER NOT NULL,
+			entity_type TEXT NOT NULL CHECK (entity_type IN ('post', 'comment')),
+			type INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, entity_id, entity_type),
+			FOREIGN KEY(user_id) REFERENCES users(id)
+		);
+
+		CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			actor_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			is_read BOOLEAN NOT NULL DEFAULT 0,
+			request_id TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id)
+		);
+
+		CREATE TABLE notification_preferences (
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			PRIMARY KEY (user_id, type),
+			FOREIGN KEY(user_id) REFERENCES users(id)
+		);
+
+		CREATE TABLE category_subscriptions (
+			user_id INTEGER NOT NULL,
+			category_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, category_id),
 			FOREIGN KEY(user_id) REFERENCES users(id),
-			FOREIGN KEY(post_id) REFERENCES posts(id)
+			FOREIGN KEY(category_id) REFERENCES categories(id)
+		);
+
+		CREATE TABLE password_reset_tokens (
+			token TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			expires_at DATETIME NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id)
 		);
 	`)
 	require.NoError(t, err)
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// errRollback is returned from a withTx function to discard its writes
+// without reporting a failure, as dry runs do
+var errRollback = errors.New("transaction rolled back")
+
+// withTx runs fn in a transaction on conn. The transaction is committed when
+// fn returns nil and rolled back when it returns an error or panics, so
+// callers never commit on an error path
+func withTx(conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	// Rolling back after a successful commit is a no-op
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx(t *testing.T) {
+	db, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	insert := func(name string) func(tx *sql.Tx) error {
+		return func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO categories (name) VALUES (?)`, name)
+			return err
+		}
+	}
+	exists := func(name string) bool {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM categories WHERE name = ?`, name).Scan(&count))
+		return count > 0
+	}
+
+	t.Run("Commits On Success", func(t *testing.T) {
+		require.NoError(t, withTx(db, insert("Committed")))
+		assert.True(t, exists("Committed"))
+	})
+
+	t.Run("Rolls Back On Error", func(t *testing.T) {
+		failure := errors.New("boom")
+		err := withTx(db, func(tx *sql.Tx) error {
+			require.NoError(t, insert("RolledBack")(tx))
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.False(t, exists("RolledBack"))
+	})
+
+	t.Run("Rolls Back On Panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			withTx(db, func(tx *sql.Tx) error {
+				require.NoError(t, insert("Panicked")(tx))
+				panic("boom")
+			})
+		})
+		assert.False(t, exists("Panicked"))
+	})
+}
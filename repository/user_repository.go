@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"forum/models"
@@ -10,6 +11,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrUserNotFound is returned when a user lookup matches no rows
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository interface {
 	Create(user *models.User) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
@@ -17,6 +21,7 @@ type UserRepository interface {
 	Update(user *models.User) error
 	UpdatePassword(userID int, newPassword string) error
 	FindByID(id int) (*models.User, error)
+	FindByIDs(ids []int) (map[int]*models.User, error)
 	Delete(userID int) error
 }
 
@@ -76,7 +81,7 @@ func (r *UserRepositoryImpl) FindByEmail(email string) (*models.User, error) {
 	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -90,13 +95,44 @@ func (r *UserRepositoryImpl) FindByID(id int) (*models.User, error) {
 	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return user, nil
 }
 
+// FindByIDs finds the given users in a single query; IDs that match no user
+// are absent from the map
+func (r *UserRepositoryImpl) FindByIDs(ids []int) (map[int]*models.User, error) {
+	users := make(map[int]*models.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `SELECT id, username, email, created_at FROM users WHERE id IN (` + placeholders + `)`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, rows.Err()
+}
+
 // UpdatePassword updates a user's password
 func (r *UserRepositoryImpl) UpdatePassword(userID int, newPassword string) error {
 	// Hash the new password
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -171,4 +172,42 @@ func TestUserDelete(t *testing.T) {
 	if err == nil {
 		t.Error("User should not exist after deletion")
 	}
+	if _, err = repo.FindByID(user.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUserFindByIDs(t *testing.T) {
+	testDB, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(testDB)
+
+	var ids []int
+	for _, name := range []string{"first", "second"} {
+		user, err := repo.Create(&models.User{Username: name, Email: name + "@example.com", Password: "StrongPass123"})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	users, err := repo.FindByIDs(append(ids, 999))
+	if err != nil {
+		t.Fatalf("Failed to find users: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+	if users[ids[1]].Username != "second" {
+		t.Errorf("Unexpected username: got %s, want second", users[ids[1]].Username)
+	}
+	if _, ok := users[999]; ok {
+		t.Error("Unknown ID should be absent from the result")
+	}
+
+	users, err = repo.FindByIDs(nil)
+	if err != nil || len(users) != 0 {
+		t.Errorf("Expected no users for no IDs, got %v, %v", users, err)
+	}
 }
@@ -1,14 +1,22 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"forum/config"
 	"forum/db"
 	"forum/handlers"
 	"forum/middleware"
+	"forum/pkg/storage"
 	"forum/repository"
 
 	"github.com/go-chi/chi/v5"
@@ -25,23 +33,32 @@ func init() {
 }
 
 type Router struct {
-	r                  *chi.Mux
-	authMiddleware     *middleware.AuthMiddleware
-	postRepo           repository.PostRepositoryInterface
-	userRepo           repository.UserRepository
-	commentRepo        repository.CommentRepositoryInterface
-	likeRepo           repository.LikeRepositoryInterface
-	categoryRepo       repository.CategoryRepositoryInterface
-	sessionRepo        repository.SessionRepositoryInterface
-	interactionRepo    repository.InteractionRepositoryInterface
-	postHandler        *handlers.PostHandler
-	commentHandler     *handlers.CommentHandler
-	interactionHandler *handlers.InteractionHandler
-	templateRenderer   *handlers.TemplateRenderer
-	authHandler        *handlers.AuthHandler
-}
-
-func NewRouter(database *db.Database, authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler, authMiddleware *middleware.AuthMiddleware) *Router {
+	r                   *chi.Mux
+	config              *config.Config
+	authMiddleware      *middleware.AuthMiddleware
+	postRepo            repository.PostRepositoryInterface
+	userRepo            repository.UserRepository
+	commentRepo         repository.CommentRepositoryInterface
+	likeRepo            repository.LikeRepositoryInterface
+	categoryRepo        repository.CategoryRepositoryInterface
+	sessionRepo         repository.SessionRepositoryInterface
+	interactionRepo     repository.InteractionRepositoryInterface
+	notificationRepo    repository.NotificationRepositoryInterface
+	subscriptionRepo    repository.SubscriptionRepositoryInterface
+	postHandler         *handlers.PostHandler
+	commentHandler      *handlers.CommentHandler
+	interactionHandler  *handlers.InteractionHandler
+	attachmentHandler   *handlers.AttachmentHandler
+	categoryHandler     *handlers.CategoryHandler
+	configHandler       *handlers.ConfigHandler
+	subscriptionHandler *handlers.SubscriptionHandler
+	notificationHandler *handlers.NotificationHandler
+	userHandler         *handlers.UserHandler
+	templateRenderer    *handlers.TemplateRenderer
+	authHandler         *handlers.AuthHandler
+}
+
+func NewRouter(database *db.Database, cfg *config.Config, authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler, authMiddleware *middleware.AuthMiddleware, templateRenderer *handlers.TemplateRenderer) *Router {
 	log.Println(" Initializing Router")
 
 	userRepo, err := initializeUserRepository(database)
@@ -79,10 +96,25 @@ func NewRouter(database *db.Database, authHandler *handlers.AuthHandler, postHan
 		log.Fatalf("Failed to initialize interaction repository: %v", err)
 	}
 
-	templateRenderer := handlers.NewTemplateRenderer(authMiddleware)
+	notificationRepo, err := initializeNotificationRepository(database)
+	if err != nil {
+		log.Fatalf("Failed to initialize notification repository: %v", err)
+	}
+
+	subscriptionRepo, err := initializeSubscriptionRepository(database)
+	if err != nil {
+		log.Fatalf("Failed to initialize subscription repository: %v", err)
+	}
+
+	signer := storage.NewURLSigner(cfg.UploadsSigningKey)
+	uploads, err := storage.New(cfg.StorageBackend, cfg.UploadsDir, cfg.UploadsBaseURL, signer)
+	if err != nil {
+		log.Fatalf("Failed to initialize uploads storage: %v", err)
+	}
 
 	router := &Router{
 		r:                chi.NewRouter(),
+		config:           cfg,
 		authMiddleware:   authMiddleware,
 		postRepo:         postRepo,
 		userRepo:         userRepo,
@@ -91,20 +123,44 @@ func NewRouter(database *db.Database, authHandler *handlers.AuthHandler, postHan
 		categoryRepo:     categoryRepo,
 		sessionRepo:      sessionRepo,
 		interactionRepo:  interactionRepo,
+		notificationRepo: notificationRepo,
+		subscriptionRepo: subscriptionRepo,
 		postHandler:      postHandler,
 		templateRenderer: templateRenderer,
 		authHandler:      authHandler,
 	}
 
 	// Initialize handlers
-	commentHandler := handlers.NewCommentHandler(commentRepo, authMiddleware)
+	commentHandler := handlers.NewCommentHandler(commentRepo, authMiddleware).
+		WithNotifications(notificationRepo).
+		WithUsers(userRepo)
 	interactionHandler := handlers.NewInteractionHandler(
 		interactionRepo,
 		authMiddleware,
-	)
+	).WithMaxBatchIDs(cfg.InteractionBatchMaxIDs)
 
 	router.commentHandler = commentHandler
 	router.interactionHandler = interactionHandler
+	router.attachmentHandler = handlers.NewAttachmentHandler(uploads, signer)
+	router.categoryHandler = handlers.NewCategoryHandler(categoryRepo)
+	router.configHandler = handlers.NewConfigHandler(cfg)
+	router.subscriptionHandler = handlers.NewSubscriptionHandler(subscriptionRepo)
+	router.notificationHandler = handlers.NewNotificationHandler(notificationRepo)
+	router.userHandler = handlers.NewUserHandler(userRepo, postRepo, commentRepo, interactionRepo).
+		WithRoles(authMiddleware)
+	postHandler.WithSubscriptions(subscriptionRepo, notificationRepo).
+		WithCategories(categoryRepo).
+		WithEngagement(commentRepo, interactionRepo).
+		WithUsers(userRepo).
+		WithRoles(authMiddleware)
+
+	// Reset tokens are only written to the log in debug mode; there is no
+	// mail delivery yet, so other deployments issue none
+	var resetMailer handlers.PasswordResetMailer
+	if cfg.DebugMode {
+		resetMailer = handlers.LogPasswordResetMailer{}
+	}
+	authHandler.WithPasswordResets(repository.NewPasswordResetRepository(database.Conn), sessionRepo, resetMailer)
 
 	// Create Router struct
 	router.registerRoutes()
@@ -120,19 +176,19 @@ func initializeUserRepository(database *db.Database) (repository.UserRepository,
 }
 
 func initializePostRepository(database *db.Database) (repository.PostRepositoryInterface, error) {
-	repo := repository.NewPostRepository(database.Conn)
+	repo := repository.NewPostRepository(database.Conn).WithReplica(database.Replica)
 	// Add any additional validation or initialization logic
 	return repo, nil
 }
 
 func initializeCategoryRepository(database *db.Database) (repository.CategoryRepositoryInterface, error) {
-	repo := repository.NewCategoryRepository(database.Conn)
+	repo := repository.NewCategoryRepository(database.Conn).WithReplica(database.Replica)
 	// Add any additional validation or initialization logic
 	return repo, nil
 }
 
 func initializeCommentRepository(database *db.Database) (repository.CommentRepositoryInterface, error) {
-	repo := repository.NewCommentRepository(database.Conn)
+	repo := repository.NewCommentRepository(database.Conn).WithReplica(database.Replica)
 	// Add any additional validation or initialization logic
 	return repo, nil
 }
@@ -150,7 +206,19 @@ func initializeSessionRepository(database *db.Database) (repository.SessionRepos
 }
 
 func initializeInteractionRepository(database *db.Database) (repository.InteractionRepositoryInterface, error) {
-	repo := repository.NewInteractionRepository(database.Conn)
+	repo := repository.NewInteractionRepository(database.Conn).WithReplica(database.Replica)
+	// Add any additional validation or initialization logic
+	return repo, nil
+}
+
+func initializeNotificationRepository(database *db.Database) (repository.NotificationRepositoryInterface, error) {
+	repo := repository.NewNotificationRepository(database.Conn)
+	// Add any additional validation or initialization logic
+	return repo, nil
+}
+
+func initializeSubscriptionRepository(database *db.Database) (repository.SubscriptionRepositoryInterface, error) {
+	repo := repository.NewSubscriptionRepository(database.Conn)
 	// Add any additional validation or initialization logic
 	return repo, nil
 }
@@ -159,20 +227,37 @@ func (router *Router) registerRoutes() {
 	r := router.r
 
 	// Middleware
+	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.Logger)
-	r.Use(chimiddleware.Recoverer)
-	r.Use(router.authMiddleware.ProtectRoute)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.NewCORSMiddleware(router.config.CORSAllowedOrigins, router.config.CORSExemptPaths).
+		WithAllowedMethods(router.config.CORSAllowedMethods...).
+		WithAllowedHeaders(router.config.CORSAllowedHeaders...).
+		Handler)
+	if router.config.JSONStringIDs {
+		r.Use(middleware.StringIDs)
+	}
+
+	// Health check for monitoring
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
 
 	// Add static file serving
-	fileServer := http.FileServer(http.Dir("./frontend/static"))
+	fileServer := http.FileServer(http.Dir(router.config.StaticDir))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
 
-	// Authentication routes
+	// Authentication routes; failed login and register attempts are rate
+	// limited to slow down credential guessing
+	loginLimiter := middleware.NewRateLimiter(router.config.LoginMaxFailures, router.config.LoginFailureWindow)
 	r.Get("/login", router.authHandler.LoginPage)
-	r.Post("/login", router.authHandler.Login)
+	r.With(loginLimiter.Handler).Post("/login", router.authHandler.Login)
 	r.Get("/register", router.authHandler.RegisterPage)
-	r.Post("/register", router.authHandler.Register)
+	r.With(loginLimiter.Handler).Post("/register", router.authHandler.Register)
 	r.Get("/logout", router.authHandler.Logout)
+	r.Post("/password/forgot", router.authHandler.ForgotPassword)
+	r.Post("/password/reset", router.authHandler.ResetPassword)
 
 	// Protected routes (require authentication)
 	r.Group(func(r chi.Router) {
@@ -189,11 +274,73 @@ func (router *Router) registerRoutes() {
 		// Posts routes
 		r.Get("/posts/create", router.postHandler.CreatePostPage)
 		r.Post("/posts/create", router.postHandler.CreatePost)
+
+		// Current user's activity
+		r.Get("/me/commented", router.postHandler.GetCommentedPosts)
+		r.Get("/me/liked-posts", router.postHandler.GetLikedPosts)
+		r.Get("/me/posts", router.userHandler.GetMyPosts)
+		r.Get("/me/permissions", router.userHandler.GetMyPermissions)
+		r.Get("/posts/discover", router.postHandler.DiscoverPosts)
+		r.Get("/me/notification-preferences", router.notificationHandler.GetPreferences)
+		r.Put("/me/notification-preferences", router.notificationHandler.UpdatePreferences)
+
+		// Moderator tools
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(router.authMiddleware.RequireModerator)
+			r.Get("/comments/recent", router.commentHandler.GetRecentComments)
+			r.Post("/categories/merge", router.categoryHandler.MergeCategories)
+			r.Get("/config", router.configHandler.GetConfig)
+			r.Get("/posts/unengaged", router.postHandler.GetPostsWithoutEngagement)
+			r.Post("/posts/approve", router.postHandler.ApprovePosts)
+		})
+
+		// Comments routes
+		r.Post("/posts/{postId}/comments", router.commentHandler.CreateComment)
+		r.Put("/comments/{id}", router.commentHandler.UpdateComment)
+		r.Delete("/comments/{id}", router.commentHandler.DeleteComment)
+
+		// Interactions routes
+		r.Post("/interactions/like", router.interactionHandler.LikeEntity)
+		r.Post("/interactions/dislike", router.interactionHandler.DislikeEntity)
+		r.Delete("/interactions", router.interactionHandler.RemoveInteraction)
+		r.Post("/interactions/toggle", router.interactionHandler.ToggleInteraction)
+		r.Get("/interactions/mine", router.interactionHandler.GetUserInteractions)
+		r.Get("/interactions/summary", router.interactionHandler.GetInteractionSummaries)
+		r.Get("/me/received-interactions", router.interactionHandler.GetReceivedInteractions)
+
+		// Category subscriptions
+		r.Put("/categories/{id}/subscription", router.subscriptionHandler.SubscribeCategory)
+		r.Delete("/categories/{id}/subscription", router.subscriptionHandler.UnsubscribeCategory)
 	})
 
-	// Public routes
-	r.Get("/posts", router.postHandler.ListPosts)
-	r.Get("/posts/{id}", router.postHandler.GetPost)
+	// Public routes; a logged-in visitor's claims are still attached, so
+	// moderators can ask for deleted comments here and authors can read
+	// their own posts while they wait for moderation
+	r.Group(func(r chi.Router) {
+		r.Use(router.authMiddleware.OptionalAuth)
+
+		r.Get("/posts", router.postHandler.ListPosts)
+		r.Get("/posts/by-category", router.postHandler.GetLatestPerCategory)
+		r.Get("/posts/changes", router.postHandler.GetPostChanges)
+		r.Get("/posts/search", router.postHandler.SearchPosts)
+		r.Get("/posts/check-title", router.postHandler.CheckTitle)
+		r.Get("/interactions/counts", router.interactionHandler.GetInteractionCounts)
+		r.Get("/leaderboard", router.interactionHandler.GetLeaderboard)
+		r.Get("/categories", router.categoryHandler.ListCategories)
+		r.Get("/categories/frequency", router.categoryHandler.GetCategoryFrequency)
+		r.Get("/categories/trending", router.categoryHandler.GetTrendingCategories)
+		r.Get("/users/{id}", router.userHandler.GetUserProfile)
+		r.Get("/users/{id}/comments", router.userHandler.GetUserComments)
+		r.Get("/users/{id}/posts", router.userHandler.GetUserPosts)
+		r.Get("/users/{id}/interactions", router.interactionHandler.GetUserInteractionSummary)
+		r.Get("/posts/slug/{slug}", router.postHandler.GetPostBySlug)
+		r.Get("/posts/{id}", router.postHandler.GetPost)
+		r.Get("/posts/{id}/full", router.postHandler.GetFullPost)
+		r.Get("/posts/{id}/counts", router.postHandler.GetPostCounts)
+		r.Get("/posts/{postId}/comments", router.commentHandler.GetComments)
+		r.Get("/comments/{id}", router.commentHandler.GetComment)
+		r.Get(strings.TrimSuffix(router.config.UploadsBaseURL, "/")+"/*", router.attachmentHandler.Download)
+	})
 
 	// Error handling routes
 	r.Get("/403", func(w http.ResponseWriter, r *http.Request) {
@@ -204,19 +351,66 @@ func (router *Router) registerRoutes() {
 	})
 }
 
+// newServer builds the HTTP server for addr with the configured timeouts, so
+// slow or idle clients cannot hold connections open indefinitely
+func (router *Router) newServer(addr string) *http.Server {
+	timeouts := router.config.ServerTimeouts
+	return &http.Server{
+		Addr:              addr,
+		Handler:           router.r,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
+
+// Start serves on addr until the process receives SIGINT or SIGTERM, then
+// stops accepting connections and lets in-flight requests finish
 func (router *Router) Start(addr string) error {
 	// Ensure address starts with a colon
 	if !strings.HasPrefix(addr, ":") {
 		addr = ":" + addr
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("server startup failed: %w", err)
+	}
+
 	log.Printf(" Starting server on %s", addr)
 	log.Printf("Server accessible at http://localhost%s", addr)
 
-	// Start the server
-	if err := http.ListenAndServe(addr, router.r); err != nil {
-		return fmt.Errorf("server startup failed: %w", err)
+	return router.serve(ctx, listener)
+}
+
+// serve handles requests on listener until ctx is done, then shuts the server
+// down, waiting up to the configured shutdown timeout for open requests
+func (router *Router) serve(ctx context.Context, listener net.Listener) error {
+	server := router.newServer(listener.Addr().String())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
 	}
 
+	log.Println(" Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), router.config.ServerTimeouts.Shutdown)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
 	return nil
 }
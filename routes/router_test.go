@@ -0,0 +1,426 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"forum/config"
+	"forum/db"
+	"forum/handlers"
+	"forum/middleware"
+	"forum/models"
+	"forum/repository"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentRoutes(t *testing.T) {
+	conn, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userID, postID := seedPost(t, conn)
+
+	const moderatorID = 99
+	authMiddleware := middleware.NewAuthMiddleware(nil, "test-secret", 1).WithModerators(moderatorID)
+	router := &Router{
+		r:              chi.NewRouter(),
+		config:         &config.Config{UploadsBaseURL: config.DefaultUploadsBaseURL},
+		authMiddleware: authMiddleware,
+		commentHandler: handlers.NewCommentHandler(repository.NewCommentRepository(conn), authMiddleware),
+	}
+	router.registerRoutes()
+
+	commentsURL := "/posts/" + strconv.FormatInt(postID, 10) + "/comments"
+
+	t.Run("Create Requires Login", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, commentsURL, bytes.NewBufferString(`{"content": "Hi"}`))
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		token, err := authMiddleware.GenerateToken(int(userID), "author", 0)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, commentsURL, bytes.NewBufferString(`{"content": "First!"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var comment repository.Comment
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&comment))
+		assert.Equal(t, postID, comment.PostID)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, commentsURL, nil)
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Data []repository.Comment `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, "First!", body.Data[0].Content)
+	})
+
+	t.Run("Moderator Include Deleted", func(t *testing.T) {
+		var commentID int64
+		require.NoError(t, conn.QueryRow(`SELECT id FROM comments WHERE post_id = ?`, postID).Scan(&commentID))
+		_, err := conn.Exec(`UPDATE comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, commentID)
+		require.NoError(t, err)
+
+		moderatorToken, err := authMiddleware.GenerateToken(moderatorID, "moderator", 0)
+		require.NoError(t, err)
+		authorToken, err := authMiddleware.GenerateToken(int(userID), "author", 0)
+		require.NoError(t, err)
+
+		serve := func(target, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			router.r.ServeHTTP(w, req)
+			return w
+		}
+
+		commentURL := "/comments/" + strconv.FormatInt(commentID, 10) + "?include_deleted=true"
+		for _, target := range []string{commentsURL + "?include_deleted=true", commentURL} {
+			assert.Equal(t, http.StatusOK, serve(target, moderatorToken).Code, "moderator GET %s", target)
+			assert.Equal(t, http.StatusForbidden, serve(target, authorToken).Code, "author GET %s", target)
+			assert.Equal(t, http.StatusForbidden, serve(target, "").Code, "anonymous GET %s", target)
+		}
+
+		w := serve(commentsURL+"?include_deleted=true", moderatorToken)
+		var body struct {
+			Data []repository.Comment `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, commentID, body.Data[0].ID)
+	})
+}
+
+func TestInteractionRoutes(t *testing.T) {
+	conn, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userID, postID := seedPost(t, conn)
+
+	authMiddleware := middleware.NewAuthMiddleware(nil, "test-secret", 1)
+	router := &Router{
+		r:                  chi.NewRouter(),
+		config:             &config.Config{UploadsBaseURL: config.DefaultUploadsBaseURL},
+		authMiddleware:     authMiddleware,
+		interactionHandler: handlers.NewInteractionHandler(repository.NewInteractionRepository(conn), authMiddleware),
+	}
+	router.registerRoutes()
+
+	likeBody := `{"entity_id": ` + strconv.FormatInt(postID, 10) + `, "entity_type": "post"}`
+
+	t.Run("Like Requires Login", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/interactions/like", bytes.NewBufferString(likeBody))
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Like Is Counted", func(t *testing.T) {
+		token, err := authMiddleware.GenerateToken(int(userID), "author", 0)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/interactions/like", bytes.NewBufferString(likeBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/interactions/counts?entity_type=post&entity_id="+strconv.FormatInt(postID, 10), nil)
+		w = httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var counts handlers.InteractionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&counts))
+		assert.Equal(t, handlers.InteractionResponse{Likes: 1, Dislikes: 0}, counts)
+	})
+}
+
+func TestPendingPostRoutes(t *testing.T) {
+	conn, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	authorID, _ := seedPost(t, conn)
+	postRepo := repository.NewPostRepository(conn)
+	pending := &models.Post{UserID: authorID, Title: "Awaiting Review", Content: "Content", Status: repository.PostStatusPending}
+	require.NoError(t, postRepo.Create(pending, nil))
+
+	const moderatorID = 99
+	authMiddleware := middleware.NewAuthMiddleware(nil, "test-secret", 1).WithModerators(moderatorID)
+	router := &Router{
+		r:              chi.NewRouter(),
+		config:         &config.Config{UploadsBaseURL: config.DefaultUploadsBaseURL},
+		authMiddleware: authMiddleware,
+		postHandler: handlers.NewPostHandler(postRepo, authMiddleware, nil).
+			WithRoles(authMiddleware).
+			WithCategories(repository.NewCategoryRepository(conn)).
+			WithEngagement(repository.NewCommentRepository(conn), repository.NewInteractionRepository(conn)),
+	}
+	router.registerRoutes()
+
+	authorToken, err := authMiddleware.GenerateToken(int(authorID), "author", 0)
+	require.NoError(t, err)
+	moderatorToken, err := authMiddleware.GenerateToken(moderatorID, "moderator", 0)
+	require.NoError(t, err)
+	readerToken, err := authMiddleware.GenerateToken(int(authorID)+1, "reader", 0)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "Anonymous", expectedStatus: http.StatusNotFound},
+		{name: "Other User", token: readerToken, expectedStatus: http.StatusNotFound},
+		{name: "Author", token: authorToken, expectedStatus: http.StatusOK},
+		{name: "Moderator", token: moderatorToken, expectedStatus: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			postPath := "/posts/" + strconv.FormatInt(pending.ID, 10)
+			for _, target := range []string{postPath, "/posts/slug/" + pending.Slug, postPath + "/counts", postPath + "/full"} {
+				req := httptest.NewRequest(http.MethodGet, target, nil)
+				if tc.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tc.token)
+				}
+				w := httptest.NewRecorder()
+				router.r.ServeHTTP(w, req)
+				assert.Equal(t, tc.expectedStatus, w.Code, "GET %s", target)
+			}
+		})
+	}
+}
+
+func TestStringIDsRoutes(t *testing.T) {
+	conn, cleanup := repository.SetupTestDB(t)
+	defer cleanup()
+
+	userID, postID := seedPost(t, conn)
+	authMiddleware := middleware.NewAuthMiddleware(nil, "test-secret", 1)
+
+	asNumber := func(id int64) interface{} { return float64(id) }
+	asString := func(id int64) interface{} { return strconv.FormatInt(id, 10) }
+
+	for _, tc := range []struct {
+		name      string
+		stringIDs bool
+		expected  func(id int64) interface{}
+	}{
+		{name: "Disabled", stringIDs: false, expected: asNumber},
+		{name: "Enabled", stringIDs: true, expected: asString},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := &Router{
+				r:              chi.NewRouter(),
+				config:         &config.Config{UploadsBaseURL: config.DefaultUploadsBaseURL, JSONStringIDs: tc.stringIDs},
+				authMiddleware: authMiddleware,
+				postHandler:    handlers.NewPostHandler(repository.NewPostRepository(conn), authMiddleware, nil).WithRoles(authMiddleware),
+			}
+			router.registerRoutes()
+
+			w := httptest.NewRecorder()
+			router.r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10), nil))
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tc.expected(postID), body["id"])
+			assert.Equal(t, tc.expected(userID), body["user_id"])
+		})
+	}
+}
+
+func TestNewRouterOnMigratedSchema(t *testing.T) {
+	database, err := db.NewDatabase(":memory:")
+	require.NoError(t, err)
+	defer database.Close()
+	database.Conn.SetMaxOpenConns(1)
+	require.NoError(t, database.Migrate(filepath.Join("..", "db", "migrations", "001_create_tables.sql")))
+
+	cfg := &config.Config{
+		StorageBackend:    config.DefaultStorageBackend,
+		UploadsDir:        t.TempDir(),
+		UploadsBaseURL:    config.DefaultUploadsBaseURL,
+		UploadsSigningKey: "test-signing-key",
+	}
+	sessionRepo := repository.NewSessionRepository(database.Conn)
+	authMiddleware := middleware.NewAuthMiddleware(sessionRepo, "test-secret", 1)
+	userRepo := repository.NewUserRepository(database.Conn)
+	authHandler := handlers.NewAuthHandler(userRepo, authMiddleware, nil)
+	postHandler := handlers.NewPostHandler(repository.NewPostRepository(database.Conn), authMiddleware, nil)
+
+	router := NewRouter(database, cfg, authHandler, postHandler, authMiddleware, nil)
+
+	user, err := userRepo.Create(&models.User{Username: "booter", Email: "booter@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	token, err := authMiddleware.GenerateToken(user.ID, user.Username, 0)
+	require.NoError(t, err)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The migration seeds the General category
+	w := serve(http.MethodPost, "/posts/create", `{"title": "Booted", "content": "Running on the migrated schema", "category_id": 1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var post models.Post
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&post))
+	postPath := "/posts/" + strconv.FormatInt(post.ID, 10)
+
+	// With no default category configured, a post without one is saved
+	// without any rather than tagged with a category that does not exist
+	w = serve(http.MethodPost, "/posts/create", `{"title": "Untagged", "content": "Filed nowhere"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var untagged models.Post
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&untagged))
+	assert.Empty(t, untagged.Categories)
+
+	w = serve(http.MethodPost, postPath+"/comments", `{"content": "First!"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serve(http.MethodPost, "/interactions/toggle", `{"entity_id": `+strconv.FormatInt(post.ID, 10)+`, "entity_type": "post", "type": "like"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	userPath := "/users/" + strconv.Itoa(user.ID)
+	for _, target := range []string{"/posts", postPath, postPath + "/comments", "/categories?with_counts=true", "/categories/frequency", "/me/notification-preferences", userPath, userPath + "/comments", userPath + "/posts", "/me/posts", postPath + "/full", postPath + "/counts", "/me/liked-posts", "/me/permissions", "/posts/slug/" + post.Slug} {
+		w = serve(http.MethodGet, target, "")
+		assert.Equal(t, http.StatusOK, w.Code, "GET %s: %s", target, w.Body.String())
+	}
+
+	// The like toggled above shows up among the user's liked posts
+	w = serve(http.MethodGet, "/me/liked-posts", "")
+	var liked struct {
+		Data []models.Post `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&liked))
+	require.Len(t, liked.Data, 1)
+	assert.Equal(t, post.ID, liked.Data[0].ID)
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	router := &Router{
+		r: chi.NewRouter(),
+		config: &config.Config{ServerTimeouts: config.ServerTimeouts{
+			ReadHeader: 2 * time.Second,
+			Read:       10 * time.Second,
+			Write:      20 * time.Second,
+			Idle:       time.Minute,
+		}},
+	}
+
+	server := router.newServer(":9090")
+
+	assert.Equal(t, ":9090", server.Addr)
+	assert.Equal(t, router.r, server.Handler)
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, server.ReadTimeout)
+	assert.Equal(t, 20*time.Second, server.WriteTimeout)
+	assert.Equal(t, time.Minute, server.IdleTimeout)
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := chi.NewRouter()
+	mux.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	router := &Router{
+		r:      mux,
+		config: &config.Config{ServerTimeouts: config.ServerTimeouts{Shutdown: 5 * time.Second}},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	baseURL := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- router.serve(ctx, listener)
+	}()
+
+	resp, err := http.Get(baseURL + "/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// A request still running when shutdown begins is allowed to finish
+	slowStatus := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			slowStatus <- 0
+			return
+		}
+		resp.Body.Close()
+		slowStatus <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	assert.Equal(t, http.StatusOK, <-slowStatus)
+
+	_, err = http.Get(baseURL + "/ping")
+	assert.Error(t, err)
+}
+
+// seedPost inserts a user and one post by them
+func seedPost(t *testing.T, conn *sql.DB) (userID, postID int64) {
+	result, err := conn.Exec(`INSERT INTO users (username, email, password) VALUES ('author', 'author@example.com', 'hash')`)
+	require.NoError(t, err)
+	userID, err = result.LastInsertId()
+	require.NoError(t, err)
+
+	result, err = conn.Exec(`INSERT INTO posts (user_id, title, content, created_at) VALUES (?, 'Post', 'Content', CURRENT_TIMESTAMP)`, userID)
+	require.NoError(t, err)
+	postID, err = result.LastInsertId()
+	require.NoError(t, err)
+
+	return userID, postID
+}